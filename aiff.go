@@ -0,0 +1,127 @@
+package sointu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// aiffHeader writes the header of a stereo .aiff file with length stereo
+// samples of the given format into the bytes.Buffer; the big-endian samples
+// should follow. Integer samples are stored in a plain AIFF file, but float
+// samples need an AIFF-C file with the fl32 compression type, as the original
+// AIFF supports only integers. Assumes 44100 Hz sample rate.
+func aiffHeader(length int, format SampleFormat, buf *bytes.Buffer) {
+	// Refer to: http://www-mmsp.ece.mcgill.ca/Documents/AudioFormats/AIFF/AIFF.html
+	numChannels := 2
+	dataSize := length * numChannels * format.Bits() / 8
+	formType, commSize, fverSize := "AIFF", 18, 0
+	if format == SampleFloat32 {
+		formType, commSize, fverSize = "AIFC", 18+4+22, 12
+	}
+	buf.Write([]byte("FORM"))
+	binary.Write(buf, binary.BigEndian, uint32(4+fverSize+8+commSize+16+dataSize))
+	buf.Write([]byte(formType))
+	if fverSize > 0 {
+		buf.Write([]byte("FVER"))
+		binary.Write(buf, binary.BigEndian, uint32(4))
+		binary.Write(buf, binary.BigEndian, uint32(0xA2805140)) // AIFF-C version 1
+	}
+	buf.Write([]byte("COMM"))
+	binary.Write(buf, binary.BigEndian, uint32(commSize))
+	binary.Write(buf, binary.BigEndian, uint16(numChannels))
+	binary.Write(buf, binary.BigEndian, uint32(length)) // number of sample frames
+	binary.Write(buf, binary.BigEndian, uint16(format.Bits()))
+	writeExtended(buf, sampleRate)
+	if format == SampleFloat32 {
+		buf.Write([]byte("fl32"))
+		name := "32-bit floating point"
+		buf.WriteByte(byte(len(name))) // pascal string, padded to even length
+		buf.Write([]byte(name))
+		if len(name)%2 == 0 {
+			buf.WriteByte(0)
+		}
+	}
+	buf.Write([]byte("SSND"))
+	binary.Write(buf, binary.BigEndian, uint32(8+dataSize))
+	binary.Write(buf, binary.BigEndian, uint32(0)) // offset
+	binary.Write(buf, binary.BigEndian, uint32(0)) // block size
+}
+
+// writeExtended writes a positive integer as an 80-bit IEEE 754 extended
+// precision float, which AIFF uses for the sample rate.
+func writeExtended(buf *bytes.Buffer, value uint64) {
+	exponent := bits.Len64(value) - 1
+	binary.Write(buf, binary.BigEndian, uint16(16383+exponent))
+	binary.Write(buf, binary.BigEndian, value<<(63-exponent)) // explicit integer bit
+}
+
+// decodeAiff decodes the samples of an AIFF or AIFF-C file.
+func decodeAiff(b []byte) (decodedAudio, error) {
+	if len(b) < 12 || string(b[0:4]) != "FORM" || (string(b[8:12]) != "AIFF" && string(b[8:12]) != "AIFC") {
+		return decodedAudio{}, errors.New("not an AIFF file")
+	}
+	var frames, width int
+	compression := "NONE"
+	var ret decodedAudio
+	for pos := 12; pos+8 <= len(b); {
+		id := string(b[pos : pos+4])
+		size := int(binary.BigEndian.Uint32(b[pos+4:]))
+		pos += 8
+		if size < 0 || pos+size > len(b) {
+			size = len(b) - pos // tolerate truncated files
+		}
+		chunk := b[pos : pos+size]
+		pos += size + size&1 // chunks are padded to even length
+		switch id {
+		case "COMM":
+			if len(chunk) < 18 {
+				return decodedAudio{}, errors.New("COMM chunk too short")
+			}
+			ret.channels = int(binary.BigEndian.Uint16(chunk[0:]))
+			frames = int(binary.BigEndian.Uint32(chunk[2:]))
+			width = (int(binary.BigEndian.Uint16(chunk[6:])) + 7) / 8
+			ret.rate = int(math.Round(readExtended(chunk[8:18])))
+			if string(b[8:12]) == "AIFC" && len(chunk) >= 22 {
+				compression = string(chunk[18:22])
+			}
+		case "SSND":
+			if ret.channels < 1 {
+				return decodedAudio{}, errors.New("SSND chunk before COMM chunk")
+			}
+			if len(chunk) < 8 {
+				return decodedAudio{}, errors.New("SSND chunk too short")
+			}
+			data := chunk[min(8+int(binary.BigEndian.Uint32(chunk)), len(chunk)):] // skip the offset to the first sample
+			data = data[:min(len(data), frames*ret.channels*width)]
+			var err error
+			switch {
+			case (compression == "NONE" || compression == "twos") && width >= 1 && width <= 4:
+				ret.data, err = decodeSamples(data, width, binary.BigEndian, false, false)
+			case compression == "sowt" && width >= 1 && width <= 4: // little-endian AIFF-C
+				ret.data, err = decodeSamples(data, width, binary.LittleEndian, false, false)
+			case compression == "fl32" || compression == "FL32":
+				ret.data, err = decodeSamples(data, 4, binary.BigEndian, true, false)
+			case compression == "fl64" || compression == "FL64":
+				ret.data, err = decodeSamples(data, 8, binary.BigEndian, true, false)
+			default:
+				return decodedAudio{}, fmt.Errorf("unsupported AIFF compression %q with %v bytes per sample", compression, width)
+			}
+			return ret, err
+		}
+	}
+	return decodedAudio{}, errors.New("no SSND chunk found")
+}
+
+// readExtended reads an 80-bit IEEE 754 extended precision float.
+func readExtended(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b) & 0x7FFF)
+	value := math.Ldexp(float64(binary.BigEndian.Uint64(b[2:])), exponent-16383-63)
+	if b[0]&0x80 != 0 {
+		return -value
+	}
+	return value
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/rand/v2"
 	"time"
 )
 
@@ -81,8 +82,32 @@ type (
 	}
 
 	CPULoad float32
+
+	// SampleFormat is the format of the samples in an encoded audio file.
+	SampleFormat int
+
+	// AudioFileFormat is the container format of an encoded audio file.
+	AudioFileFormat int
+)
+
+const (
+	SampleFloat32 SampleFormat = iota // 32-bit IEEE floats
+	SampleInt16                       // 16-bit signed integers, dithered
+	SampleInt24                       // 24-bit signed integers, dithered
+	NumSampleFormats
+)
+
+const (
+	WavFile  AudioFileFormat = iota // RIFF WAVE
+	AiffFile                        // AIFF, or AIFF-C for float samples
+	FlacFile                        // FLAC, lossless compressed; integer samples only
+	RawFile                         // headerless little-endian samples
+	NumAudioFileFormats
 )
 
+// sampleRate is the sample rate of all the audio rendered by the synths.
+const sampleRate = 44100
+
 // Play plays the Song by first compiling the patch with the given Synther,
 // returning the stereo audio buffer as a result (and possible errors).
 func Play(synther Synther, song Song, progress func(float32)) (AudioBuffer, error) {
@@ -193,13 +218,7 @@ func (a *BufferSource) ReadAudio(buf AudioBuffer) error {
 // If pcm16 is set to true, the samples in the WAV-file will be 16-bit signed
 // integers; otherwise the samples will be 32-bit floats
 func (buffer AudioBuffer) Wav(pcm16 bool) ([]byte, error) {
-	buf := new(bytes.Buffer)
-	wavHeader(len(buffer)*2, pcm16, buf)
-	err := buffer.rawToBuffer(pcm16, buf)
-	if err != nil {
-		return nil, fmt.Errorf("Wav failed: %v", err)
-	}
-	return buf.Bytes(), nil
+	return buffer.Encode(WavFile, pcm16Format(pcm16))
 }
 
 // Raw converts an AudioBuffer into a raw audio file, returned as a []byte
@@ -208,14 +227,105 @@ func (buffer AudioBuffer) Wav(pcm16 bool) ([]byte, error) {
 // If pcm16 is set to true, the samples will be 16-bit signed integers;
 // otherwise the samples will be 32-bit floats
 func (buffer AudioBuffer) Raw(pcm16 bool) ([]byte, error) {
+	return buffer.Encode(RawFile, pcm16Format(pcm16))
+}
+
+// Encode converts an AudioBuffer into an audio file of the given file format
+// and sample format, returned as a []byte array. When converting to integer
+// samples, the samples are dithered with triangular (TPDF) noise, except exact
+// zeros. The dither is seeded, so the same buffer always encodes to the same
+// bytes.
+func (buffer AudioBuffer) Encode(file AudioFileFormat, format SampleFormat) ([]byte, error) {
+	if format < 0 || format >= NumSampleFormats {
+		return nil, fmt.Errorf("unknown sample format %v", int(format))
+	}
+	if !file.Supports(format) {
+		return nil, fmt.Errorf("%v files do not support %v samples", file, format)
+	}
 	buf := new(bytes.Buffer)
-	err := buffer.rawToBuffer(pcm16, buf)
+	var err error
+	switch file {
+	case WavFile:
+		wavHeader(len(buffer)*2, format, buf)
+		err = buffer.rawToBuffer(format, binary.LittleEndian, buf)
+	case AiffFile:
+		aiffHeader(len(buffer), format, buf)
+		err = buffer.rawToBuffer(format, binary.BigEndian, buf)
+	case FlacFile:
+		err = buffer.flacToBuffer(format, buf)
+	case RawFile:
+		err = buffer.rawToBuffer(format, binary.LittleEndian, buf)
+	default:
+		return nil, fmt.Errorf("unknown audio file format %v", int(file))
+	}
 	if err != nil {
-		return nil, fmt.Errorf("Raw failed: %v", err)
+		return nil, fmt.Errorf("encoding %v failed: %v", file, err)
 	}
 	return buf.Bytes(), nil
 }
 
+func pcm16Format(pcm16 bool) SampleFormat {
+	if pcm16 {
+		return SampleInt16
+	}
+	return SampleFloat32
+}
+
+// Bits returns the number of bits in a sample.
+func (f SampleFormat) Bits() int {
+	switch f {
+	case SampleInt16:
+		return 16
+	case SampleInt24:
+		return 24
+	}
+	return 32
+}
+
+func (f SampleFormat) String() string {
+	switch f {
+	case SampleFloat32:
+		return "32-bit float"
+	case SampleInt16:
+		return "16-bit"
+	case SampleInt24:
+		return "24-bit"
+	}
+	return fmt.Sprintf("SampleFormat(%d)", int(f))
+}
+
+// Supports returns true if the file format can store samples of the format.
+func (f AudioFileFormat) Supports(format SampleFormat) bool {
+	return f != FlacFile || format != SampleFloat32
+}
+
+// Extension returns the file extension of the file format, with the dot.
+func (f AudioFileFormat) Extension() string {
+	switch f {
+	case WavFile:
+		return ".wav"
+	case AiffFile:
+		return ".aiff"
+	case FlacFile:
+		return ".flac"
+	}
+	return ".raw"
+}
+
+func (f AudioFileFormat) String() string {
+	switch f {
+	case WavFile:
+		return "WAV"
+	case AiffFile:
+		return "AIFF"
+	case FlacFile:
+		return "FLAC"
+	case RawFile:
+		return "raw"
+	}
+	return fmt.Sprintf("AudioFileFormat(%d)", int(f))
+}
+
 func (p *CPULoad) Update(duration time.Duration, frames int64) {
 	if frames <= 0 {
 		return // no frames rendered, so cannot compute CPU load
@@ -227,17 +337,29 @@ func (p *CPULoad) Update(duration time.Duration, frames int64) {
 	*p = CPULoad(float64(*p)*alpha + newload*(1-alpha))
 }
 
-func (data AudioBuffer) rawToBuffer(pcm16 bool, buf *bytes.Buffer) error {
+func (data AudioBuffer) rawToBuffer(format SampleFormat, order binary.ByteOrder, buf *bytes.Buffer) error {
 	var err error
-	if pcm16 {
+	switch format {
+	case SampleInt16:
 		int16data := make([][2]int16, len(data))
-		for i, v := range data {
-			int16data[i][0] = int16(clamp(int(v[0]*math.MaxInt16), math.MinInt16, math.MaxInt16))
-			int16data[i][1] = int16(clamp(int(v[1]*math.MaxInt16), math.MinInt16, math.MaxInt16))
+		for i, v := range data.quantize(16) {
+			int16data[i] = [2]int16{int16(v[0]), int16(v[1])}
 		}
-		err = binary.Write(buf, binary.LittleEndian, int16data)
-	} else {
-		err = binary.Write(buf, binary.LittleEndian, data)
+		err = binary.Write(buf, order, int16data)
+	case SampleInt24:
+		b := make([]byte, 0, len(data)*6)
+		for _, v := range data.quantize(24) {
+			for _, s := range v {
+				if order == binary.BigEndian {
+					b = append(b, byte(s>>16), byte(s>>8), byte(s))
+				} else {
+					b = append(b, byte(s), byte(s>>8), byte(s>>16))
+				}
+			}
+		}
+		_, err = buf.Write(b)
+	default:
+		err = binary.Write(buf, order, data)
 	}
 	if err != nil {
 		return fmt.Errorf("could not binary write data to binary buffer: %v", err)
@@ -245,25 +367,48 @@ func (data AudioBuffer) rawToBuffer(pcm16 bool, buf *bytes.Buffer) error {
 	return nil
 }
 
-// wavHeader writes a wave header for either float32 or int16 .wav file into the
-// bytes.buffer. It needs to know the length of the buffer and assumes stereo
-// sound, so the length in stereo samples (L + R) is bufferlength / 2. If pcm16
-// = true, then the header is for int16 audio; pcm16 = false means the header is
-// for float32 audio. Assumes 44100 Hz sample rate.
-func wavHeader(bufferLength int, pcm16 bool, buf *bytes.Buffer) {
+// quantize converts the samples into signed integers of the given number of
+// bits, so that 1.0 maps to the largest integer. Before rounding, triangular
+// (TPDF) dither noise of +-1 LSB is added, which makes the quantization error
+// independent of the signal: instead of distortion, e.g. fade-outs get a
+// constant noise floor. Exact zeros are not dithered, so that digital silence,
+// e.g. before the first note, stays silent and compresses well. The samples are
+// then clamped to the range of the integers.
+func (data AudioBuffer) quantize(bits int) [][2]int32 {
+	scale := float64(int32(1)<<(bits-1) - 1)
+	rng := rand.New(rand.NewPCG(0x50, 0x1d))
+	ret := make([][2]int32, len(data))
+	for i, v := range data {
+		for c := range v {
+			if v[c] == 0 {
+				continue
+			}
+			s := math.Round(float64(v[c])*scale + rng.Float64() - rng.Float64())
+			if s != s { // NaN
+				s = 0
+			}
+			ret[i][c] = int32(min(max(s, -scale-1), scale))
+		}
+	}
+	return ret
+}
+
+// wavHeader writes a wave header for a .wav file with samples of the given
+// format into the bytes.buffer. It needs to know the length of the buffer and
+// assumes stereo sound, so the length in stereo samples (L + R) is
+// bufferlength / 2. Assumes 44100 Hz sample rate.
+func wavHeader(bufferLength int, format SampleFormat, buf *bytes.Buffer) {
 	// Refer to: http://www-mmsp.ece.mcgill.ca/Documents/AudioFormats/WAVE/WAVE.html
 	numChannels := 2
-	sampleRate := 44100
-	var bytesPerSample, chunkSize, fmtChunkSize, waveFormat int
+	bytesPerSample := format.Bits() / 8
+	var chunkSize, fmtChunkSize, waveFormat int
 	var factChunk bool
-	if pcm16 {
-		bytesPerSample = 2
+	if format != SampleFloat32 {
 		chunkSize = 36 + bytesPerSample*bufferLength
 		fmtChunkSize = 16
 		waveFormat = 1 // PCM
 		factChunk = false
 	} else {
-		bytesPerSample = 4
 		chunkSize = 50 + bytesPerSample*bufferLength
 		fmtChunkSize = 18
 		waveFormat = 3 // IEEE float
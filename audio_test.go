@@ -0,0 +1,405 @@
+package sointu_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"testing"
+
+	"github.com/vsariola/sointu"
+)
+
+// testBuffer returns a stereo buffer with a sine and a cosine, a silent part,
+// a clipping part and a NaN, not a multiple of the FLAC block size long.
+func testBuffer() sointu.AudioBuffer {
+	ret := make(sointu.AudioBuffer, 10000)
+	for i := range ret {
+		t := float64(i) * 2 * math.Pi * 440 / 44100
+		ret[i] = [2]float32{float32(0.9 * math.Sin(t)), float32(0.5 * math.Cos(3*t))}
+	}
+	clear(ret[4096:5000])
+	for i := 6000; i < 6100; i++ {
+		ret[i] = [2]float32{1.5, -1.5}
+	}
+	ret[7000][0] = float32(math.NaN())
+	return ret
+}
+
+// clipped is what the buffer is expected to be after encoding and decoding,
+// with out-of-range samples clamped and NaNs zeroed.
+func clipped(v float32) float32 {
+	if v != v {
+		return 0
+	}
+	return min(max(v, -1), 1)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	buffer := testBuffer()
+	for file := sointu.AudioFileFormat(0); file < sointu.NumAudioFileFormats; file++ {
+		for format := sointu.SampleFormat(0); format < sointu.NumSampleFormats; format++ {
+			t.Run(file.String()+" "+format.String(), func(t *testing.T) {
+				encoded, err := buffer.Encode(file, format)
+				if !file.Supports(format) {
+					if err == nil {
+						t.Errorf("expected an error, as %v does not support %v samples", file, format)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Encode failed: %v", err)
+				}
+				var decoded sointu.AudioBuffer
+				switch file {
+				case sointu.WavFile, sointu.AiffFile:
+					decoded = decodeFile(t, encoded, file, format)
+				case sointu.FlacFile:
+					decoded = decodeFlac(t, encoded)
+				case sointu.RawFile:
+					decoded = decodeRaw(encoded, format, binary.LittleEndian)
+				}
+				if len(decoded) != len(buffer) {
+					t.Fatalf("expected %v samples, got %v", len(buffer), len(decoded))
+				}
+				tolerance := 0.0
+				if format != sointu.SampleFloat32 {
+					tolerance = 2.5 / float64(int(1)<<(format.Bits()-1)) // rounding, dither and the scale
+				}
+				for i := range buffer {
+					for c := range 2 {
+						expected := clipped(buffer[i][c])
+						if format == sointu.SampleFloat32 {
+							expected = buffer[i][c] // floats are stored as is
+						}
+						if got := decoded[i][c]; (got != got) != (expected != expected) || math.Abs(float64(got-expected)) > tolerance {
+							t.Fatalf("sample %v channel %v: expected %v, got %v", i, c, expected, decoded[i][c])
+						}
+						if expected == 0 && decoded[i][c] != 0 {
+							t.Fatalf("sample %v channel %v: silence should not be dithered, got %v", i, c, decoded[i][c])
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// decodeFile decodes the sample data chunk of a WAV or AIFF file.
+func decodeFile(t *testing.T, b []byte, file sointu.AudioFileFormat, format sointu.SampleFormat) sointu.AudioBuffer {
+	order, id := binary.ByteOrder(binary.LittleEndian), "data"
+	if file == sointu.AiffFile {
+		order, id = binary.BigEndian, "SSND"
+	}
+	for pos := 12; pos+8 <= len(b); {
+		size := int(order.Uint32(b[pos+4:]))
+		if pos+8+size > len(b) {
+			t.Fatalf("chunk %q of %v bytes does not fit in the file", b[pos:pos+4], size)
+		}
+		if string(b[pos:pos+4]) == id {
+			data := b[pos+8 : pos+8+size]
+			if file == sointu.AiffFile {
+				data = data[8:] // skip the offset and the block size
+			}
+			return decodeRaw(data, format, order)
+		}
+		pos += 8 + size + size&1
+	}
+	t.Fatalf("no %v chunk found", id)
+	return nil
+}
+
+func decodeRaw(b []byte, format sointu.SampleFormat, order binary.ByteOrder) sointu.AudioBuffer {
+	width := format.Bits() / 8
+	ret := make(sointu.AudioBuffer, len(b)/width/2)
+	for i := range ret {
+		for c := range 2 {
+			s := b[(i*2+c)*width:]
+			switch format {
+			case sointu.SampleFloat32:
+				ret[i][c] = math.Float32frombits(order.Uint32(s))
+			case sointu.SampleInt16:
+				ret[i][c] = float32(int16(order.Uint16(s))) / (1 << 15)
+			case sointu.SampleInt24:
+				if order == binary.BigEndian {
+					ret[i][c] = float32(int32(uint32(s[2])<<8|uint32(s[1])<<16|uint32(s[0])<<24)) / (1 << 31)
+				} else {
+					ret[i][c] = float32(int32(uint32(s[0])<<8|uint32(s[1])<<16|uint32(s[2])<<24)) / (1 << 31)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func TestFlacMatchesWav(t *testing.T) {
+	buffer := testBuffer()
+	for _, format := range []sointu.SampleFormat{sointu.SampleInt16, sointu.SampleInt24} {
+		wav, err := buffer.Encode(sointu.WavFile, format)
+		if err != nil {
+			t.Fatalf("could not encode %v WAV: %v", format, err)
+		}
+		flac, err := buffer.Encode(sointu.FlacFile, format)
+		if err != nil {
+			t.Fatalf("could not encode %v FLAC: %v", format, err)
+		}
+		fromWav := decodeFile(t, wav, sointu.WavFile, format)
+		fromFlac := decodeFlac(t, flac)
+		for i := range fromWav {
+			if fromWav[i] != fromFlac[i] {
+				t.Fatalf("%v: the dithered samples should be the same in FLAC and WAV, but sample %v is %v in FLAC and %v in WAV", format, i, fromFlac[i], fromWav[i])
+			}
+		}
+		if len(flac) >= len(wav) {
+			t.Errorf("%v: FLAC should be smaller than WAV, got %v and %v bytes", format, len(flac), len(wav))
+		}
+	}
+}
+
+func TestFlacFrameNumbers(t *testing.T) {
+	// frame numbers of 128 and above take several bytes in the frame header
+	buffer := make(sointu.AudioBuffer, 200*4096+1)
+	for i := range buffer {
+		if i%4096 == 0 {
+			buffer[i] = [2]float32{0.5, -0.5}
+		}
+	}
+	flac, err := buffer.Encode(sointu.FlacFile, sointu.SampleInt16)
+	if err != nil {
+		t.Fatalf("could not encode FLAC: %v", err)
+	}
+	if decoded := decodeFlac(t, flac); len(decoded) != len(buffer) || decoded[199*4096][0] == 0 {
+		t.Errorf("the last frames were not decoded correctly")
+	}
+}
+
+// flacReader reads the big-endian bit stream of a FLAC file.
+type flacReader struct {
+	t   *testing.T
+	b   []byte
+	pos int // in bits
+}
+
+func (r *flacReader) read(n int) uint64 {
+	var ret uint64
+	for range n {
+		if r.pos/8 >= len(r.b) {
+			r.t.Fatalf("unexpected end of the FLAC stream")
+		}
+		ret = ret<<1 | uint64(r.b[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return ret
+}
+
+func (r *flacReader) readSigned(n int) int64 {
+	return int64(r.read(n)<<(64-n)) >> (64 - n)
+}
+
+func (r *flacReader) bytePos() int {
+	if r.pos%8 != 0 {
+		r.t.Fatalf("the stream should be byte aligned at bit %v", r.pos)
+	}
+	return r.pos / 8
+}
+
+// decodeFlac decodes a FLAC file written by AudioBuffer.Encode and checks its
+// structure: the STREAMINFO block, the frame headers, frame numbers and CRCs,
+// and the MD5 of the decoded samples.
+func decodeFlac(t *testing.T, b []byte) sointu.AudioBuffer {
+	t.Helper()
+	r := &flacReader{t: t, b: b}
+	if string(b[:4]) != "fLaC" {
+		t.Fatalf("missing the fLaC marker")
+	}
+	r.pos = 32
+	if last, typ, length := r.read(1), r.read(7), r.read(24); last != 1 || typ != 0 || length != 34 {
+		t.Fatalf("expected STREAMINFO as the only metadata block, got last %v type %v length %v", last, typ, length)
+	}
+	minBlock, maxBlock := r.read(16), r.read(16)
+	minFrame, maxFrame := int(r.read(24)), int(r.read(24))
+	rate, channels, bps, total := r.read(20), r.read(3)+1, int(r.read(5)+1), int(r.read(36))
+	if minBlock != 4096 || maxBlock != 4096 || rate != 44100 || channels != 2 || (bps != 16 && bps != 24) {
+		t.Fatalf("wrong STREAMINFO: block size %v-%v, rate %v, channels %v, bps %v", minBlock, maxBlock, rate, channels, bps)
+	}
+	md5sum := b[r.bytePos() : r.bytePos()+16]
+	r.pos += 128
+	var samples [][2]int64
+	gotMinFrame, gotMaxFrame := math.MaxInt, 0
+	for frame := 0; r.pos/8 < len(b); frame++ {
+		start := r.bytePos()
+		if sync := r.read(14); sync != 0b11111111111110 {
+			t.Fatalf("frame %v: wrong sync code %b", frame, sync)
+		}
+		if reserved, variable := r.read(1), r.read(1); reserved != 0 || variable != 0 {
+			t.Fatalf("frame %v: expected fixed block size", frame)
+		}
+		blockCode, rateCode, assignment, sizeCode := r.read(4), r.read(4), r.read(4), r.read(3)
+		if rateCode != 0b1001 || sizeCode != map[int]uint64{16: 0b100, 24: 0b110}[bps] || r.read(1) != 0 {
+			t.Fatalf("frame %v: wrong sample rate %b or size %b", frame, rateCode, sizeCode)
+		}
+		if n := bits.LeadingZeros8(^byte(r.read(8))); n > 0 { // utf-8 coded frame number
+			value := uint64(r.b[r.bytePos()-1]) & (0x7F >> n)
+			for range n - 1 {
+				value = value<<6 | r.read(8)&0x3F
+			}
+			if int(value) != frame {
+				t.Fatalf("expected frame number %v, got %v", frame, value)
+			}
+		} else if int(r.b[r.bytePos()-1]) != frame {
+			t.Fatalf("expected frame number %v, got %v", frame, r.b[r.bytePos()-1])
+		}
+		blockSize := 4096
+		switch blockCode {
+		case 0b1100:
+		case 0b0111:
+			blockSize = int(r.read(16)) + 1
+		default:
+			t.Fatalf("frame %v: unexpected block size code %b", frame, blockCode)
+		}
+		if crc := flacCRC(b[start:r.bytePos()], 8, 0x07); uint64(crc) != r.read(8) {
+			t.Fatalf("frame %v: wrong header CRC-8", frame)
+		}
+		var sub [2][]int64
+		for c := range sub {
+			subBps := bps
+			if (assignment == 0b1000 && c == 1) || (assignment == 0b1001 && c == 0) || (assignment == 0b1010 && c == 1) {
+				subBps++ // side channel
+			}
+			sub[c] = decodeFlacSubframe(r, blockSize, subBps)
+		}
+		for len(b) > r.pos/8 && r.pos%8 != 0 {
+			if r.read(1) != 0 {
+				t.Fatalf("frame %v: padding should be zeros", frame)
+			}
+		}
+		if crc := flacCRC(b[start:r.bytePos()], 16, 0x8005); uint64(crc) != r.read(16) {
+			t.Fatalf("frame %v: wrong CRC-16", frame)
+		}
+		gotMinFrame, gotMaxFrame = min(gotMinFrame, r.bytePos()-start), max(gotMaxFrame, r.bytePos()-start)
+		for i := range blockSize {
+			a, s := sub[0][i], sub[1][i]
+			switch assignment {
+			case 0b0001:
+				samples = append(samples, [2]int64{a, s})
+			case 0b1000:
+				samples = append(samples, [2]int64{a, a - s})
+			case 0b1001:
+				samples = append(samples, [2]int64{a + s, s})
+			case 0b1010:
+				mid := a<<1 | s&1
+				samples = append(samples, [2]int64{(mid + s) >> 1, (mid - s) >> 1})
+			default:
+				t.Fatalf("frame %v: unexpected channel assignment %b", frame, assignment)
+			}
+		}
+	}
+	if len(samples) != total {
+		t.Fatalf("STREAMINFO says %v samples, but the frames have %v", total, len(samples))
+	}
+	if gotMinFrame != minFrame || gotMaxFrame != maxFrame {
+		t.Errorf("STREAMINFO says frame sizes %v-%v, but the frames are %v-%v", minFrame, maxFrame, gotMinFrame, gotMaxFrame)
+	}
+	hash := md5.New()
+	ret := make(sointu.AudioBuffer, len(samples))
+	for i, v := range samples {
+		for c, s := range v {
+			for k := 0; k < bps; k += 8 {
+				hash.Write([]byte{byte(s >> k)})
+			}
+			ret[i][c] = float32(s<<(32-bps)) / (1 << 31)
+		}
+	}
+	if !bytes.Equal(hash.Sum(nil), md5sum) {
+		t.Errorf("the MD5 of the decoded samples does not match STREAMINFO")
+	}
+	return ret
+}
+
+func decodeFlacSubframe(r *flacReader, n, bps int) []int64 {
+	if r.read(1) != 0 {
+		r.t.Fatalf("subframe padding should be zero")
+	}
+	typ := r.read(6)
+	if r.read(1) != 0 {
+		r.t.Fatalf("expected no wasted bits")
+	}
+	ret := make([]int64, n)
+	switch {
+	case typ == 0: // constant
+		v := r.readSigned(bps)
+		for i := range ret {
+			ret[i] = v
+		}
+	case typ == 1: // verbatim
+		for i := range ret {
+			ret[i] = r.readSigned(bps)
+		}
+	case typ >= 8 && typ <= 12: // fixed
+		order := int(typ - 8)
+		for i := range order {
+			ret[i] = r.readSigned(bps)
+		}
+		method := r.read(2)
+		if method > 1 {
+			r.t.Fatalf("unexpected residual coding method %v", method)
+		}
+		paramBits := 4 + int(method)
+		partOrder := int(r.read(4))
+		i := order
+		for p := range 1 << partOrder {
+			k := int(r.read(paramBits))
+			if k == 1<<paramBits-1 {
+				r.t.Fatalf("unexpected escaped partition")
+			}
+			count := n >> partOrder
+			if p == 0 {
+				count -= order
+			}
+			for range count {
+				q := 0
+				for r.read(1) == 0 {
+					q++
+				}
+				u := uint64(q)<<k | r.read(k)
+				ret[i] = int64(u>>1) ^ -int64(u&1)
+				i++
+			}
+		}
+		for i := order; i < n; i++ {
+			s := ret
+			switch order {
+			case 1:
+				s[i] += s[i-1]
+			case 2:
+				s[i] += 2*s[i-1] - s[i-2]
+			case 3:
+				s[i] += 3*s[i-1] - 3*s[i-2] + s[i-3]
+			case 4:
+				s[i] += 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+			}
+		}
+	default:
+		r.t.Fatalf("unexpected subframe type %b", typ)
+	}
+	return ret
+}
+
+// flacCRC computes the CRC-8 or CRC-16 of FLAC with the given polynomial.
+func flacCRC(data []byte, width int, poly uint32) uint32 {
+	var crc uint32
+	top := uint32(1) << (width - 1)
+	for _, b := range data {
+		crc ^= uint32(b) << (width - 8)
+		for range 8 {
+			if crc&top != 0 {
+				crc = crc<<1 ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+		crc &= 1<<width - 1
+	}
+	return crc
+}
@@ -3,18 +3,22 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/vsariola/sointu"
 	"github.com/vsariola/sointu/version"
+	"github.com/vsariola/sointu/vm"
 	"github.com/vsariola/sointu/vm/compiler"
 )
 
@@ -38,12 +42,22 @@ func main() {
 	library := flag.Bool("a", false, "Compile Sointu into a library. Input files are not needed.")
 	jsonOut := flag.Bool("j", false, "Output the song as .json file instead of compiling.")
 	yamlOut := flag.Bool("y", false, "Output the song as .yml file instead of compiling.")
-	tmplDir := flag.String("t", "", "When compiling, use the templates in this directory instead of the standard templates.")
+	bytecodeOut := flag.Bool("b", false, "Output the patch bytecode as .bytecode.json file instead of compiling, for loading it into the wasm library.")
+	syncOut := flag.String("sync", "", "Render the values of the sync units offline and output them instead of compiling, in these comma separated formats: csv, json, rocket. rocket outputs one GNU Rocket .track file per sync. With -r, the current row is the first sync.")
+	syncRate := flag.Float64("syncrate", vm.DefaultSyncRate, "Number of frames per second, when outputting syncs. By default, the same as the sync buffer of the compiled players.")
+	sizeOut := flag.Bool("size", false, "Print the estimated packed sizes of the data of the compiled song (patterns, bytecode, delay table and samples) instead of compiling. The sizes are estimated with a context mixing model similar to the intro packers (Crinkler, Squishy).")
+	tmplDir := flag.String("t", "", "When compiling, use the templates in this directory instead of the standard templates. The directory is checked to have all the templates needed before compiling.")
+	listTemplates := flag.Bool("listtemplates", false, "List the standard templates of the target architecture and exit.")
+	exportTemplates := flag.String("exporttemplates", "", "Write the standard templates of the target architecture to this directory and exit. The templates can then be modified and used with -t.")
 	outPath := flag.String("o", "", "Directory or filename where to write compiled code. Extension is ignored. Directory and its parents are created if needed. By default, everything is placed in the same directory where the original song file is.")
 	extensionsOut := flag.String("e", "", "Output only the compiled files with these comma separated extensions. For example: h,asm")
-	targetArch := flag.String("arch", runtime.GOARCH, "Target architecture. Defaults to OS architecture. Possible values: 386, amd64, wasm")
+	targetArch := flag.String("arch", runtime.GOARCH, "Target architecture. Defaults to OS architecture. Possible values: 386, amd64, wasm, c, go")
 	output16bit := flag.Bool("i", false, "Compiled song should output 16-bit integers, instead of floats.")
-	targetOs := flag.String("os", runtime.GOOS, "Target OS. Defaults to current OS. Possible values: windows, darwin, linux. Anything else is assumed linuxy. Ignored when targeting wasm.")
+	targetOs := flag.String("os", runtime.GOOS, "Target OS. Defaults to current OS. Possible values: windows, darwin, linux. Anything else is assumed linuxy. Ignored when targeting wasm, c or go.")
+	transpose := flag.Bool("transpose", false, "Reuse the patterns that are transpositions of other patterns, storing a transposition for each pattern in the tracks. Reports the bytes saved. Songs where nothing is saved compile as without the flag.")
+	goPackage := flag.String("pkg", "song", "Package name of the generated Go code. Used only when targeting go.")
+	manifestOut := flag.String("manifest", "", "Write a JSON manifest to this file, listing for each song the generated files and their sizes, the features and voices used, the sizes of the data sections and the warnings. Use - for standard output.")
+	compareManifest := flag.String("compare", "", "Compare the sizes of the songs to the ones in this manifest, written earlier with -manifest, and print the size deltas.")
 	versionFlag := flag.Bool("v", false, "Print version.")
 	flag.Usage = printUsage
 	flag.Parse()
@@ -51,11 +65,40 @@ func main() {
 		fmt.Println(version.VersionOrHash)
 		os.Exit(0)
 	}
+	if *listTemplates || *exportTemplates != "" {
+		if *listTemplates {
+			names, err := compiler.EmbeddedTemplates(*targetArch)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "could not list the templates: %v\n", err)
+				os.Exit(1)
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+		}
+		if *exportTemplates != "" {
+			if err := compiler.ExportTemplates(*targetArch, *exportTemplates); err != nil {
+				fmt.Fprintf(os.Stderr, "could not export the templates: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(0)
+	}
 	if (flag.NArg() == 0 && !*library) || *help {
 		flag.Usage()
 		os.Exit(0)
 	}
-	compile := !*jsonOut && !*yamlOut // if the user gives nothing to output, then the default behaviour is to compile the file
+	var syncFormats []string
+	if *syncOut != "" {
+		syncFormats = strings.Split(*syncOut, ",")
+		for _, format := range syncFormats {
+			if !slices.Contains(vm.SyncFormats, format) {
+				fmt.Fprintf(os.Stderr, "unknown sync format %v; possible values: %v\n", format, strings.Join(vm.SyncFormats, ", "))
+				os.Exit(1)
+			}
+		}
+	}
+	compile := !*jsonOut && !*yamlOut && !*bytecodeOut && len(syncFormats) == 0 && !*sizeOut // if the user gives nothing to output, then the default behaviour is to compile the file
 	var comp *compiler.Compiler
 	if compile || *library {
 		var err error
@@ -68,9 +111,37 @@ func main() {
 			fmt.Fprintf(os.Stderr, `error creating compiler: %v`, err)
 			os.Exit(1)
 		}
+		if *tmplDir != "" {
+			var missing []string
+			if *library {
+				missing = comp.MissingTemplates(true)
+			}
+			if compile && flag.NArg() > 0 {
+				missing = append(missing, comp.MissingTemplates(false)...)
+			}
+			if len(missing) > 0 {
+				fmt.Fprintf(os.Stderr, "the template directory %v is missing templates: %v\n", *tmplDir, strings.Join(missing, ", "))
+				os.Exit(1)
+			}
+		}
+		comp.GoPackage = *goPackage
+		comp.TransposePatterns = *transpose
+	}
+	var oldManifest *Manifest
+	if *compareManifest != "" {
+		var err error
+		if oldManifest, err = readManifest(*compareManifest); err != nil {
+			fmt.Fprintf(os.Stderr, "could not read the manifest to compare to: %v\n", err)
+			os.Exit(1)
+		}
 	}
+	manifest := &Manifest{Version: version.VersionOrHash, Arch: *targetArch, OS: *targetOs}
+	var entry *ManifestEntry // the manifest entry of the song being processed
 	output := func(filename string, extension string, contents []byte) error {
 		if *stdout {
+			if entry != nil {
+				entry.Files = append(entry.Files, ManifestFile{extension, len(contents)})
+			}
 			fmt.Print(string(contents))
 			return nil
 		}
@@ -99,6 +170,9 @@ func main() {
 		}
 		name = strings.TrimSuffix(name, filepath.Ext(name)) + extension
 		f := filepath.Join(dir, name)
+		if entry != nil {
+			entry.Files = append(entry.Files, ManifestFile{f, len(contents)})
+		}
 		original, err := ioutil.ReadFile(f)
 		if err == nil {
 			if bytes.Compare(original, contents) == 0 {
@@ -123,7 +197,7 @@ func main() {
 		}
 		return nil
 	}
-	process := func(filename string) error {
+	processSong := func(filename string) error {
 		inputBytes, err := ioutil.ReadFile(filename)
 		if err != nil {
 			return fmt.Errorf("could not read file %v: %v", filename, err)
@@ -134,11 +208,22 @@ func main() {
 				return fmt.Errorf("song could not be unmarshaled as a .json (%v) or .yml (%v)", errJSON, errYaml)
 			}
 		}
+		if err := song.Patch.LoadSamples(filepath.Dir(filename)); err != nil {
+			return fmt.Errorf("could not load the samples of %v: %v", filename, err)
+		}
 		if song.RowsPerBeat == 0 {
 			song.RowsPerBeat = 4
 		}
 		if song.Score.Length == 0 {
 			song.Score.Length = len(song.Score.Tracks[0].Patterns)
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("the song has no length; using %v patterns", song.Score.Length))
+		}
+		if compile && (*targetArch == "386" || *targetArch == "amd64") && song.Patch.NumThreads() > 1 &&
+			(*rowsync || slices.Contains(vm.NecessaryFeaturesFor(song.Patch).Instructions(), "sync")) {
+			entry.Warnings = append(entry.Warnings, "syncs are not supported in multithreaded players; rendering all instruments on one thread")
+		}
+		if *manifestOut != "" || oldManifest != nil { // estimating the sizes is slow, so only when they are needed
+			entry.describeSong(&song, *transpose)
 		}
 		var compiledPlayer map[string]string
 		if compile {
@@ -147,11 +232,17 @@ func main() {
 			if err != nil {
 				return fmt.Errorf("compiling player failed: %v", err)
 			}
+			if *transpose {
+				if patterns, sequences, err := compiler.ConstructPatterns(&song); err == nil {
+					_, _, _, saved := compiler.TransposePatterns(patterns, sequences)
+					fmt.Fprintf(os.Stderr, "%v: transposed patterns saved %v bytes\n", filename, saved)
+				}
+			}
 			if len(*extensionsOut) > 0 {
 				compiledPlayer = filterExtensions(compiledPlayer, strings.Split(*extensionsOut, ","))
 			}
-			for extension, code := range compiledPlayer {
-				if err := output(filename, extension, []byte(code)); err != nil {
+			for _, extension := range slices.Sorted(maps.Keys(compiledPlayer)) {
+				if err := output(filename, extension, []byte(compiledPlayer[extension])); err != nil {
 					return fmt.Errorf("error outputting %v file: %v", extension, err)
 				}
 			}
@@ -174,8 +265,58 @@ func main() {
 				return fmt.Errorf("error outputting yaml file: %v", err)
 			}
 		}
+		if *bytecodeOut {
+			bytecode, err := libraryBytecode(song)
+			if err != nil {
+				return fmt.Errorf("could not encode the patch for the wasm library: %v", err)
+			}
+			jsonBytecode, err := json.Marshal(bytecode)
+			if err != nil {
+				return fmt.Errorf("could not marshal the bytecode as json file: %v", err)
+			}
+			if err := output(filename, ".bytecode.json", jsonBytecode); err != nil {
+				return fmt.Errorf("error outputting bytecode file: %v", err)
+			}
+		}
+		if *sizeOut {
+			sizes, err := compiler.EstimateSizes(&song, *transpose)
+			if err != nil {
+				return fmt.Errorf("could not estimate the sizes: %v", err)
+			}
+			fmt.Printf("%v:\n", filename)
+			for _, s := range sizes {
+				fmt.Printf("  %-12v %7d bytes, packed ~%7.1f bytes\n", s.Name, s.Raw, s.Packed)
+			}
+		}
+		if len(syncFormats) > 0 {
+			syncs, err := vm.RenderSyncs(song, *syncRate, *rowsync)
+			if err != nil {
+				return fmt.Errorf("could not render the syncs: %v", err)
+			}
+			for _, format := range syncFormats {
+				files, err := syncs.Files(format)
+				if err != nil {
+					return fmt.Errorf("could not convert the syncs: %v", err)
+				}
+				for suffix, contents := range files {
+					if err := output(filename, suffix, contents); err != nil {
+						return fmt.Errorf("error outputting sync file: %v", err)
+					}
+				}
+			}
+		}
 		return nil
 	}
+	process := func(filename string) error {
+		entry = &ManifestEntry{Song: filename}
+		err := processSong(filename)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		manifest.Songs = append(manifest.Songs, *entry)
+		entry = nil
+		return err
+	}
 	retval := 0
 	if *library {
 		compiledLibrary, err := comp.Library()
@@ -216,6 +357,24 @@ func main() {
 					retval = 1
 				}
 			}
+		} else if _, err := os.Stat(param); err != nil && strings.ContainsAny(param, "*?[") {
+			files, err := filepath.Glob(param)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "could not glob the pattern %v: %v\n", param, err)
+				retval = 1
+				continue
+			}
+			if len(files) == 0 {
+				fmt.Fprintf(os.Stderr, "no files match the pattern %v\n", param)
+				retval = 1
+			}
+			for _, file := range files {
+				err := process(file)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "could not process file %v: %v\n", file, err)
+					retval = 1
+				}
+			}
 		} else {
 			err := process(param)
 			if err != nil {
@@ -224,10 +383,57 @@ func main() {
 			}
 		}
 	}
+	if *manifestOut != "" {
+		jsonManifest, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not marshal the manifest: %v\n", err)
+			retval = 1
+		} else if *manifestOut == "-" {
+			fmt.Println(string(jsonManifest))
+		} else if err := os.WriteFile(*manifestOut, append(jsonManifest, '\n'), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write the manifest: %v\n", err)
+			retval = 1
+		}
+	}
+	if oldManifest != nil {
+		w := os.Stdout
+		if *manifestOut == "-" {
+			w = os.Stderr // keep the manifest on standard output parseable
+		}
+		printSizeDeltas(w, oldManifest, manifest)
+	}
 	os.Exit(retval)
 }
 
+// libraryBytecode encodes the patch of the song for the wasm library, which
+// has all the features compiled in. The byte slices are converted to ints, so
+// that they are marshaled as arrays instead of base64 strings.
+func libraryBytecode(song sointu.Song) (any, error) {
+	bytecode, err := vm.NewBytecode(song.Patch, vm.AllFeatures{}, song.BPM)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytecode.SampleOffsets) > 0 || len(bytecode.WavetableOffsets) > 0 || bytecode.NoteTable != nil {
+		return nil, errors.New("samples, wavetables and tunings are not supported by the wasm library")
+	}
+	toInts := func(b []byte) []int {
+		ret := make([]int, len(b))
+		for i, v := range b {
+			ret[i] = int(v)
+		}
+		return ret
+	}
+	return struct {
+		Opcodes          []int    `json:"opcodes"`
+		Operands         []int    `json:"operands"`
+		DelayTimes       []uint16 `json:"delayTimes"`
+		NumDelayLines    int      `json:"numDelayLines"`
+		NumVoices        uint32   `json:"numVoices"`
+		PolyphonyBitmask uint32   `json:"polyphonyBitmask"`
+	}{toInts(bytecode.Opcodes), toInts(bytecode.Operands), bytecode.DelayTimes, song.Patch.NumDelayLines(), bytecode.NumVoices, bytecode.PolyphonyBitmask}, nil
+}
+
 func printUsage() {
-	fmt.Fprintf(os.Stderr, "Sointu compiler. Input .yml or .json songs, outputs compiled songs (e.g. .asm and .h files).\nUsage: %s [flags] [path ...]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Sointu compiler. Input .yml or .json songs, outputs compiled songs (e.g. .asm and .h files).\nUsage: %s [flags] [path ...]\nA path can be a song, a directory of songs or a glob pattern, like songs/*.yml.\n", os.Args[0])
 	flag.PrintDefaults()
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm"
+	"github.com/vsariola/sointu/vm/compiler"
+)
+
+type (
+	// Manifest lists what the compiler did for each input song, so that the
+	// sizes of a song corpus can be tracked from one compiler version to the
+	// next.
+	Manifest struct {
+		Version string          `json:"version"`
+		Arch    string          `json:"arch"`
+		OS      string          `json:"os"`
+		Songs   []ManifestEntry `json:"songs"`
+	}
+
+	// ManifestEntry describes the compilation of one input song.
+	ManifestEntry struct {
+		Song     string         `json:"song"`
+		Files    []ManifestFile `json:"files,omitempty"`
+		Features []string       `json:"features,omitempty"` // the unit types compiled into the player
+		// Polyphony and GlobalSend tell if the player needs the code for
+		// polyphonic instruments and for sends across instruments
+		Polyphony  bool `json:"polyphony,omitempty"`
+		GlobalSend bool `json:"globalSend,omitempty"`
+		// Voices is the number of voices in the patch, InstrumentVoices the
+		// number of voices of each instrument and TrackVoices the number of
+		// voices used by the tracks
+		Voices           int            `json:"voices"`
+		InstrumentVoices []int          `json:"instrumentVoices,omitempty"`
+		TrackVoices      int            `json:"trackVoices"`
+		Data             []ManifestSize `json:"data,omitempty"` // sizes of the data sections, see compiler.EstimateSizes
+		Warnings         []string       `json:"warnings,omitempty"`
+		Error            string         `json:"error,omitempty"`
+	}
+
+	// ManifestFile is a file generated by the compiler, with its size in bytes.
+	ManifestFile struct {
+		Path string `json:"path"`
+		Size int    `json:"size"`
+	}
+
+	// ManifestSize is the size of one data section, see compiler.SectionSize.
+	ManifestSize struct {
+		Name   string  `json:"name"`
+		Raw    int     `json:"raw"`
+		Packed float64 `json:"packed"`
+	}
+)
+
+// describeSong fills in the features, voices and data sizes of the song.
+func (e *ManifestEntry) describeSong(song *sointu.Song, transpose bool) {
+	features := vm.NecessaryFeaturesFor(song.Patch)
+	e.Features = append([]string{}, features.Instructions()...)
+	e.Polyphony = features.SupportsPolyphony()
+	e.GlobalSend = features.SupportsGlobalSend()
+	e.Voices = song.Patch.NumVoices()
+	e.InstrumentVoices = make([]int, len(song.Patch))
+	for i, instr := range song.Patch {
+		e.InstrumentVoices[i] = instr.NumVoices
+	}
+	e.TrackVoices = song.Score.NumVoices()
+	if e.TrackVoices != e.Voices {
+		e.Warnings = append(e.Warnings, fmt.Sprintf("the tracks use %v voices, but the patch has %v voices", e.TrackVoices, e.Voices))
+	}
+	sizes, err := compiler.EstimateSizes(song, transpose)
+	if err != nil {
+		e.Warnings = append(e.Warnings, fmt.Sprintf("could not estimate the data sizes: %v", err))
+		return
+	}
+	for _, s := range sizes {
+		e.Data = append(e.Data, ManifestSize{s.Name, s.Raw, s.Packed})
+	}
+}
+
+// fileSize returns the total size of the files generated for the song.
+func (e *ManifestEntry) fileSize() int {
+	ret := 0
+	for _, f := range e.Files {
+		ret += f.Size
+	}
+	return ret
+}
+
+// packedSize returns the estimated packed size of all the data of the song.
+func (e *ManifestEntry) packedSize() float64 {
+	for _, s := range e.Data {
+		if s.Name == "total" {
+			return s.Packed
+		}
+	}
+	return 0
+}
+
+func readManifest(filename string) (*Manifest, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("could not unmarshal manifest %v: %v", filename, err)
+	}
+	return &m, nil
+}
+
+// printSizeDeltas writes a summary of how the sizes of the songs have changed
+// since the old manifest: the total size of the generated files and the
+// estimated packed size of the data, for each song and for the whole corpus.
+func printSizeDeltas(w io.Writer, old, cur *Manifest) {
+	oldEntries := map[string]*ManifestEntry{}
+	for i := range old.Songs {
+		oldEntries[old.Songs[i].Song] = &old.Songs[i]
+	}
+	var totalFiles, totalOldFiles int
+	var totalPacked, totalOldPacked float64
+	for i := range cur.Songs {
+		e := &cur.Songs[i]
+		o, ok := oldEntries[e.Song]
+		delete(oldEntries, e.Song)
+		switch {
+		case e.Error != "":
+			fmt.Fprintf(w, "%v: failed: %v\n", e.Song, e.Error)
+		case !ok || o.Error != "":
+			fmt.Fprintf(w, "%v: new, files %d B, packed ~%.1f B\n", e.Song, e.fileSize(), e.packedSize())
+		default:
+			fmt.Fprintf(w, "%v: files %d B (%+d), packed ~%.1f B (%+.1f)\n", e.Song, e.fileSize(), e.fileSize()-o.fileSize(), e.packedSize(), e.packedSize()-o.packedSize())
+			totalFiles += e.fileSize()
+			totalOldFiles += o.fileSize()
+			totalPacked += e.packedSize()
+			totalOldPacked += o.packedSize()
+		}
+	}
+	removed := make([]string, 0, len(oldEntries))
+	for name := range oldEntries {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		fmt.Fprintf(w, "%v: removed\n", name)
+	}
+	fmt.Fprintf(w, "total of the songs in both: files %d B (%+d), packed ~%.1f B (%+.1f)\n", totalFiles, totalFiles-totalOldFiles, totalPacked, totalPacked-totalOldPacked)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintSizeDeltas(t *testing.T) {
+	entry := func(song string, fileSize int, packed float64) ManifestEntry {
+		return ManifestEntry{Song: song, Files: []ManifestFile{{song + ".asm", fileSize}}, Data: []ManifestSize{{"patterns", 10, 1}, {"total", 100, packed}}}
+	}
+	failed := func(song string) ManifestEntry { return ManifestEntry{Song: song, Error: "could not parse"} }
+	for _, tc := range []struct {
+		name     string
+		old, cur []ManifestEntry
+		expected string
+	}{
+		{
+			name: "changed",
+			old:  []ManifestEntry{entry("a.yml", 1000, 50)},
+			cur:  []ManifestEntry{entry("a.yml", 1100, 45.5)},
+			expected: "a.yml: files 1100 B (+100), packed ~45.5 B (-4.5)\n" +
+				"total of the songs in both: files 1100 B (+100), packed ~45.5 B (-4.5)\n",
+		},
+		{
+			name: "new",
+			old:  nil,
+			cur:  []ManifestEntry{entry("a.yml", 1000, 50)},
+			expected: "a.yml: new, files 1000 B, packed ~50.0 B\n" +
+				"total of the songs in both: files 0 B (+0), packed ~0.0 B (+0.0)\n",
+		},
+		{
+			name: "new after failing",
+			old:  []ManifestEntry{failed("a.yml")},
+			cur:  []ManifestEntry{entry("a.yml", 1000, 50)},
+			expected: "a.yml: new, files 1000 B, packed ~50.0 B\n" +
+				"total of the songs in both: files 0 B (+0), packed ~0.0 B (+0.0)\n",
+		},
+		{
+			name: "removed",
+			old:  []ManifestEntry{entry("b.yml", 1000, 50), entry("a.yml", 10, 5)},
+			cur:  nil,
+			expected: "a.yml: removed\n" +
+				"b.yml: removed\n" +
+				"total of the songs in both: files 0 B (+0), packed ~0.0 B (+0.0)\n",
+		},
+		{
+			name: "failed",
+			old:  []ManifestEntry{entry("a.yml", 1000, 50)},
+			cur:  []ManifestEntry{failed("a.yml")},
+			expected: "a.yml: failed: could not parse\n" +
+				"total of the songs in both: files 0 B (+0), packed ~0.0 B (+0.0)\n",
+		},
+		{
+			name: "mixed",
+			old:  []ManifestEntry{entry("a.yml", 1000, 50), entry("b.yml", 2000, 80), entry("c.yml", 10, 5)},
+			cur:  []ManifestEntry{entry("a.yml", 900, 50), entry("b.yml", 2000, 81), entry("d.yml", 10, 5)},
+			expected: "a.yml: files 900 B (-100), packed ~50.0 B (+0.0)\n" +
+				"b.yml: files 2000 B (+0), packed ~81.0 B (+1.0)\n" +
+				"d.yml: new, files 10 B, packed ~5.0 B\n" +
+				"c.yml: removed\n" +
+				"total of the songs in both: files 2900 B (-100), packed ~131.0 B (+1.0)\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			printSizeDeltas(&b, &Manifest{Songs: tc.old}, &Manifest{Songs: tc.cur})
+			if b.String() != tc.expected {
+				t.Errorf("got:\n%v\nexpected:\n%v", b.String(), tc.expected)
+			}
+		})
+	}
+}
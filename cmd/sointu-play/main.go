@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/vsariola/sointu/cmd"
@@ -15,7 +16,9 @@ import (
 
 	"github.com/vsariola/sointu"
 	"github.com/vsariola/sointu/oto"
+	"github.com/vsariola/sointu/tracker"
 	"github.com/vsariola/sointu/version"
+	"github.com/vsariola/sointu/vm"
 )
 
 func main() {
@@ -28,7 +31,21 @@ func main() {
 	//units := flag.String("unit", "pattern", "Units for parameters start and stop. Possible values: second, sample, pattern, beat. Warning: beat and pattern do not take SPEED modulations into account.")
 	rawOut := flag.Bool("r", false, "Output the rendered song as .raw file. By default, saves stereo float32 buffer to disk.")
 	wavOut := flag.Bool("w", false, "Output the rendered song as .wav file. By default, saves stereo float32 buffer to disk.")
-	pcm := flag.Bool("c", false, "Convert audio to 16-bit signed PCM when outputting.")
+	aiffOut := flag.Bool("aiff", false, "Output the rendered song as .aiff file. By default, saves stereo float32 buffer to disk.")
+	flacOut := flag.Bool("flac", false, "Output the rendered song as losslessly compressed .flac file. FLAC supports only integer samples, so saves 24-bit samples unless -bits 16 is given.")
+	pcm := flag.Bool("c", false, "Convert audio to 16-bit signed PCM when outputting. Same as -bits 16.")
+	bits := flag.Int("bits", 32, "Sample format when outputting: 16 or 24 for signed PCM, dithered, or 32 for floats.")
+	normalize := flag.Bool("normalize", false, "Normalize the loudness of the rendered song to the target given with -loudness, limiting the true peaks to -maxpeak, before playing or outputting it.")
+	loudness := flag.Float64("loudness", -14, "Target integrated loudness of -normalize, in LUFS.")
+	maxPeak := flag.Float64("maxpeak", float64(tracker.ExportMaxPeak), "Highest true peak allowed by -normalize, in dB.")
+	writeGain := flag.Bool("writegain", false, "With -normalize, also output the song with its output gains scaled by the normalization gain, as a .normalized.yml file.")
+	analyze := flag.Bool("analyze", false, "Print the integrated loudness, loudness range, maximum momentary and short-term loudness and peaks of the song and of each instrument played solo, measured like in the tracker.")
+	analyzeJSON := flag.Bool("json", false, "With -analyze, print the analysis as JSON.")
+	weighting := flag.String("weighting", "k", "Weighting of the loudness in the analysis. Possible values: k (LUFS), a, c, none (RMS).")
+	samplePeaks := flag.Bool("samplepeaks", false, "Measure sample peaks in the analysis, instead of true peaks.")
+	syncOut := flag.String("sync", "", "Render the values of the sync units offline with the Go VM and output them, in these comma separated formats: csv, json, rocket. rocket outputs one GNU Rocket .track file per sync.")
+	syncRate := flag.Float64("syncrate", vm.DefaultSyncRate, "Number of frames per second, when outputting syncs. By default, the same as the sync buffer of the compiled players.")
+	syncRow := flag.Bool("syncrow", false, "When outputting syncs, output the current fractional row as the first sync, like the players compiled with sointu-compile -r.")
 	versionFlag := flag.Bool("v", false, "Print version.")
 	syntherInt := flag.Int("synth", 0, "Select the synther to use. By default, uses the first one in the list of available synthers.")
 	flag.Usage = printUsage
@@ -48,9 +65,34 @@ func main() {
 		flag.Usage()
 		os.Exit(0)
 	}
-	if !*rawOut && !*wavOut {
+	var syncFormats []string
+	if *syncOut != "" {
+		syncFormats = strings.Split(*syncOut, ",")
+		for _, format := range syncFormats {
+			if !slices.Contains(vm.SyncFormats, format) {
+				fmt.Fprintf(os.Stderr, "unknown sync format %v; possible values: %v\n", format, strings.Join(vm.SyncFormats, ", "))
+				os.Exit(1)
+			}
+		}
+	}
+	if !*rawOut && !*wavOut && !*aiffOut && !*flacOut && !*analyze && len(syncFormats) == 0 {
 		*play = true // if the user gives nothing to output, then the default behaviour is just to play the file
 	}
+	sampleFormats := map[int]sointu.SampleFormat{16: sointu.SampleInt16, 24: sointu.SampleInt24, 32: sointu.SampleFloat32}
+	if *pcm {
+		*bits = 16
+	}
+	sampleFormat, ok := sampleFormats[*bits]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unsupported number of bits %v; possible values: 16, 24, 32\n", *bits)
+		os.Exit(1)
+	}
+	weightingTypes := map[string]tracker.WeightingType{"k": tracker.KWeighting, "a": tracker.AWeighting, "c": tracker.CWeighting, "none": tracker.NoWeighting}
+	weightingType, ok := weightingTypes[*weighting]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown weighting %v; possible values: k, a, c, none\n", *weighting)
+		os.Exit(1)
+	}
 	var audioContext sointu.AudioContext
 	var playWaiter sointu.CloserWaiter
 	if *play {
@@ -102,29 +144,86 @@ func main() {
 				return fmt.Errorf("the song could not be parsed as .json (%v) or .yml (%v)", errJSON, errYaml)
 			}
 		}
+		if err := song.Patch.LoadSamples(filepath.Dir(filename)); err != nil {
+			return fmt.Errorf("could not load the samples of %v: %v", filename, err)
+		}
 		buffer, err := sointu.Play(cmd.Synthers[*syntherInt], song, nil) // render the song to calculate its length
 		if err != nil {
 			return fmt.Errorf("sointu.Play failed: %v", err)
 		}
+		if *normalize {
+			gain := tracker.NormalizationGain(tracker.AnalyzeLoudness(buffer, tracker.KWeighting, true), tracker.Decibel(*loudness), tracker.Decibel(*maxPeak))
+			tracker.ApplyGain(buffer, gain)
+			fmt.Fprintf(os.Stderr, "%v: normalized by %+.1f dB\n", filename, gain)
+			if *writeGain {
+				normalized := song.Copy()
+				if !tracker.ScaleOutputGains(normalized.Patch, gain) {
+					fmt.Fprintf(os.Stderr, "%v: some output gains were already at maximum; the normalized song plays quieter than the output\n", filename)
+				}
+				yamlSong, err := yaml.Marshal(normalized)
+				if err != nil {
+					return fmt.Errorf("could not marshal the normalized song: %v", err)
+				}
+				if err := output(".normalized.yml", yamlSong); err != nil {
+					return fmt.Errorf("error outputting the normalized song: %v", err)
+				}
+			}
+		}
 		if *play {
 			playWaiter = audioContext.Play(buffer.Source())
 		}
-		if *rawOut {
-			raw, err := buffer.Raw(*pcm)
+		for _, o := range []struct {
+			enabled bool
+			format  sointu.AudioFileFormat
+		}{{*rawOut, sointu.RawFile}, {*wavOut, sointu.WavFile}, {*aiffOut, sointu.AiffFile}, {*flacOut, sointu.FlacFile}} {
+			if !o.enabled {
+				continue
+			}
+			format := sampleFormat
+			if !o.format.Supports(format) {
+				format = sointu.SampleInt24
+			}
+			contents, err := buffer.Encode(o.format, format)
 			if err != nil {
-				return fmt.Errorf("could not generate .raw file: %v", err)
+				return fmt.Errorf("could not generate %v file: %v", o.format.Extension(), err)
 			}
-			if err := output(".raw", raw); err != nil {
-				return fmt.Errorf("error outputting .raw file: %v", err)
+			if err := output(o.format.Extension(), contents); err != nil {
+				return fmt.Errorf("error outputting %v file: %v", o.format.Extension(), err)
 			}
 		}
-		if *wavOut {
-			wav, err := buffer.Wav(*pcm)
+		if len(syncFormats) > 0 {
+			syncs, err := vm.RenderSyncs(song, *syncRate, *syncRow)
 			if err != nil {
-				return fmt.Errorf("could not generate .wav file: %v", err)
+				return fmt.Errorf("could not render the syncs: %v", err)
 			}
-			if err := output(".wav", wav); err != nil {
-				return fmt.Errorf("error outputting .wav file: %v", err)
+			for _, format := range syncFormats {
+				files, err := syncs.Files(format)
+				if err != nil {
+					return fmt.Errorf("could not convert the syncs: %v", err)
+				}
+				for suffix, contents := range files {
+					if err := output(suffix, contents); err != nil {
+						return fmt.Errorf("error outputting sync file: %v", err)
+					}
+				}
+			}
+		}
+		if *analyze {
+			analysis, err := tracker.AnalyzeSong(cmd.Synthers[*syntherInt], song, weightingType, !*samplePeaks)
+			if err != nil {
+				return fmt.Errorf("could not analyze the song: %v", err)
+			}
+			if *analyzeJSON {
+				jsonAnalysis, err := json.MarshalIndent(struct {
+					Song string `json:"song"`
+					tracker.SongAnalysis
+				}{filename, analysis}, "", "  ")
+				if err != nil {
+					return fmt.Errorf("could not marshal the analysis: %v", err)
+				}
+				fmt.Println(string(jsonAnalysis))
+			} else {
+				printAnalysis(filename, analysis)
 			}
 		}
 		if *play {
@@ -166,6 +265,18 @@ func main() {
 	os.Exit(retval)
 }
 
+func printAnalysis(filename string, a tracker.SongAnalysis) {
+	fmt.Printf("%v:\n", filename)
+	fmt.Printf("  %-16v %9v %9v %9v %9v %9v %9v\n", "", "integr.", "range", "max mom.", "max st.", "peak L", "peak R")
+	row := func(name string, l tracker.LoudnessAnalysis) {
+		fmt.Printf("  %-16.16v %9.1f %9.1f %9.1f %9.1f %9.1f %9.1f\n", name, l.Integrated, l.Range, l.MaxMomentary, l.MaxShortTerm, l.Peak[0], l.Peak[1])
+	}
+	row("song", a.LoudnessAnalysis)
+	for _, instr := range a.Instruments {
+		row(instr.Name, instr.LoudnessAnalysis)
+	}
+}
+
 func printUsage() {
 	fmt.Fprintf(os.Stderr, "Sointu command line utility for playing .asm/.json song files.\nUsage: %s [flags] [path ...]\n", os.Args[0])
 	flag.PrintDefaults()
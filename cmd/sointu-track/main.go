@@ -44,11 +44,15 @@ func main() {
 	if configDir, err := os.UserConfigDir(); err == nil {
 		recoveryFile = filepath.Join(configDir, "sointu", "recovery", "sointu-track-recovery.json")
 	}
+	gmDlsErr := gioui.LoadGmDls() // before the player, as the synths read the sample table
 	broker := tracker.NewBroker()
 	midiContext := cmd.NewMidiContext(broker)
 	defer midiContext.Close()
 	model := tracker.NewModel(broker, cmd.Synthers, midiContext, recoveryFile)
 	player := tracker.NewPlayer(broker, cmd.Synthers[0])
+	if gmDlsErr != nil {
+		model.Alerts().Add(gmDlsErr.Error(), tracker.Warning)
+	}
 	if isFlagPassed("midi-input") {
 		for i, s := range model.MIDI().Input().Values {
 			if strings.HasPrefix(s, *defaultMidiInput) {
@@ -46,6 +46,7 @@ func init() {
 	var (
 		version = int32(100)
 	)
+	gmDlsErr := gioui.LoadGmDls() // once for all the plugin instances, as they share the sample table
 	vst2.PluginAllocator = func(h vst2.Host) (vst2.Plugin, vst2.Dispatcher) {
 		recoveryFile := ""
 		if configDir, err := os.UserConfigDir(); err == nil {
@@ -56,6 +57,9 @@ func init() {
 		broker := tracker.NewBroker()
 		model := tracker.NewModel(broker, cmd.Synthers, cmd.NewMidiContext(broker), recoveryFile)
 		player := tracker.NewPlayer(broker, cmd.Synthers[0])
+		if gmDlsErr != nil {
+			model.Alerts().Add(gmDlsErr.Error(), tracker.Warning)
+		}
 
 		t := gioui.NewTracker(model)
 		model.Play().TrackerHidden().SetValue(true)
@@ -0,0 +1,287 @@
+package sointu
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// DLS is the parsed contents of a DownLoadable Sounds file, such as the
+	// gm.dls that ships with Windows.
+	DLS struct {
+		Instruments []DLSInstrument
+		Waves       []DLSWave
+	}
+
+	// DLSInstrument is a single instrument of a DLS file. The regions map key
+	// and velocity ranges to waves.
+	DLSInstrument struct {
+		Name    string
+		Bank    int // MIDI bank, MSB*128 + LSB
+		Program int
+		Drums   bool
+		Regions []DLSRegion
+	}
+
+	// DLSRegion maps a range of keys and velocities to a wave. If the region
+	// does not define its own tuning and loop, they are copied from the wave.
+	DLSRegion struct {
+		KeyLow, KeyHigh int
+		VelLow, VelHigh int
+		Wave            int // index to DLS.Waves
+		DLSWaveSample
+	}
+
+	// DLSWave is a single wave of a DLS file. The Start is relative to the
+	// beginning of the file, so when the whole file is loaded into the sample
+	// table, the wave is played by setting the samplestart of the oscillator
+	// to Start.
+	DLSWave struct {
+		Name          string
+		Start         int // offset of the sample data from the beginning of the file, in words
+		Length        int // length of the sample data, in samples
+		SampleRate    int
+		BitsPerSample int
+		Channels      int
+		DLSWaveSample
+	}
+
+	// DLSWaveSample is the tuning and loop information of a wave or region.
+	// LoopLength 0 means the sample is not looped.
+	DLSWaveSample struct {
+		UnityNote  int // MIDI note at which the sample plays at its original pitch
+		FineTune   int // tuning offset from UnityNote, in the relative pitch units of the file
+		LoopStart  int // in samples, relative to the beginning of the sample
+		LoopLength int // in samples
+	}
+)
+
+// GmDlsPathEnv is the environment variable that can be used to tell where the
+// gm.dls file is, e.g. when a copy of it is used on Linux or Mac.
+const GmDlsPathEnv = "SOINTU_GMDLS"
+
+// FindGmDls returns the path of the gm.dls file. The path given in the
+// environment variable SOINTU_GMDLS is tried first, then gm.dls in the current
+// directory and finally the Windows system directories. Returns an empty
+// string if the file could not be found.
+func FindGmDls() string {
+	candidates := []string{
+		os.Getenv(GmDlsPathEnv),
+		"gm.dls",
+		filepath.Join(os.Getenv("SystemRoot"), "system32", "drivers", "gm.dls"),
+		filepath.Join(os.Getenv("SystemRoot"), "SysWOW64", "drivers", "gm.dls"),
+	}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// ReadDLS reads a DLS file from r and returns its instruments and waves.
+func ReadDLS(r io.Reader) (*DLS, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "DLS " {
+		return nil, errors.New("not a RIFF DLS file")
+	}
+	p := dlsParser{data: b}
+	if err := p.list(12, min(8+int(binary.LittleEndian.Uint32(b[4:])), len(b)), "DLS "); err != nil {
+		return nil, err
+	}
+	ret := &DLS{Instruments: p.instruments, Waves: p.waves}
+	waveIndex := make(map[int]int, len(p.waveOffsets))
+	for i, o := range p.waveOffsets {
+		waveIndex[o] = i
+	}
+	for i := range ret.Instruments {
+		for j := range ret.Instruments[i].Regions {
+			r := &ret.Instruments[i].Regions[j]
+			if r.Wave < 0 || r.Wave >= len(p.poolTable) {
+				return nil, fmt.Errorf("instrument %v region %v links to a nonexistent pool table entry %v", i, j, r.Wave)
+			}
+			w, ok := waveIndex[p.poolTable[r.Wave]]
+			if !ok {
+				return nil, fmt.Errorf("instrument %v region %v links to a nonexistent wave", i, j)
+			}
+			r.Wave = w
+			if !p.regionHasSample[i][j] {
+				r.DLSWaveSample = ret.Waves[w].DLSWaveSample
+			}
+		}
+	}
+	return ret, nil
+}
+
+type dlsParser struct {
+	data            []byte
+	instruments     []DLSInstrument
+	regionHasSample [][]bool
+	waves           []DLSWave
+	waveOffsets     []int // offsets of the wave lists, relative to the start of the wave pool
+	poolTable       []int // wave offsets, indexed by the region wave links
+	poolStart       int
+	context         string // "ins " or "wave", tells to which the INFO chunks belong
+}
+
+// list walks the chunks in data[pos:end], which are the contents of a list
+// with the given list type.
+func (p *dlsParser) list(pos, end int, listType string) error {
+	for pos+8 <= end {
+		id := string(p.data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(p.data[pos+4:]))
+		start := pos + 8
+		if size < 0 || start+size > end {
+			return fmt.Errorf("chunk %q at %v extends beyond its parent", id, pos)
+		}
+		chunk := p.data[start : start+size]
+		if id == "LIST" {
+			if size < 4 {
+				return fmt.Errorf("list at %v is too short", pos)
+			}
+			subType := string(chunk[0:4])
+			switch subType {
+			case "ins ":
+				p.instruments = append(p.instruments, DLSInstrument{})
+				p.regionHasSample = append(p.regionHasSample, nil)
+			case "rgn ", "rgn2":
+				if len(p.instruments) == 0 {
+					return errors.New("region outside an instrument")
+				}
+				instr := &p.instruments[len(p.instruments)-1]
+				instr.Regions = append(instr.Regions, DLSRegion{})
+				p.regionHasSample[len(p.regionHasSample)-1] = append(p.regionHasSample[len(p.regionHasSample)-1], false)
+			case "wvpl":
+				p.poolStart = start + 4
+			case "wave":
+				p.waves = append(p.waves, DLSWave{})
+				p.waveOffsets = append(p.waveOffsets, pos-p.poolStart)
+			}
+			context := p.context
+			if subType == "ins " || subType == "wave" {
+				p.context = subType
+			}
+			if err := p.list(start+4, start+size, subType); err != nil {
+				return err
+			}
+			p.context = context
+		} else if err := p.chunk(id, start, chunk, listType); err != nil {
+			return err
+		}
+		pos = start + size + size&1 // chunks are padded to even length
+	}
+	return nil
+}
+
+func (p *dlsParser) chunk(id string, start int, chunk []byte, listType string) error {
+	le := binary.LittleEndian
+	tooShort := func(n int) error {
+		if len(chunk) < n {
+			return fmt.Errorf("%q chunk at %v is too short", id, start-8)
+		}
+		return nil
+	}
+	switch {
+	case id == "insh":
+		if err := tooShort(12); err != nil {
+			return err
+		}
+		if len(p.instruments) == 0 {
+			return errors.New("instrument header outside an instrument")
+		}
+		instr := &p.instruments[len(p.instruments)-1]
+		bank := le.Uint32(chunk[4:])
+		instr.Bank = int(bank>>8&0x7F)*128 + int(bank&0x7F)
+		instr.Drums = bank&0x80000000 != 0
+		instr.Program = int(le.Uint32(chunk[8:]) & 0x7F)
+	case id == "rgnh":
+		if err := tooShort(8); err != nil {
+			return err
+		}
+		r := p.lastRegion()
+		r.KeyLow, r.KeyHigh = int(le.Uint16(chunk[0:])), int(le.Uint16(chunk[2:]))
+		r.VelLow, r.VelHigh = int(le.Uint16(chunk[4:])), int(le.Uint16(chunk[6:]))
+	case id == "wlnk":
+		if err := tooShort(12); err != nil {
+			return err
+		}
+		p.lastRegion().Wave = int(le.Uint32(chunk[8:]))
+	case id == "ptbl":
+		if err := tooShort(8); err != nil {
+			return err
+		}
+		headerSize, count := int(le.Uint32(chunk[0:])), int(le.Uint32(chunk[4:]))
+		if err := tooShort(headerSize + count*4); err != nil {
+			return err
+		}
+		p.poolTable = make([]int, count)
+		for i := range p.poolTable {
+			p.poolTable[i] = int(le.Uint32(chunk[headerSize+i*4:]))
+		}
+	case id == "wsmp":
+		if err := tooShort(20); err != nil {
+			return err
+		}
+		var s DLSWaveSample
+		headerSize := int(le.Uint32(chunk[0:]))
+		s.UnityNote = int(le.Uint16(chunk[4:]))
+		s.FineTune = int(int16(le.Uint16(chunk[6:])))
+		if numLoops := le.Uint32(chunk[16:]); numLoops > 0 {
+			if err := tooShort(headerSize + 16); err != nil {
+				return err
+			}
+			s.LoopStart = int(le.Uint32(chunk[headerSize+8:]))
+			s.LoopLength = int(le.Uint32(chunk[headerSize+12:]))
+		}
+		switch listType {
+		case "rgn ", "rgn2":
+			p.lastRegion().DLSWaveSample = s
+			h := p.regionHasSample[len(p.regionHasSample)-1]
+			h[len(h)-1] = true
+		case "wave":
+			p.waves[len(p.waves)-1].DLSWaveSample = s
+		}
+	case id == "fmt " && listType == "wave":
+		if err := tooShort(16); err != nil {
+			return err
+		}
+		w := &p.waves[len(p.waves)-1]
+		w.Channels = int(le.Uint16(chunk[2:]))
+		w.SampleRate = int(le.Uint32(chunk[4:]))
+		w.BitsPerSample = int(le.Uint16(chunk[14:]))
+	case id == "data" && listType == "wave":
+		w := &p.waves[len(p.waves)-1]
+		w.Start = start / 2
+		if frameSize := w.Channels * w.BitsPerSample / 8; frameSize > 0 {
+			w.Length = len(chunk) / frameSize
+		}
+	case id == "INAM":
+		name := strings.TrimRight(string(chunk), "\x00")
+		switch p.context {
+		case "wave":
+			p.waves[len(p.waves)-1].Name = name
+		case "ins ":
+			p.instruments[len(p.instruments)-1].Name = name
+		}
+	}
+	return nil
+}
+
+func (p *dlsParser) lastRegion() *DLSRegion {
+	if len(p.instruments) == 0 || len(p.instruments[len(p.instruments)-1].Regions) == 0 {
+		return &DLSRegion{} // malformed file, chunk outside a region; ignore it
+	}
+	instr := &p.instruments[len(p.instruments)-1]
+	return &instr.Regions[len(instr.Regions)-1]
+}
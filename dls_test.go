@@ -0,0 +1,89 @@
+package sointu_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/internal/rifftest"
+)
+
+func TestReadDLS(t *testing.T) {
+	b := rifftest.DLS(
+		[][]byte{
+			rifftest.DLSInstrument("Piano", 0x00000102, 5,
+				rifftest.DLSRegion(0, 59, 1, nil),
+				rifftest.DLSRegion(60, 127, 0, rifftest.Wsmp(72, -10, 1, 2)),
+			),
+			rifftest.DLSInstrument("Drums", 0x80000000, 0, rifftest.DLSRegion(35, 35, 1, nil)),
+		},
+		[][]byte{
+			rifftest.DLSWave("Sine", 16, 1, rifftest.Wsmp(60, 0, 0, 0), make([]byte, 8)),
+			rifftest.DLSWave("Saw", 16, 1, rifftest.Wsmp(48, 5, 2, 3), make([]byte, 12)),
+		},
+	)
+	dls, err := sointu.ReadDLS(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadDLS failed: %v", err)
+	}
+	if len(dls.Waves) != 2 {
+		t.Fatalf("expected 2 waves, got %v", len(dls.Waves))
+	}
+	for i, w := range dls.Waves {
+		offset := bytes.Index(b, []byte("data")) + 8
+		if i == 1 {
+			offset = bytes.LastIndex(b, []byte("data")) + 8
+		}
+		if w.Start*2 != offset {
+			t.Errorf("wave %v: expected start %v words, got %v", i, offset/2, w.Start)
+		}
+		if w.SampleRate != 22050 || w.BitsPerSample != 16 || w.Channels != 1 {
+			t.Errorf("wave %v: wrong format: %+v", i, w)
+		}
+	}
+	if w := dls.Waves[0]; w.Name != "Sine" || w.Length != 4 || w.UnityNote != 60 || w.LoopLength != 0 {
+		t.Errorf("wrong first wave: %+v", w)
+	}
+	if w := dls.Waves[1]; w.Name != "Saw" || w.Length != 6 || w.UnityNote != 48 || w.FineTune != 5 || w.LoopStart != 2 || w.LoopLength != 3 {
+		t.Errorf("wrong second wave: %+v", w)
+	}
+	if len(dls.Instruments) != 2 {
+		t.Fatalf("expected 2 instruments, got %v", len(dls.Instruments))
+	}
+	piano := dls.Instruments[0]
+	if piano.Name != "Piano" || piano.Bank != 1*128+2 || piano.Program != 5 || piano.Drums {
+		t.Errorf("wrong instrument header: %+v", piano)
+	}
+	if len(piano.Regions) != 2 {
+		t.Fatalf("expected 2 regions, got %v", len(piano.Regions))
+	}
+	if r := piano.Regions[0]; r.KeyLow != 0 || r.KeyHigh != 59 || r.Wave != 1 || r.DLSWaveSample != dls.Waves[1].DLSWaveSample {
+		t.Errorf("region without wsmp should inherit the tuning and loop of the wave: %+v", r)
+	}
+	if r := piano.Regions[1]; r.KeyLow != 60 || r.KeyHigh != 127 || r.Wave != 0 || r.UnityNote != 72 || r.FineTune != -10 || r.LoopStart != 1 || r.LoopLength != 2 {
+		t.Errorf("region with wsmp should override the wave: %+v", r)
+	}
+	if drums := dls.Instruments[1]; drums.Name != "Drums" || !drums.Drums || len(drums.Regions) != 1 || drums.Regions[0].Wave != 1 {
+		t.Errorf("wrong drum instrument: %+v", drums)
+	}
+}
+
+func TestReadDLSErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"not a DLS file", rifftest.Chunk("RIFF", []byte("WAVE"))},
+		{"truncated wave pool", func() []byte {
+			b := rifftest.DLS(nil, [][]byte{rifftest.DLSWave("Sine", 16, 1, nil, make([]byte, 8))})
+			return b[:len(b)-4]
+		}()},
+		{"nonexistent pool table entry", rifftest.DLS([][]byte{rifftest.DLSInstrument("Piano", 0, 0, rifftest.DLSRegion(0, 127, 1, nil))}, [][]byte{rifftest.DLSWave("Sine", 16, 1, nil, make([]byte, 8))})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := sointu.ReadDLS(bytes.NewReader(tc.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
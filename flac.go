@@ -0,0 +1,356 @@
+package sointu
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"math"
+)
+
+type (
+	// flacWriter writes the big-endian bit stream of a FLAC file.
+	flacWriter struct {
+		buf   []byte
+		acc   uint64 // bits not yet written to buf, aligned to the right
+		nbits uint
+	}
+
+	// flacSubframe is the encoding chosen for one channel of a FLAC frame.
+	flacSubframe struct {
+		samples   []int64
+		bps       int     // bits per sample of the channel; the side channel needs one extra bit
+		kind      int     // flacConstant, flacVerbatim or flacFixed
+		order     int     // order of the fixed predictor
+		partOrder int     // the residual is split into 2^partOrder partitions
+		params    []int   // rice parameter of each partition
+		residual  []int64 // residual of the fixed predictor
+		size      int     // size of the subframe in bits; estimated for flacFixed
+	}
+)
+
+const (
+	flacBlockSize    = 4096
+	flacMaxPartOrder = 8
+	flacMaxOrder     = 4
+)
+
+const (
+	flacConstant = iota
+	flacVerbatim
+	flacFixed
+)
+
+// flacToBuffer encodes the buffer as a FLAC file into the bytes.Buffer. The
+// encoder is simple but reasonably fast: it uses blocks of fixed size, fixed
+// polynomial predictors, and chooses for each block the stereo decorrelation
+// (left/right, left/side, right/side or mid/side) that gives the smallest
+// frame.
+func (data AudioBuffer) flacToBuffer(format SampleFormat, buf *bytes.Buffer) error {
+	// Refer to: https://xiph.org/flac/format.html
+	bps := format.Bits()
+	if format == SampleFloat32 {
+		return errors.New("FLAC supports only integer samples")
+	}
+	samples := data.quantize(bps)
+	hash := md5.New()
+	sampleBytes := make([]byte, 0, len(samples)*2*bps/8)
+	for _, v := range samples {
+		for _, s := range v {
+			for i := 0; i < bps; i += 8 {
+				sampleBytes = append(sampleBytes, byte(s>>i))
+			}
+		}
+	}
+	hash.Write(sampleBytes)
+	var frames flacWriter
+	minFrame, maxFrame := math.MaxInt, 0
+	for frame := 0; frame*flacBlockSize < len(samples); frame++ {
+		block := samples[frame*flacBlockSize : min((frame+1)*flacBlockSize, len(samples))]
+		start := len(frames.buf)
+		frames.writeFrame(frame, block, bps)
+		minFrame = min(minFrame, len(frames.buf)-start)
+		maxFrame = max(maxFrame, len(frames.buf)-start)
+	}
+	if maxFrame == 0 {
+		minFrame = 0
+	}
+	var header flacWriter
+	header.write(0x664C6143, 32) // "fLaC"
+	header.write(1, 1)           // last metadata block
+	header.write(0, 7)           // STREAMINFO
+	header.write(34, 24)         // length of STREAMINFO
+	header.write(flacBlockSize, 16)
+	header.write(flacBlockSize, 16)
+	header.write(uint64(minFrame), 24)
+	header.write(uint64(maxFrame), 24)
+	header.write(sampleRate, 20)
+	header.write(2-1, 3) // number of channels - 1
+	header.write(uint64(bps-1), 5)
+	header.write(uint64(len(samples)), 36)
+	buf.Write(header.buf)
+	buf.Write(hash.Sum(nil))
+	buf.Write(frames.buf)
+	return nil
+}
+
+// writeFrame writes a frame with the stereo samples of the block.
+func (w *flacWriter) writeFrame(index int, block [][2]int32, bps int) {
+	n := len(block)
+	left, right := make([]int64, n), make([]int64, n)
+	mid, side := make([]int64, n), make([]int64, n)
+	for i, v := range block {
+		l, r := int64(v[0]), int64(v[1])
+		left[i], right[i] = l, r
+		mid[i], side[i] = (l+r)>>1, l-r
+	}
+	l, r := encodeFlacSubframe(left, bps), encodeFlacSubframe(right, bps)
+	m, s := encodeFlacSubframe(mid, bps), encodeFlacSubframe(side, bps+1)
+	assignment, first, second := uint64(0b0001), l, r // independent left and right
+	if size := l.size + s.size; size < first.size+second.size {
+		assignment, first, second = 0b1000, l, s
+	}
+	if size := s.size + r.size; size < first.size+second.size {
+		assignment, first, second = 0b1001, s, r
+	}
+	if size := m.size + s.size; size < first.size+second.size {
+		assignment, first, second = 0b1010, m, s
+	}
+	start := len(w.buf)
+	w.write(0b11111111111110, 14) // sync code
+	w.write(0, 1)                 // reserved
+	w.write(0, 1)                 // fixed block size
+	if n == flacBlockSize {
+		w.write(0b1100, 4) // 256 * 2^(12-8) = 4096 samples
+	} else {
+		w.write(0b0111, 4) // block size - 1 follows as 16 bits
+	}
+	w.write(0b1001, 4) // 44.1 kHz
+	w.write(assignment, 4)
+	if bps == 16 {
+		w.write(0b100, 3)
+	} else {
+		w.write(0b110, 3)
+	}
+	w.write(0, 1) // reserved
+	w.writeUTF8(uint64(index))
+	if n != flacBlockSize {
+		w.write(uint64(n-1), 16)
+	}
+	w.buf = append(w.buf, flacCRC8(w.buf[start:]))
+	w.writeSubframe(first)
+	w.writeSubframe(second)
+	w.align()
+	crc := flacCRC16(w.buf[start:])
+	w.buf = append(w.buf, byte(crc>>8), byte(crc))
+}
+
+// encodeFlacSubframe chooses the smallest encoding for the samples: constant,
+// verbatim or a fixed predictor of order 0 to 4 followed by rice-coded
+// residual.
+func encodeFlacSubframe(samples []int64, bps int) flacSubframe {
+	n := len(samples)
+	ret := flacSubframe{samples: samples, bps: bps, kind: flacConstant, size: 8 + bps}
+	constant := true
+	for _, s := range samples {
+		constant = constant && s == samples[0]
+	}
+	if constant {
+		return ret
+	}
+	ret.kind, ret.size = flacVerbatim, 8+n*bps
+	residual := make([]int64, n)
+	for order := 0; order <= flacMaxOrder && order < n; order++ {
+		for i := order; i < n; i++ {
+			s := samples
+			switch order {
+			case 0:
+				residual[i] = s[i]
+			case 1:
+				residual[i] = s[i] - s[i-1]
+			case 2:
+				residual[i] = s[i] - 2*s[i-1] + s[i-2]
+			case 3:
+				residual[i] = s[i] - 3*s[i-1] + 3*s[i-2] - s[i-3]
+			case 4:
+				residual[i] = s[i] - 4*s[i-1] + 6*s[i-2] - 4*s[i-3] + s[i-4]
+			}
+		}
+		partOrder, params, size := flacPartition(residual, order)
+		if size += 8 + order*bps; size < ret.size {
+			ret.kind, ret.order, ret.partOrder, ret.params, ret.size = flacFixed, order, partOrder, params, size
+			ret.residual = append(ret.residual[:0], residual...)
+		}
+	}
+	return ret
+}
+
+// flacPartition chooses the partition order and the rice parameters of each
+// partition for coding the residual, whose first order samples are warm-up
+// samples and not coded. Returns also the estimated size of the coded residual
+// in bits.
+func flacPartition(residual []int64, order int) (partOrder int, params []int, size int) {
+	n := len(residual)
+	maxPartOrder := 0
+	for maxPartOrder < flacMaxPartOrder && n%(2<<maxPartOrder) == 0 && n>>(maxPartOrder+1) > order {
+		maxPartOrder++
+	}
+	// the sums of the zigzag coded residuals of the partitions at the finest
+	// partition order; the sums of coarser orders are the sums of these
+	sums := make([]uint64, 1<<maxPartOrder)
+	for i := order; i < n; i++ {
+		sums[i/(n>>maxPartOrder)] += flacZigzag(residual[i])
+	}
+	size = math.MaxInt
+	for p := maxPartOrder; p >= 0; p-- {
+		partSize, partParams, maxParam := 0, make([]int, 1<<p), 0
+		for j := range partParams {
+			count := n >> p
+			if j == 0 {
+				count -= order
+			}
+			// estimated size of rice coding with the parameter k: the unary
+			// coded quotients take about sum/2^k bits, plus 1 stop bit and k
+			// remainder bits for each sample
+			best := math.MaxInt
+			for k := 0; k <= 30; k++ {
+				if s := count*(k+1) + int(min(sums[j]>>k, math.MaxInt32)); s < best {
+					best, partParams[j] = s, k
+				}
+			}
+			partSize += best
+			maxParam = max(maxParam, partParams[j])
+		}
+		paramBits := 4
+		if maxParam > 14 {
+			paramBits = 5 // 15 and 31 are escape codes
+		}
+		if partSize += 6 + paramBits<<p; partSize < size {
+			size, partOrder, params = partSize, p, partParams
+		}
+		if p > 0 { // merge the sums of the pairs of partitions
+			for j := range 1 << (p - 1) {
+				sums[j] = sums[2*j] + sums[2*j+1]
+			}
+		}
+	}
+	return partOrder, params, size
+}
+
+func (w *flacWriter) writeSubframe(s flacSubframe) {
+	w.write(0, 1) // zero padding
+	switch s.kind {
+	case flacConstant:
+		w.write(0b000000, 6)
+		w.write(0, 1) // no wasted bits
+		w.writeSigned(s.samples[0], s.bps)
+	case flacVerbatim:
+		w.write(0b000001, 6)
+		w.write(0, 1)
+		for _, v := range s.samples {
+			w.writeSigned(v, s.bps)
+		}
+	case flacFixed:
+		w.write(uint64(0b001000|s.order), 6)
+		w.write(0, 1)
+		for _, v := range s.samples[:s.order] {
+			w.writeSigned(v, s.bps)
+		}
+		paramBits := 4
+		for _, k := range s.params {
+			if k > 14 {
+				paramBits = 5
+			}
+		}
+		w.write(uint64(paramBits-4), 2) // residual coding method: 4 or 5 bit rice parameters
+		w.write(uint64(s.partOrder), 4)
+		partLen := len(s.samples) >> s.partOrder
+		for j, k := range s.params {
+			w.write(uint64(k), uint(paramBits))
+			for i := max(j*partLen, s.order); i < (j+1)*partLen; i++ {
+				u := flacZigzag(s.residual[i])
+				for q := u >> k; q > 0; { // unary coded quotient
+					z := min(q, 32)
+					w.write(0, uint(z))
+					q -= z
+				}
+				w.write(1, 1)
+				w.write(u&(1<<k-1), uint(k))
+			}
+		}
+	}
+}
+
+// write writes the lowest n bits of value, n <= 32.
+func (w *flacWriter) write(value uint64, n uint) {
+	w.acc = w.acc<<n | value&(1<<n-1)
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.nbits -= 8
+		w.buf = append(w.buf, byte(w.acc>>w.nbits))
+	}
+}
+
+func (w *flacWriter) writeSigned(value int64, n int) {
+	w.write(uint64(value), uint(n))
+}
+
+// align pads the stream with zero bits to a byte boundary.
+func (w *flacWriter) align() {
+	if w.nbits > 0 {
+		w.write(0, 8-w.nbits)
+	}
+}
+
+// writeUTF8 writes the value coded like an UTF-8 character, extended to 36
+// bits, which is how FLAC codes the frame numbers.
+func (w *flacWriter) writeUTF8(value uint64) {
+	if value < 0x80 {
+		w.write(value, 8)
+		return
+	}
+	n := 2 // number of bytes
+	for value >= 1<<(5*n+1) {
+		n++
+	}
+	w.write(uint64(0xFF00>>n)&0xFF|value>>(6*(n-1)), 8) // n ones, a zero and the highest bits
+
+	for i := n - 2; i >= 0; i-- {
+		w.write(0x80|value>>(6*i)&0x3F, 8)
+	}
+}
+
+func flacZigzag(v int64) uint64 {
+	return uint64(v<<1 ^ v>>63)
+}
+
+// flacCRC8 computes the CRC-8 of the frame header, with polynomial 0x07.
+func flacCRC8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// flacCRC16 computes the CRC-16 of the frame, with polynomial 0x8005.
+func flacCRC16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,87 @@
+// Package rifftest builds RIFF files, such as DLS and WAV files, for tests.
+package rifftest
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Chunk builds a RIFF chunk, padded to even length.
+func Chunk(id string, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+	buf := new(bytes.Buffer)
+	buf.WriteString(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(body)))
+	buf.Write(body)
+	if len(body)%2 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// List builds a LIST chunk of the given type.
+func List(listType string, children ...[]byte) []byte {
+	return Chunk("LIST", append([][]byte{[]byte(listType)}, children...)...)
+}
+
+// LE encodes the values in little-endian byte order.
+func LE(values ...any) []byte {
+	buf := new(bytes.Buffer)
+	for _, v := range values {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// Wsmp builds a wave sample chunk with a single loop, or no loop if
+// loopLength is 0.
+func Wsmp(unityNote, fineTune, loopStart, loopLength int) []byte {
+	if loopLength == 0 {
+		return Chunk("wsmp", LE(uint32(20), uint16(unityNote), int16(fineTune), int32(0), uint32(0), uint32(0)))
+	}
+	return Chunk("wsmp", LE(uint32(20), uint16(unityNote), int16(fineTune), int32(0), uint32(0), uint32(1),
+		uint32(16), uint32(0), uint32(loopStart), uint32(loopLength)))
+}
+
+// DLSWave builds a wave of a DLS wave pool, at 22050 Hz. wsmp can be nil.
+func DLSWave(name string, bits, channels int, wsmp []byte, data []byte) []byte {
+	format := Chunk("fmt ", LE(uint16(1), uint16(channels), uint32(22050), uint32(22050*channels*bits/8), uint16(channels*bits/8), uint16(bits)))
+	return List("wave", format, wsmp, Chunk("data", data), List("INFO", Chunk("INAM", []byte(name+"\x00"))))
+}
+
+// DLSRegion builds a region of a DLS instrument, playing the wave at the
+// given index of the pool table. wsmp can be nil.
+func DLSRegion(keyLow, keyHigh, poolIndex int, wsmp []byte) []byte {
+	return List("rgn ",
+		Chunk("rgnh", LE(uint16(keyLow), uint16(keyHigh), uint16(0), uint16(127))),
+		wsmp,
+		Chunk("wlnk", LE(uint16(0), uint16(0), uint32(1), uint32(poolIndex))),
+	)
+}
+
+// DLSInstrument builds a DLS instrument with the regions.
+func DLSInstrument(name string, bank uint32, program int, regions ...[]byte) []byte {
+	return List("ins ",
+		Chunk("insh", LE(uint32(len(regions)), bank, uint32(program))),
+		List("lrgn", regions...),
+		List("INFO", Chunk("INAM", []byte(name+"\x00"))),
+	)
+}
+
+// DLS builds a DLS file with the instruments and waves; the pool table links
+// to the waves in order.
+func DLS(instruments [][]byte, waves [][]byte) []byte {
+	offsets := make([]any, 0, len(waves)+2)
+	offsets = append(offsets, uint32(8), uint32(len(waves)))
+	pos := 0
+	for _, w := range waves {
+		offsets = append(offsets, uint32(pos))
+		pos += len(w)
+	}
+	return Chunk("RIFF", []byte("DLS "),
+		Chunk("colh", LE(uint32(len(instruments)))),
+		List("lins", instruments...),
+		Chunk("ptbl", LE(offsets...)),
+		List("wvpl", waves...),
+	)
+}
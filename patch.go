@@ -29,6 +29,17 @@ type (
 		ThreadMaskM1 int    `yaml:",omitempty"`
 		MIDI         MIDI   `yaml:",flow,omitempty"` // MIDI contains info on how MIDI events should trigger this instrument.
 		Units        []Unit // Units contains all the units of the instrument
+		// Samples are the user provided samples of the instrument, which
+		// the sample oscillators can use instead of gm.dls (parameter
+		// "sample" > 0 is the 1-based index to this list).
+		Samples []UserSample `yaml:",omitempty"`
+		// Wavetables are the user drawn wavetables of the instrument, which
+		// the wavetable oscillators can use besides the built-in wavetables
+		// (parameter "wavetable" >= NumBuiltinWavetables indexes this list).
+		Wavetables []UserWavetable `yaml:",omitempty"`
+		// Tuning maps the notes of the instrument to pitches, e.g. from Scala
+		// files. nil means 12-tone equal temperament.
+		Tuning *Tuning `yaml:",omitempty"`
 	}
 
 	// Unit is one small component of an instrument—e.g. a filter, an
@@ -144,6 +155,8 @@ var UnitTypes = map[string]([]UnitParameter){
 	"pan": []UnitParameter{
 		{Name: "stereo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
 		{Name: "panning", MinValue: 0, Neutral: 64, MaxValue: 128, CanSet: true, CanModulate: true}},
+	"width": []UnitParameter{
+		{Name: "width", MinValue: 0, Neutral: 64, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) { return strconv.Itoa(v * 100 / 64), "%" }}},
 	"delay": []UnitParameter{
 		{Name: "stereo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
 		{Name: "pregain", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true},
@@ -151,6 +164,7 @@ var UnitTypes = map[string]([]UnitParameter){
 		{Name: "feedback", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true},
 		{Name: "damp", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true},
 		{Name: "notetracking", MinValue: 0, MaxValue: 2, CanSet: true, CanModulate: false, DisplayFunc: arrDispFunc(noteTrackingNames[:])},
+		{Name: "interpolation", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false, DisplayFunc: arrDispFunc(interpolationNames[:])},
 		{Name: "delaytime", MinValue: 0, MaxValue: -1, CanSet: false, CanModulate: true}},
 	"compressor": []UnitParameter{
 		{Name: "stereo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
@@ -162,7 +176,15 @@ var UnitTypes = map[string]([]UnitParameter){
 		{Name: "threshold", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) {
 			return strconv.FormatFloat(toDecibel(float64(v)/128), 'g', 3, 64), "dB"
 		}},
-		{Name: "ratio", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) { return formatFloat(1 - float64(v)/128), "" }}},
+		{Name: "ratio", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) { return formatFloat(1 - float64(v)/128), "" }},
+		{Name: "sidechain", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false}},
+	"limiter": []UnitParameter{
+		{Name: "stereo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
+		{Name: "ceiling", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) {
+			return strconv.FormatFloat(toDecibel(float64(v)/128), 'g', 3, 64), "dB"
+		}},
+		{Name: "release", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: compressorTimeDispFunc},
+		{Name: "lookahead", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: false, DisplayFunc: func(v int) (string, string) { return engineeringTime(float64(v*4) / 44100) }}},
 	"speed": []UnitParameter{},
 	"out": []UnitParameter{
 		{Name: "stereo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
@@ -204,12 +226,26 @@ var UnitTypes = map[string]([]UnitParameter){
 		{Name: "shape", MinValue: 0, Neutral: 64, MaxValue: 128, CanSet: true, CanModulate: true},
 		{Name: "gain", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) { return strconv.FormatFloat(toDecibel(float64(v)/128), 'g', 3, 64), "dB" }},
 		{Name: "frequency", MinValue: 0, MaxValue: -1, CanSet: false, CanModulate: true},
-		{Name: "type", MinValue: int(Sine), MaxValue: int(Sample), CanSet: true, CanModulate: false, DisplayFunc: arrDispFunc(oscTypes[:])},
+		{Name: "type", MinValue: int(Sine), MaxValue: int(Wavetable), CanSet: true, CanModulate: false, DisplayFunc: arrDispFunc(oscTypes[:])},
 		{Name: "lfo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
+		{Name: "rate", MinValue: 0, MaxValue: len(LFORateTicks) - 1, CanSet: true, CanModulate: false, DisplayFunc: arrDispFunc(lfoRateNames[:])},
+		{Name: "reset", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false, DisplayFunc: arrDispFunc(lfoResetNames[:])},
 		{Name: "unison", MinValue: 0, MaxValue: 3, CanSet: true, CanModulate: false},
+		{Name: "sample", MinValue: 0, MaxValue: 255, CanSet: true, CanModulate: false, DisplayFunc: func(v int) (string, string) {
+			if v == 0 {
+				return "gm.dls", ""
+			}
+			return strconv.Itoa(v), ""
+		}},
 		{Name: "samplestart", MinValue: 0, MaxValue: 1720329, CanSet: true, CanModulate: false},
 		{Name: "loopstart", MinValue: 0, MaxValue: 65535, CanSet: true, CanModulate: false},
-		{Name: "looplength", MinValue: 0, MaxValue: 65535, CanSet: true, CanModulate: false}},
+		{Name: "looplength", MinValue: 0, MaxValue: 65535, CanSet: true, CanModulate: false},
+		{Name: "wavetable", MinValue: 0, MaxValue: 255, CanSet: true, CanModulate: false, DisplayFunc: func(v int) (string, string) {
+			if v < NumBuiltinWavetables {
+				return builtinWavetableAmps[v].name, ""
+			}
+			return "user " + strconv.Itoa(v-NumBuiltinWavetables+1), ""
+		}}},
 	"loadval": []UnitParameter{
 		{Name: "stereo", MinValue: 0, MaxValue: 1, CanSet: true, CanModulate: false},
 		{Name: "value", MinValue: 0, MaxValue: 128, CanSet: true, CanModulate: true, DisplayFunc: func(v int) (string, string) { return formatFloat(float64(v)/64 - 1), "" }}},
@@ -254,7 +290,16 @@ func (a *ParamMap) UnmarshalYAML(value *yaml.Node) error {
 
 var channelNames = [...]string{"left", "right", "aux1 left", "aux1 right", "aux2 left", "aux2 right", "aux3 left", "aux3 right"}
 var noteTrackingNames = [...]string{"fixed", "pitch", "BPM"}
-var oscTypes = [...]string{"sine", "trisaw", "pulse", "gate", "sample"}
+var interpolationNames = [...]string{"none", "linear"}
+var oscTypes = [...]string{"sine", "trisaw", "pulse", "gate", "sample", "wavetable"}
+var lfoRateNames = [...]string{"free", "1/32", "1/16T", "1/16", "1/8T", "1/16D", "1/8", "1/4T", "1/8D", "1/4", "1/2T", "1/4D", "1/2", "1/2D", "1 bar", "2 bars", "4 bars", "8 bars"}
+var lfoResetNames = [...]string{"note", "song"}
+
+// LFORateTicks gives the period of a tempo synced LFO for each value of the
+// "rate" parameter of an oscillator, in 1/48ths of a beat (the same unit the
+// BPM synced delay times use). Rate 0 means that the LFO is free running and
+// its frequency is determined by transpose and detune.
+var LFORateTicks = [...]int{0, 6, 8, 12, 16, 18, 24, 32, 36, 48, 64, 72, 96, 144, 192, 384, 768, 1536}
 
 func arrDispFunc(arr []string) UnitParameterDisplayFunc {
 	return func(v int) (string, string) {
@@ -336,14 +381,15 @@ func toDecibel(amplitude float64) float64 {
 }
 
 // When unit.Type = "oscillator", its unit.Parameter["Type"] tells the type of
-// the oscillator. There is five different oscillator types, so these consts
+// the oscillator. There is six different oscillator types, so these consts
 // just enumerate them.
 const (
-	Sine   = iota
-	Trisaw = iota
-	Pulse  = iota
-	Gate   = iota
-	Sample = iota
+	Sine      = iota
+	Trisaw    = iota
+	Pulse     = iota
+	Gate      = iota
+	Sample    = iota
+	Wavetable = iota
 )
 
 // UnitNames is a list of all the names of units, sorted
@@ -453,6 +499,10 @@ var stackUseMonoStereo = map[string][2]StackUse{
 		{Inputs: [][]int{{0, 1}}, Modifies: []bool{true, true}, NumOutputs: 2},   // mono
 		{Inputs: [][]int{{0}, {1}}, Modifies: []bool{true, true}, NumOutputs: 2}, // mono
 	},
+	"width": { // stereo only; the unit has no stereo parameter, so both entries are the same
+		{Inputs: [][]int{{0, 1}, {0, 1}}, Modifies: []bool{true, true}, NumOutputs: 2},
+		{Inputs: [][]int{{0, 1}, {0, 1}}, Modifies: []bool{true, true}, NumOutputs: 2},
+	},
 	"speed": {
 		{Inputs: [][]int{{0}}, Modifies: []bool{true}, NumOutputs: 0},
 		{},
@@ -461,7 +511,8 @@ var stackUseMonoStereo = map[string][2]StackUse{
 		{Inputs: [][]int{{0}}, Modifies: []bool{false}, NumOutputs: 1},
 		{},
 	},
-	"belleq": stackUseEffect,
+	"belleq":  stackUseEffect,
+	"limiter": stackUseEffect,
 }
 var stackUseSendNoPop = [2]StackUse{
 	{Inputs: [][]int{{0}}, Modifies: []bool{true}, NumOutputs: 1},
@@ -471,6 +522,10 @@ var stackUseSendPop = [2]StackUse{
 	{Inputs: [][]int{{0}}, Modifies: []bool{true}, NumOutputs: 0},            // mono
 	{Inputs: [][]int{{0}, {1}}, Modifies: []bool{true, true}, NumOutputs: 0}, // stereo
 }
+var stackUseCompressorSidechain = [2]StackUse{
+	{Inputs: [][]int{{0}, {1}}, Modifies: []bool{false, true}, NumOutputs: 2},                              // mono
+	{Inputs: [][]int{{0}, {1}, {2, 3}, {2, 3}}, Modifies: []bool{false, false, true, true}, NumOutputs: 4}, // stereo
+}
 
 func (u *Unit) StackUse() StackUse {
 	if u.Disabled {
@@ -483,6 +538,11 @@ func (u *Unit) StackUse() StackUse {
 		}
 		return stackUseSendPop[u.Parameters["stereo"]]
 	}
+	if u.Type == "compressor" && u.Parameters["sidechain"] == 1 {
+		// with sidechain, the level is detected from the topmost signal, which
+		// is replaced with the gain
+		return stackUseCompressorSidechain[u.Parameters["stereo"]]
+	}
 	return stackUseMonoStereo[u.Type][u.Parameters["stereo"]]
 }
 
@@ -511,6 +571,19 @@ func (instr *Instrument) Copy() Instrument {
 	for i, u := range instr.Units {
 		ret.Units[i] = u.Copy()
 	}
+	if instr.Samples != nil {
+		ret.Samples = make([]UserSample, len(instr.Samples))
+		for i, s := range instr.Samples {
+			ret.Samples[i] = s.Copy()
+		}
+	}
+	if instr.Wavetables != nil {
+		ret.Wavetables = make([]UserWavetable, len(instr.Wavetables))
+		for i, w := range instr.Wavetables {
+			ret.Wavetables[i] = w.Copy()
+		}
+	}
+	ret.Tuning = instr.Tuning.Copy()
 	return ret
 }
 
@@ -543,7 +616,8 @@ func (p Patch) NumVoices() int {
 }
 
 // NumDelayLines return the total number of delay lines used in the patch;
-// summing the number of delay lines of every delay unit in every instrument
+// summing the number of delay lines of every delay unit in every instrument.
+// Each limiter unit uses one delay line as its lookahead buffer.
 func (p Patch) NumDelayLines() int {
 	total := 0
 	for _, instr := range p {
@@ -551,6 +625,9 @@ func (p Patch) NumDelayLines() int {
 			if unit.Type == "delay" {
 				total += len(unit.VarArgs) * instr.NumVoices
 			}
+			if unit.Type == "limiter" { // lookahead buffer
+				total += instr.NumVoices
+			}
 		}
 	}
 	return total
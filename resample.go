@@ -0,0 +1,52 @@
+package sointu
+
+import "math"
+
+const (
+	resampleZeroCrossings = 16  // the half-width of the interpolation kernel, in zero crossings of the sinc
+	resampleResolution    = 256 // the number of entries in the kernel table per zero crossing
+)
+
+// Resample converts the sample rate of the buffer from the rate from to the
+// rate to and returns the result as a new buffer; if the rates are equal, the
+// buffer is returned as is. The samples are interpolated with a windowed sinc,
+// which also low-pass filters the signal when downsampling, so that the
+// frequencies above the new Nyquist frequency do not alias.
+func (buffer AudioBuffer) Resample(from, to int) AudioBuffer {
+	if from == to || from <= 0 || to <= 0 {
+		return buffer
+	}
+	// the kernel is a sinc with a Blackman window, tabulated for the distances
+	// 0 ... resampleZeroCrossings and interpolated linearly between the entries
+	table := make([]float64, resampleZeroCrossings*resampleResolution+2)
+	for i := range table[:len(table)-1] {
+		x := float64(i) / resampleResolution
+		sinc := 1.0
+		if x > 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		window := 0.42 + 0.5*math.Cos(math.Pi*x/resampleZeroCrossings) + 0.08*math.Cos(2*math.Pi*x/resampleZeroCrossings)
+		table[i] = sinc * window
+	}
+	cutoff := min(1, float64(to)/float64(from)) // relative to the Nyquist frequency of the input
+	halfWidth := resampleZeroCrossings / cutoff // in input samples
+	step := float64(from) / float64(to)
+	ret := make(AudioBuffer, int(math.Round(float64(len(buffer))/step)))
+	for i := range ret {
+		t := float64(i) * step
+		var l, r float64
+		for j := max(int(math.Ceil(t-halfWidth)), 0); j <= min(int(t+halfWidth), len(buffer)-1); j++ {
+			p := math.Abs(t-float64(j)) * cutoff * resampleResolution
+			k := int(p)
+			if k >= len(table)-1 {
+				continue
+			}
+			f := p - float64(k)
+			w := table[k] + (table[k+1]-table[k])*f
+			l += w * float64(buffer[j][0])
+			r += w * float64(buffer[j][1])
+		}
+		ret[i] = [2]float32{float32(l * cutoff), float32(r * cutoff)}
+	}
+	return ret
+}
@@ -0,0 +1,133 @@
+package sointu
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// UserSample is an user provided audio sample, which the sample oscillators can
+	// play instead of the samples in gm.dls. A sample is either embedded in the
+	// song (Data) or references a WAV file (Path), in which case the data is
+	// loaded with Patch.LoadSamples and not saved with the song. The samples
+	// are mono, 16-bit and played back at 44100 Hz.
+	UserSample struct {
+		Name string     `yaml:",omitempty"`
+		Path string     `yaml:",omitempty"`
+		Data SampleData `yaml:",omitempty"`
+	}
+
+	// SampleData is the 16-bit PCM data of a sample. It is marshaled as a
+	// base64 encoded string of little endian words, to keep the song files
+	// reasonably compact.
+	SampleData []int16
+)
+
+// Copy makes a deep copy of a sample.
+func (s *UserSample) Copy() UserSample {
+	ret := *s
+	ret.Data = append(SampleData(nil), s.Data...)
+	return ret
+}
+
+// MarshalYAML omits the data of samples that reference a WAV file, so only the
+// embedded samples end up in the song file.
+func (s UserSample) MarshalYAML() (interface{}, error) {
+	type plainSample UserSample
+	if s.Path != "" {
+		s.Data = nil
+	}
+	return plainSample(s), nil
+}
+
+func (d SampleData) MarshalText() ([]byte, error) {
+	b := make([]byte, len(d)*2)
+	for i, v := range d {
+		binary.LittleEndian.PutUint16(b[i*2:], uint16(v))
+	}
+	ret := make([]byte, base64.StdEncoding.EncodedLen(len(b)))
+	base64.StdEncoding.Encode(ret, b)
+	return ret, nil
+}
+
+func (d *SampleData) UnmarshalText(text []byte) error {
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(b, text)
+	if err != nil {
+		return fmt.Errorf("could not decode sample data: %v", err)
+	}
+	if n%2 != 0 {
+		return errors.New("sample data should have an even number of bytes")
+	}
+	*d = make(SampleData, n/2)
+	for i := range *d {
+		(*d)[i] = int16(binary.LittleEndian.Uint16(b[i*2:]))
+	}
+	return nil
+}
+
+// LoadSamples reads the data of all samples that reference a WAV file but have
+// no data yet. Relative paths are resolved against dir, which is typically the
+// directory of the song file.
+func (p Patch) LoadSamples(dir string) error {
+	for i := range p {
+		for j := range p[i].Samples {
+			s := &p[i].Samples[j]
+			if s.Path == "" || len(s.Data) > 0 {
+				continue
+			}
+			path := s.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("could not open sample %v: %v", s.Path, err)
+			}
+			s.Data, err = ReadWavSample(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("could not read sample %v: %v", s.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadWavSample reads a WAV file (8, 16, 24 or 32-bit PCM, or 32 or 64-bit
+// float) and returns it as sample data, mixing all channels down to mono.
+// Files with sample rates other than 44100 Hz are resampled, as the sample
+// oscillators play the samples at 44100 Hz.
+func ReadWavSample(r io.Reader) (SampleData, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	a, err := decodeWav(b)
+	if err != nil {
+		return nil, err
+	}
+	if a.rate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %v", a.rate)
+	}
+	mono := make(AudioBuffer, len(a.data)/a.channels)
+	for i := range mono {
+		var sum float32
+		for c := 0; c < a.channels; c++ {
+			sum += a.data[i*a.channels+c]
+		}
+		mono[i][0] = sum / float32(a.channels)
+	}
+	mono = mono.Resample(a.rate, sampleRate)
+	ret := make(SampleData, len(mono))
+	for i, v := range mono {
+		ret[i] = int16(clamp(int(math.Round(float64(v[0]*32767))), -32768, 32767))
+	}
+	return ret, nil
+}
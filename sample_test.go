@@ -0,0 +1,34 @@
+package sointu_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/vsariola/sointu"
+)
+
+func TestReadWavSampleResamples(t *testing.T) {
+	samples := make([]float64, 48000)
+	for i := range samples { // one second of 1 kHz sine at 48 kHz
+		samples[i] = 0.5 * math.Sin(2*math.Pi*1000*float64(i)/48000)
+	}
+	data := encodeSamples(samples, 2, binary.LittleEndian, false, false)
+	sample, err := sointu.ReadWavSample(bytes.NewReader(makeWav(1, 48000, 1, 16, data)))
+	if err != nil {
+		t.Fatalf("ReadWavSample failed: %v", err)
+	}
+	if len(sample) != 44100 {
+		t.Fatalf("expected one second at 44100 Hz, got %v samples", len(sample))
+	}
+	crossings := 0 // a 1 kHz sine at 44100 Hz crosses zero upwards 1000 times a second
+	for i := 1; i < len(sample); i++ {
+		if sample[i-1] < 0 && sample[i] >= 0 {
+			crossings++
+		}
+	}
+	if crossings < 999 || crossings > 1001 {
+		t.Fatalf("expected about 1000 periods after resampling, got %v", crossings)
+	}
+}
@@ -0,0 +1,319 @@
+package sointu
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type (
+	// SF2 is the parsed contents of a SoundFont 2 file.
+	SF2 struct {
+		Presets     []SF2Preset
+		Instruments []SF2Instrument
+		Samples     []SF2Sample
+		Data        SampleData // the sample data of all samples
+	}
+
+	// SF2Preset is a preset of a SoundFont, which layers instruments.
+	SF2Preset struct {
+		Name    string
+		Bank    int
+		Program int
+		Zones   []SF2Zone // zones with Instrument >= 0
+	}
+
+	// SF2Instrument is an instrument of a SoundFont, which maps keys and
+	// velocities to samples.
+	SF2Instrument struct {
+		Name  string
+		Zones []SF2Zone // zones with Sample >= 0
+	}
+
+	// SF2Zone is a preset or instrument zone. Generators of the global zone
+	// are already merged into the zones.
+	SF2Zone struct {
+		KeyLow, KeyHigh int
+		VelLow, VelHigh int
+		Instrument      int         // index to SF2.Instruments, -1 if none
+		Sample          int         // index to SF2.Samples, -1 if none
+		Generators      map[int]int // all generators of the zone, by generator operator
+	}
+
+	// SF2Sample is a sample header of a SoundFont. The offsets are in samples
+	// and relative to the beginning of SF2.Data.
+	SF2Sample struct {
+		Name            string
+		Start, End      int
+		LoopStart       int
+		LoopEnd         int
+		SampleRate      int
+		RootKey         int
+		PitchCorrection int // in cents
+	}
+)
+
+// generator operators of the SoundFont 2 specification that are needed to play
+// the samples
+const (
+	sf2GenStartAddrsOffset           = 0
+	sf2GenEndAddrsOffset             = 1
+	sf2GenStartloopAddrsOffset       = 2
+	sf2GenEndloopAddrsOffset         = 3
+	sf2GenStartAddrsCoarseOffset     = 4
+	sf2GenEndAddrsCoarseOffset       = 12
+	sf2GenKeyRange                   = 43
+	sf2GenVelRange                   = 44
+	sf2GenStartloopAddrsCoarseOffset = 45
+	sf2GenEndloopAddrsCoarseOffset   = 50
+	sf2GenInstrument                 = 41
+	sf2GenKeynum                     = 46
+	sf2GenVelocity                   = 47
+	sf2GenExclusiveClass             = 57
+	sf2GenCoarseTune                 = 51
+	sf2GenFineTune                   = 52
+	sf2GenSampleID                   = 53
+	sf2GenSampleModes                = 54
+	sf2GenOverridingRootKey          = 58
+)
+
+// ReadSF2 reads a SoundFont 2 file from r.
+func ReadSF2(r io.Reader) (*SF2, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "sfbk" {
+		return nil, errors.New("not a RIFF sfbk file")
+	}
+	chunks := map[string][]byte{}
+	if err := readSF2Chunks(b[12:min(8+int(binary.LittleEndian.Uint32(b[4:])), len(b))], chunks); err != nil {
+		return nil, err
+	}
+	for _, id := range []string{"smpl", "phdr", "pbag", "pgen", "inst", "ibag", "igen", "shdr"} {
+		if _, ok := chunks[id]; !ok {
+			return nil, fmt.Errorf("missing %q chunk", id)
+		}
+	}
+	ret := &SF2{Data: make(SampleData, len(chunks["smpl"])/2)}
+	for i := range ret.Data {
+		ret.Data[i] = int16(binary.LittleEndian.Uint16(chunks["smpl"][i*2:]))
+	}
+	le := binary.LittleEndian
+	shdr := chunks["shdr"]
+	for i := 0; i+46 <= len(shdr)-46; i += 46 { // the last record is the terminal EOS record
+		h := shdr[i : i+46]
+		ret.Samples = append(ret.Samples, SF2Sample{
+			Name:            sf2Name(h[0:20]),
+			Start:           int(le.Uint32(h[20:])),
+			End:             int(le.Uint32(h[24:])),
+			LoopStart:       int(le.Uint32(h[28:])),
+			LoopEnd:         int(le.Uint32(h[32:])),
+			SampleRate:      int(le.Uint32(h[36:])),
+			RootKey:         int(h[40]),
+			PitchCorrection: int(int8(h[41])),
+		})
+	}
+	inst := chunks["inst"]
+	for i := 0; i+22 <= len(inst)-22; i += 22 {
+		zones, err := readSF2Zones(chunks["ibag"], chunks["igen"], int(le.Uint16(inst[i+20:])), int(le.Uint16(inst[i+42:])), sf2GenSampleID)
+		if err != nil {
+			return nil, fmt.Errorf("instrument %v: %v", i/22, err)
+		}
+		ret.Instruments = append(ret.Instruments, SF2Instrument{Name: sf2Name(inst[i : i+20]), Zones: zones})
+	}
+	phdr := chunks["phdr"]
+	for i := 0; i+38 <= len(phdr)-38; i += 38 {
+		zones, err := readSF2Zones(chunks["pbag"], chunks["pgen"], int(le.Uint16(phdr[i+24:])), int(le.Uint16(phdr[i+62:])), sf2GenInstrument)
+		if err != nil {
+			return nil, fmt.Errorf("preset %v: %v", i/38, err)
+		}
+		ret.Presets = append(ret.Presets, SF2Preset{
+			Name:    sf2Name(phdr[i : i+20]),
+			Program: int(le.Uint16(phdr[i+20:])),
+			Bank:    int(le.Uint16(phdr[i+22:])),
+			Zones:   zones,
+		})
+	}
+	for _, p := range ret.Presets {
+		for _, z := range p.Zones {
+			if z.Instrument >= len(ret.Instruments) {
+				return nil, fmt.Errorf("preset %v refers to a nonexistent instrument %v", p.Name, z.Instrument)
+			}
+		}
+	}
+	for _, instr := range ret.Instruments {
+		for _, z := range instr.Zones {
+			if z.Sample >= len(ret.Samples) {
+				return nil, fmt.Errorf("instrument %v refers to a nonexistent sample %v", instr.Name, z.Sample)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// ZoneSample returns the sample data of an instrument zone, with the sample
+// offsets of the zone applied, and the loop relative to the returned data. If
+// the zone does not loop, loopLength is 0.
+func (s *SF2) ZoneSample(zone SF2Zone) (data SampleData, loopStart, loopLength int, err error) {
+	if zone.Sample < 0 || zone.Sample >= len(s.Samples) {
+		return nil, 0, 0, errors.New("the zone has no sample")
+	}
+	h := s.Samples[zone.Sample]
+	g := zone.Generators
+	start := h.Start + g[sf2GenStartAddrsOffset] + g[sf2GenStartAddrsCoarseOffset]*32768
+	end := h.End + g[sf2GenEndAddrsOffset] + g[sf2GenEndAddrsCoarseOffset]*32768
+	if start < 0 || end > len(s.Data) || start >= end {
+		return nil, 0, 0, fmt.Errorf("sample %v is out of bounds", h.Name)
+	}
+	data = s.Data[start:end]
+	if mode := g[sf2GenSampleModes]; mode == 1 || mode == 3 {
+		loopStart = h.LoopStart + g[sf2GenStartloopAddrsOffset] + g[sf2GenStartloopAddrsCoarseOffset]*32768 - start
+		loopEnd := h.LoopEnd + g[sf2GenEndloopAddrsOffset] + g[sf2GenEndloopAddrsCoarseOffset]*32768 - start
+		if loopStart >= 0 && loopEnd <= len(data) && loopStart < loopEnd {
+			loopLength = loopEnd - loopStart
+		} else {
+			loopStart = 0
+		}
+	}
+	return data, loopStart, loopLength, nil
+}
+
+// RootKey returns the MIDI note at which the sample of the zone plays at its
+// original pitch, and the tuning in cents that should be added to the pitch
+// when playing the zone.
+func (s *SF2) RootKey(zone SF2Zone) (key int, cents int) {
+	h := s.Samples[zone.Sample]
+	key = h.RootKey
+	if k, ok := zone.Generators[sf2GenOverridingRootKey]; ok && k >= 0 && k <= 127 {
+		key = k
+	}
+	cents = h.PitchCorrection + zone.Generators[sf2GenFineTune] + zone.Generators[sf2GenCoarseTune]*100
+	return key, cents
+}
+
+// ApplyPresetZone returns the instrument zone with the generators of the
+// preset zone applied on top of it, as in the SoundFont 2 specification: the
+// key and velocity ranges are intersected and the other preset generators are
+// added to the instrument generators, e.g. to tune the instrument. The
+// generators valid only in instrument zones, like the sample offsets, sample
+// modes and the overriding root key, are ignored in the preset zone. Returns
+// false if the ranges of the zones do not overlap.
+func ApplyPresetZone(preset, instrument SF2Zone) (SF2Zone, bool) {
+	ret := instrument
+	ret.Instrument = preset.Instrument
+	ret.KeyLow, ret.KeyHigh = max(preset.KeyLow, instrument.KeyLow), min(preset.KeyHigh, instrument.KeyHigh)
+	ret.VelLow, ret.VelHigh = max(preset.VelLow, instrument.VelLow), min(preset.VelHigh, instrument.VelHigh)
+	if ret.KeyLow > ret.KeyHigh || ret.VelLow > ret.VelHigh {
+		return SF2Zone{}, false
+	}
+	ret.Generators = make(map[int]int, len(instrument.Generators)+len(preset.Generators))
+	for k, v := range instrument.Generators {
+		ret.Generators[k] = v
+	}
+	for k, v := range preset.Generators {
+		switch k {
+		case sf2GenStartAddrsOffset, sf2GenEndAddrsOffset, sf2GenStartloopAddrsOffset, sf2GenEndloopAddrsOffset,
+			sf2GenStartAddrsCoarseOffset, sf2GenEndAddrsCoarseOffset, sf2GenStartloopAddrsCoarseOffset, sf2GenEndloopAddrsCoarseOffset,
+			sf2GenKeynum, sf2GenVelocity, sf2GenSampleModes, sf2GenExclusiveClass, sf2GenOverridingRootKey, sf2GenSampleID:
+			// instrument level only
+		case sf2GenInstrument:
+			// already in ret.Instrument
+		case sf2GenKeyRange:
+			ret.Generators[k] = ret.KeyLow | ret.KeyHigh<<8
+		case sf2GenVelRange:
+			ret.Generators[k] = ret.VelLow | ret.VelHigh<<8
+		default:
+			ret.Generators[k] += v
+		}
+	}
+	return ret, true
+}
+
+func readSF2Chunks(b []byte, chunks map[string][]byte) error {
+	for pos := 0; pos+8 <= len(b); {
+		id := string(b[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(b[pos+4:]))
+		pos += 8
+		if size < 0 || pos+size > len(b) {
+			return fmt.Errorf("chunk %q extends beyond its parent", id)
+		}
+		if id == "LIST" {
+			if size < 4 {
+				return errors.New("list is too short")
+			}
+			if err := readSF2Chunks(b[pos+4:pos+size], chunks); err != nil {
+				return err
+			}
+		} else {
+			chunks[id] = b[pos : pos+size]
+		}
+		pos += size + size&1
+	}
+	return nil
+}
+
+// readSF2Zones reads the zones bagStart...bagEnd-1. The zones are terminated
+// by the generator term (sf2GenInstrument for presets, sf2GenSampleID for
+// instruments); a first zone without it is the global zone, whose generators
+// are used as defaults for the other zones.
+func readSF2Zones(bags, gens []byte, bagStart, bagEnd int, term int) ([]SF2Zone, error) {
+	le := binary.LittleEndian
+	if bagStart > bagEnd || (bagEnd+1)*4 > len(bags) {
+		return nil, errors.New("zone indices out of bounds")
+	}
+	var global map[int]int
+	var ret []SF2Zone
+	for bag := bagStart; bag < bagEnd; bag++ {
+		genStart, genEnd := int(le.Uint16(bags[bag*4:])), int(le.Uint16(bags[bag*4+4:]))
+		if genStart > genEnd || genEnd*4 > len(gens) {
+			return nil, errors.New("generator indices out of bounds")
+		}
+		z := SF2Zone{KeyHigh: 127, VelHigh: 127, Instrument: -1, Sample: -1, Generators: map[int]int{}}
+		for k, v := range global {
+			z.Generators[k] = v
+		}
+		terminated := false
+		for gen := genStart; gen < genEnd; gen++ {
+			op := int(le.Uint16(gens[gen*4:]))
+			amount := gens[gen*4+2 : gen*4+4]
+			switch op {
+			case sf2GenKeyRange, sf2GenVelRange:
+				z.Generators[op] = int(amount[0]) | int(amount[1])<<8
+			case sf2GenInstrument, sf2GenSampleID:
+				z.Generators[op] = int(le.Uint16(amount))
+				terminated = op == term
+			default:
+				z.Generators[op] = int(int16(le.Uint16(amount)))
+			}
+		}
+		if !terminated {
+			if bag == bagStart {
+				global = z.Generators
+			}
+			continue // global zone or a zone without instrument / sample, which should be ignored
+		}
+		if r, ok := z.Generators[sf2GenKeyRange]; ok {
+			z.KeyLow, z.KeyHigh = r&0xFF, r>>8
+		}
+		if r, ok := z.Generators[sf2GenVelRange]; ok {
+			z.VelLow, z.VelHigh = r&0xFF, r>>8
+		}
+		if term == sf2GenInstrument {
+			z.Instrument = z.Generators[sf2GenInstrument]
+		} else {
+			z.Sample = z.Generators[sf2GenSampleID]
+		}
+		ret = append(ret, z)
+	}
+	return ret, nil
+}
+
+func sf2Name(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,197 @@
+package sointu_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/internal/rifftest"
+)
+
+type (
+	sf2Gen struct{ op, amount int }
+
+	sf2PresetDef struct {
+		name          string
+		bank, program int
+		zones         [][]sf2Gen
+	}
+
+	sf2InstrumentDef struct {
+		name  string
+		zones [][]sf2Gen
+	}
+)
+
+func sf2Name(name string) []byte {
+	ret := make([]byte, 20)
+	copy(ret, name)
+	return ret
+}
+
+// makeSF2 builds a SoundFont 2 file. The zones are lists of generators; a
+// range generator has the high key in the high byte of the amount.
+func makeSF2(presets []sf2PresetDef, instruments []sf2InstrumentDef, samples []sointu.SF2Sample, data []int16) []byte {
+	var phdr, pbag, pgen, inst, ibag, igen, shdr []byte
+	bags := func(bag, gen *[]byte, zones [][]sf2Gen) {
+		for _, z := range zones {
+			*bag = append(*bag, rifftest.LE(uint16(len(*gen)/4), uint16(0))...)
+			for _, g := range z {
+				*gen = append(*gen, rifftest.LE(uint16(g.op), uint16(g.amount))...)
+			}
+		}
+	}
+	for _, p := range presets {
+		phdr = append(phdr, sf2Name(p.name)...)
+		phdr = append(phdr, rifftest.LE(uint16(p.program), uint16(p.bank), uint16(len(pbag)/4), uint32(0), uint32(0), uint32(0))...)
+		bags(&pbag, &pgen, p.zones)
+	}
+	phdr = append(phdr, sf2Name("EOP")...)
+	phdr = append(phdr, rifftest.LE(uint16(0), uint16(0), uint16(len(pbag)/4), uint32(0), uint32(0), uint32(0))...)
+	for _, i := range instruments {
+		inst = append(inst, sf2Name(i.name)...)
+		inst = append(inst, rifftest.LE(uint16(len(ibag)/4))...)
+		bags(&ibag, &igen, i.zones)
+	}
+	inst = append(inst, sf2Name("EOI")...)
+	inst = append(inst, rifftest.LE(uint16(len(ibag)/4))...)
+	pbag = append(pbag, rifftest.LE(uint16(len(pgen)/4), uint16(0))...)
+	ibag = append(ibag, rifftest.LE(uint16(len(igen)/4), uint16(0))...)
+	pgen = append(pgen, make([]byte, 4)...)
+	igen = append(igen, make([]byte, 4)...)
+	for _, s := range append(samples, sointu.SF2Sample{Name: "EOS"}) {
+		shdr = append(shdr, sf2Name(s.Name)...)
+		shdr = append(shdr, rifftest.LE(uint32(s.Start), uint32(s.End), uint32(s.LoopStart), uint32(s.LoopEnd), uint32(s.SampleRate), uint8(s.RootKey), int8(s.PitchCorrection), uint16(0), uint16(1))...)
+	}
+	return rifftest.Chunk("RIFF", []byte("sfbk"),
+		rifftest.List("INFO", rifftest.Chunk("ifil", rifftest.LE(uint16(2), uint16(1)))),
+		rifftest.List("sdta", rifftest.Chunk("smpl", rifftest.LE(data))),
+		rifftest.List("pdta",
+			rifftest.Chunk("phdr", phdr), rifftest.Chunk("pbag", pbag), rifftest.Chunk("pmod", make([]byte, 10)), rifftest.Chunk("pgen", pgen),
+			rifftest.Chunk("inst", inst), rifftest.Chunk("ibag", ibag), rifftest.Chunk("imod", make([]byte, 10)), rifftest.Chunk("igen", igen),
+			rifftest.Chunk("shdr", shdr),
+		),
+	)
+}
+
+const (
+	genStartAddrsOffset     = 0
+	genStartloopAddrsOffset = 2
+	genInstrument           = 41
+	genKeyRange             = 43
+	genCoarseTune           = 51
+	genFineTune             = 52
+	genSampleID             = 53
+	genSampleModes          = 54
+	genOverridingRootKey    = 58
+)
+
+func testSF2() []byte {
+	data := make([]int16, 100)
+	for i := range data {
+		data[i] = int16(i)
+	}
+	return makeSF2(
+		[]sf2PresetDef{{name: "Piano", bank: 1, program: 2, zones: [][]sf2Gen{
+			{{genFineTune, 10}}, // global zone
+			{{genKeyRange, 50 | 70<<8}, {genCoarseTune, 1}, {genInstrument, 0}}, // layers the instrument on keys 50-70
+			{{genOverridingRootKey, 10}, {genStartAddrsOffset, 5}, {genInstrument, 1}},
+		}}},
+		[]sf2InstrumentDef{
+			{name: "Low and high", zones: [][]sf2Gen{
+				{{genKeyRange, 0 | 59<<8}, {genSampleID, 0}},
+				{{genKeyRange, 60 | 127<<8}, {genCoarseTune, -2}, {genSampleModes, 1}, {genSampleID, 1}},
+			}},
+			{name: "Root key", zones: [][]sf2Gen{
+				{{genOverridingRootKey, 64}, {genStartloopAddrsOffset, 2}, {genSampleModes, 1}, {genSampleID, 1}},
+			}},
+		},
+		[]sointu.SF2Sample{
+			{Name: "Low", Start: 0, End: 40, SampleRate: 22050, RootKey: 48},
+			{Name: "High", Start: 50, End: 90, LoopStart: 60, LoopEnd: 80, SampleRate: 44100, RootKey: 72, PitchCorrection: -5},
+		},
+		data,
+	)
+}
+
+func TestReadSF2(t *testing.T) {
+	sf, err := sointu.ReadSF2(bytes.NewReader(testSF2()))
+	if err != nil {
+		t.Fatalf("ReadSF2 failed: %v", err)
+	}
+	if len(sf.Data) != 100 || sf.Data[99] != 99 {
+		t.Errorf("wrong sample data: %v", sf.Data)
+	}
+	if len(sf.Samples) != 2 || sf.Samples[1].Name != "High" || sf.Samples[1].LoopStart != 60 || sf.Samples[1].RootKey != 72 || sf.Samples[1].PitchCorrection != -5 {
+		t.Errorf("wrong samples: %+v", sf.Samples)
+	}
+	if len(sf.Presets) != 1 {
+		t.Fatalf("expected 1 preset, got %v", len(sf.Presets))
+	}
+	p := sf.Presets[0]
+	if p.Name != "Piano" || p.Bank != 1 || p.Program != 2 || len(p.Zones) != 2 {
+		t.Fatalf("wrong preset: %+v", p)
+	}
+	if z := p.Zones[0]; z.Instrument != 0 || z.KeyLow != 50 || z.KeyHigh != 70 || z.Generators[genFineTune] != 10 || z.Generators[genCoarseTune] != 1 {
+		t.Errorf("the preset zone should have its key range and the generators of the global zone: %+v", z)
+	}
+	if len(sf.Instruments) != 2 || len(sf.Instruments[0].Zones) != 2 {
+		t.Fatalf("wrong instruments: %+v", sf.Instruments)
+	}
+	if z := sf.Instruments[0].Zones[1]; z.Sample != 1 || z.KeyLow != 60 || z.KeyHigh != 127 || z.Generators[genCoarseTune] != -2 {
+		t.Errorf("wrong instrument zone: %+v", z)
+	}
+}
+
+func TestSF2ZoneSample(t *testing.T) {
+	sf, err := sointu.ReadSF2(bytes.NewReader(testSF2()))
+	if err != nil {
+		t.Fatalf("ReadSF2 failed: %v", err)
+	}
+	data, loopStart, loopLength, err := sf.ZoneSample(sf.Instruments[0].Zones[0])
+	if err != nil || len(data) != 40 || data[0] != 0 || loopLength != 0 {
+		t.Errorf("a zone without sample modes should not loop: %v %v %v %v", len(data), loopStart, loopLength, err)
+	}
+	data, loopStart, loopLength, err = sf.ZoneSample(sf.Instruments[0].Zones[1])
+	if err != nil || len(data) != 40 || data[0] != 50 || loopStart != 10 || loopLength != 20 {
+		t.Errorf("the loop should be relative to the start of the sample: %v %v %v %v", len(data), loopStart, loopLength, err)
+	}
+	_, loopStart, loopLength, err = sf.ZoneSample(sf.Instruments[1].Zones[0])
+	if err != nil || loopStart != 12 || loopLength != 18 {
+		t.Errorf("the loop offset generator should move the loop start: %v %v %v", loopStart, loopLength, err)
+	}
+	if key, cents := sf.RootKey(sf.Instruments[1].Zones[0]); key != 64 || cents != -5 {
+		t.Errorf("the overriding root key should replace the root key of the sample, got %v %v", key, cents)
+	}
+}
+
+func TestApplyPresetZone(t *testing.T) {
+	sf, err := sointu.ReadSF2(bytes.NewReader(testSF2()))
+	if err != nil {
+		t.Fatalf("ReadSF2 failed: %v", err)
+	}
+	p := sf.Presets[0]
+	if _, ok := sointu.ApplyPresetZone(p.Zones[0], sf.Instruments[0].Zones[0]); !ok {
+		t.Errorf("the key ranges 50-70 and 0-59 overlap")
+	}
+	z, ok := sointu.ApplyPresetZone(p.Zones[0], sf.Instruments[0].Zones[1])
+	if !ok {
+		t.Fatalf("the key ranges 50-70 and 60-127 overlap")
+	}
+	if z.KeyLow != 60 || z.KeyHigh != 70 || z.Sample != 1 || z.Instrument != 0 {
+		t.Errorf("the key ranges should be intersected: %+v", z)
+	}
+	if key, cents := sf.RootKey(z); key != 72 || cents != -5+10+(1-2)*100 {
+		t.Errorf("the preset tuning should be added to the instrument tuning, got %v %v", key, cents)
+	}
+	outside := p.Zones[0]
+	outside.KeyLow, outside.KeyHigh = 0, 10
+	if _, ok := sointu.ApplyPresetZone(outside, sf.Instruments[0].Zones[1]); ok {
+		t.Errorf("the key ranges 0-10 and 60-127 do not overlap")
+	}
+	z, _ = sointu.ApplyPresetZone(p.Zones[1], sf.Instruments[1].Zones[0])
+	data, _, _, _ := sf.ZoneSample(z)
+	if key, _ := sf.RootKey(z); key != 64 || data[0] != 50 {
+		t.Errorf("the root key and the sample offsets are instrument level generators and should be ignored in presets, got %v %v", key, data[0])
+	}
+}
@@ -19,6 +19,7 @@ int main(int argc, char* argv[])
     const unsigned char opcodes[] = { SU_OSCILLATOR_ID + 1, // STEREO                                     
                                      SU_ADVANCE_ID };
     const unsigned char operands[] = { 69, 74, 0, 0, 82, 128, 128 };
+    SampleOffset sampleoffset = { 91507, 5448, 563 };
     int errcode;
     int time;
     int samples;
@@ -27,14 +28,12 @@ int main(int argc, char* argv[])
     // initialize Synth
     synth = (Synth*)malloc(sizeof(Synth));
     memset(synth, 0, sizeof(Synth));
-    memcpy(synth->Opcodes, opcodes, sizeof(opcodes));
-    memcpy(synth->Operands, operands, sizeof(operands));
+    synth->Opcodes = (unsigned char*)opcodes;
+    synth->Operands = (unsigned char*)operands;
     synth->NumVoices = 3;
     synth->Polyphony = 6;
     synth->RandSeed = 1;
-    synth->SampleOffsets[0].Start = 91507;
-    synth->SampleOffsets[0].LoopStart = 5448;
-    synth->SampleOffsets[0].LoopLength = 563;
+    synth->SampleOffsets = &sampleoffset;
     // initialize Buffer
     buffer = (float*)malloc(2 * sizeof(float) * su_max_samples);
     // triger first voice
@@ -20,8 +20,8 @@ void SU_CALLCONV su_render_song(float *buffer)
     // initialize Synth
     synth = (Synth *)malloc(sizeof(Synth));
     memset(synth, 0, sizeof(Synth));
-    memcpy(synth->Opcodes, opcodes, sizeof(opcodes));
-    memcpy(synth->Operands, operands, sizeof(operands));
+    synth->Opcodes = (unsigned char *)opcodes;
+    synth->Operands = (unsigned char *)operands;
     synth->NumVoices = 1;
     synth->Polyphony = 0;
     synth->RandSeed = 1;
@@ -29,8 +29,8 @@ int main(int argc, char *argv[])
     // initialize Synth
     synth = (Synth *)malloc(sizeof(Synth));
     memset(synth, 0, sizeof(Synth));
-    memcpy(synth->Opcodes, opcodes, sizeof(opcodes));
-    memcpy(synth->Operands, operands, sizeof(operands));
+    synth->Opcodes = (unsigned char *)opcodes;
+    synth->Operands = (unsigned char *)operands;
     synth->NumVoices = 1;
     synth->Polyphony = 0;
     synth->RandSeed = 1;
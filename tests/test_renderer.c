@@ -14,6 +14,17 @@
 #include <stdio.h>
 
 #include TEST_HEADER
+
+#ifdef TEST_TOLERANT
+// The C player does its math like the Go VM, so it is checked as liberally as
+// the Go VM tests: the x86 VM that rendered the expected outputs rounds
+// slightly differently, so a neighbouring frame may also match and up to 200
+// bigger errors are tolerated.
+#define TEST_THRESHOLD 1e-2f
+#else
+#define TEST_THRESHOLD 1e-3f
+#endif
+
 SUsample buf[SU_BUFFER_LENGTH];
 SUsample filebuf[SU_BUFFER_LENGTH];
 #ifdef SU_SYNC
@@ -32,6 +43,9 @@ int main(int argc, char *argv[])
     long fsize;
     float max_diff;
     float diff;
+#ifdef TEST_TOLERANT
+    int errors = 0;
+#endif
 
     if (argc < 2)
     {
@@ -96,7 +110,15 @@ int main(int argc, char *argv[])
     for (n = 0; n < SU_BUFFER_LENGTH; n++)
     {
         diff = (float)fabs((float)(buf[n] - filebuf[n]) / SU_SAMPLE_RANGE);
-        if (diff > 1e-3f || isnan(diff))
+#ifdef TEST_TOLERANT
+        if (diff > TEST_THRESHOLD && n >= SU_CHANNEL_COUNT)
+            diff = fminf(diff, (float)fabs((float)(buf[n - SU_CHANNEL_COUNT] - filebuf[n]) / SU_SAMPLE_RANGE));
+        if (diff > TEST_THRESHOLD && n + SU_CHANNEL_COUNT < SU_BUFFER_LENGTH)
+            diff = fminf(diff, (float)fabs((float)(buf[n + SU_CHANNEL_COUNT] - filebuf[n]) / SU_SAMPLE_RANGE));
+        if (diff > TEST_THRESHOLD && !isnan(diff) && ++errors <= 200)
+            continue;
+#endif
+        if (diff > TEST_THRESHOLD || isnan(diff))
         {
             fprintf(stderr, "Sointu rendered different wave than expected\n");
             goto fail;
@@ -0,0 +1,177 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/vsariola/sointu"
+)
+
+type (
+	// LoudnessAnalysis is the loudness and the peaks of a whole rendered
+	// song, as measured by the same detector that the tracker uses live.
+	LoudnessAnalysis struct {
+		Integrated   Decibel    `json:"integrated"`
+		Range        Decibel    `json:"range"` // loudness range (LRA), in LU
+		MaxMomentary Decibel    `json:"maxMomentary"`
+		MaxShortTerm Decibel    `json:"maxShortTerm"`
+		Peak         [2]Decibel `json:"peak"` // true peaks if oversampling, sample peaks otherwise; left and right
+	}
+
+	// SongAnalysis is the loudness analysis of a song, and of each of its
+	// instruments played solo.
+	SongAnalysis struct {
+		LoudnessAnalysis
+		Instruments []InstrumentAnalysis `json:"instruments"`
+	}
+
+	// InstrumentAnalysis is the loudness analysis of an instrument played solo.
+	InstrumentAnalysis struct {
+		Name string `json:"name"`
+		LoudnessAnalysis
+	}
+
+	// soloSynther wraps a Synther so that only the voices first ... last-1
+	// are triggered, like muting all the other instruments in the tracker.
+	soloSynther struct {
+		sointu.Synther
+		first, last int
+	}
+
+	soloSynth struct {
+		sointu.Synth
+		first, last int
+	}
+)
+
+// AnalyzeLoudness measures the loudness and the peaks of the buffer, using
+// the given weighting for the loudness and oversampling for the true peaks.
+func AnalyzeLoudness(buffer sointu.AudioBuffer, weighting WeightingType, oversampling bool) LoudnessAnalysis {
+	if len(buffer) == 0 {
+		silence := Decibel(math.Inf(-1))
+		return LoudnessAnalysis{silence, 0, silence, silence, [2]Decibel{silence, silence}}
+	}
+	ld := makeLoudnessDetector(weighting)
+	pd := makePeakDetector(oversampling)
+	var loudness LoudnessResult
+	var peaks PeakResult
+	for len(buffer) > 0 { // the same 100 ms chunks as in the live detector; the last chunk can be shorter
+		n := min(len(buffer), 4410)
+		loudness = ld.update(buffer[:n])
+		peaks = pd.update(buffer[:n])
+		buffer = buffer[n:]
+	}
+	ld.integrate() // the live detector integrates only every second
+	return LoudnessAnalysis{
+		Integrated:   powerToDecibel(ld.integratedPower),
+		Range:        ld.loudnessRange,
+		MaxMomentary: loudness[LoudnessMaxMomentary],
+		MaxShortTerm: loudness[LoudnessMaxShortTerm],
+		Peak:         peaks[PeakIntegrated],
+	}
+}
+
+// AnalyzeSong renders the song with the synther and analyzes its loudness,
+// and then renders and analyzes each instrument solo, to see how much each
+// instrument contributes. Note that an instrument played solo does not send
+// signals to other instruments, so e.g. a reverb instrument is silent solo.
+func AnalyzeSong(synther sointu.Synther, song sointu.Song, weighting WeightingType, oversampling bool) (SongAnalysis, error) {
+	buffer, err := sointu.Play(synther, song, nil)
+	if err != nil {
+		return SongAnalysis{}, err
+	}
+	ret := SongAnalysis{LoudnessAnalysis: AnalyzeLoudness(buffer, weighting, oversampling)}
+	voice := 0
+	for i, instr := range song.Patch {
+		solo := soloSynther{Synther: synther, first: voice, last: voice + instr.NumVoices}
+		voice += instr.NumVoices
+		buffer, err := sointu.Play(solo, song, nil)
+		if err != nil {
+			return SongAnalysis{}, fmt.Errorf("could not render instrument %v solo: %v", i, err)
+		}
+		name := instr.Name
+		if name == "" {
+			name = fmt.Sprintf("%v", i)
+		}
+		ret.Instruments = append(ret.Instruments, InstrumentAnalysis{name, AnalyzeLoudness(buffer, weighting, oversampling)})
+	}
+	return ret, nil
+}
+
+func (s soloSynther) Synth(patch sointu.Patch, bpm int) (sointu.Synth, error) {
+	synth, err := s.Synther.Synth(patch, bpm)
+	if err != nil {
+		return nil, err
+	}
+	return soloSynth{synth, s.first, s.last}, nil
+}
+
+func (s soloSynth) Trigger(voice int, note byte) {
+	if voice >= s.first && voice < s.last {
+		s.Synth.Trigger(voice, note)
+	}
+}
+
+// MarshalJSON marshals the decibels as a number, or as null if they are not
+// finite, e.g. the loudness of silence, as JSON has no infinities.
+func (d Decibel) MarshalJSON() ([]byte, error) {
+	if math.IsInf(float64(d), 0) || math.IsNaN(float64(d)) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float32(d))
+}
+
+// NormalizationGain returns the gain that brings the integrated loudness of
+// the analyzed audio to the target, limited so that the peak does not exceed
+// maxPeak. Silence is not amplified.
+func NormalizationGain(a LoudnessAnalysis, target, maxPeak Decibel) Decibel {
+	if math.IsInf(float64(a.Integrated), -1) {
+		return 0
+	}
+	gain := target - a.Integrated
+	if peak := max(a.Peak[0], a.Peak[1]); peak+gain > maxPeak {
+		gain = maxPeak - peak
+	}
+	return gain
+}
+
+// ApplyGain multiplies the buffer by the gain, in place.
+func ApplyGain(buffer sointu.AudioBuffer, gain Decibel) {
+	g := float32(math.Pow(10, float64(gain)/20))
+	for i := range buffer {
+		buffer[i][0] *= g
+		buffer[i][1] *= g
+	}
+}
+
+// ScaleOutputGains multiplies the gains of the units writing to the main
+// outputs (out, the outgain of outaux and aux to the left or right channel) by
+// the gain, so that the song plays that much louder. The aux buses are not
+// scaled, as they are output through other instruments. Modulations of the
+// gains are not scaled. The gains are rounded to integers; returns false if
+// some gain had to be clamped to the maximum of 128.
+func ScaleOutputGains(patch sointu.Patch, gain Decibel) (ok bool) {
+	g := math.Pow(10, float64(gain)/20)
+	ok = true
+	scale := func(params map[string]int, name string) {
+		v := int(math.Round(float64(params[name]) * g))
+		if v > 128 {
+			v, ok = 128, false
+		}
+		params[name] = v
+	}
+	for _, instr := range patch {
+		for _, unit := range instr.Units {
+			switch {
+			case unit.Type == "out":
+				scale(unit.Parameters, "gain")
+			case unit.Type == "outaux":
+				scale(unit.Parameters, "outgain")
+			case unit.Type == "aux" && unit.Parameters["channel"] < 2:
+				scale(unit.Parameters, "gain")
+			}
+		}
+	}
+	return ok
+}
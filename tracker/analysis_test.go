@@ -0,0 +1,78 @@
+package tracker_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/tracker"
+)
+
+func TestAnalyzeLoudness(t *testing.T) {
+	buffer := make(sointu.AudioBuffer, 10*44100)
+	for i := range buffer {
+		v := float32(0.1 * math.Sin(2*math.Pi*1000*float64(i)/44100))
+		buffer[i] = [2]float32{v, v}
+	}
+	// a stereo 1 kHz sine with amplitude 0.1 is -20 LUFS and has a -20 dB peak
+	a := tracker.AnalyzeLoudness(buffer, tracker.KWeighting, true)
+	if math.Abs(float64(a.Integrated)+20) > 0.2 {
+		t.Errorf("expected integrated loudness of -20 dB, got %v", a.Integrated)
+	}
+	if a.Range > 0.5 {
+		t.Errorf("expected no loudness range for a steady sine, got %v", a.Range)
+	}
+	for _, p := range a.Peak {
+		if math.Abs(float64(p)+20) > 0.2 {
+			t.Errorf("expected peak of -20 dB, got %v", p)
+		}
+	}
+	silence := tracker.AnalyzeLoudness(make(sointu.AudioBuffer, 44100), tracker.KWeighting, true)
+	if !math.IsInf(float64(silence.Integrated), -1) {
+		t.Errorf("expected silence to have loudness -Inf, got %v", silence.Integrated)
+	}
+}
+
+func TestLoudnessRange(t *testing.T) {
+	buffer := make(sointu.AudioBuffer, 40*44100)
+	for i := range buffer {
+		amplitude := 0.1 // 20 s at -20 LUFS, then 20 s at -30 LUFS
+		if i >= len(buffer)/2 {
+			amplitude = 0.1 / math.Sqrt(10)
+		}
+		v := float32(amplitude * math.Sin(2*math.Pi*1000*float64(i)/44100))
+		buffer[i] = [2]float32{v, v}
+	}
+	a := tracker.AnalyzeLoudness(buffer, tracker.KWeighting, true)
+	if math.Abs(float64(a.Range)-10) > 0.5 {
+		t.Errorf("expected loudness range of 10 LU, got %v", a.Range)
+	}
+}
+
+func TestNormalizationGain(t *testing.T) {
+	a := tracker.LoudnessAnalysis{Integrated: -20, Peak: [2]tracker.Decibel{-10, -8}}
+	if gain := tracker.NormalizationGain(a, -14, -1); gain != 6 {
+		t.Errorf("expected gain of 6 dB, got %v", gain)
+	}
+	if gain := tracker.NormalizationGain(a, -5, -1); gain != 7 {
+		t.Errorf("expected the peak to limit the gain to 7 dB, got %v", gain)
+	}
+}
+
+func TestScaleOutputGains(t *testing.T) {
+	patch := sointu.Patch{sointu.Instrument{NumVoices: 1, Units: []sointu.Unit{
+		{Type: "out", Parameters: map[string]int{"stereo": 1, "gain": 64}},
+		{Type: "aux", Parameters: map[string]int{"stereo": 1, "gain": 64, "channel": 2}},
+		{Type: "outaux", Parameters: map[string]int{"stereo": 1, "outgain": 100, "auxgain": 64}},
+	}}}
+	if !tracker.ScaleOutputGains(patch, -6.0206) {
+		t.Fatalf("halving the gains should not clamp them")
+	}
+	units := patch[0].Units
+	if units[0].Parameters["gain"] != 32 || units[1].Parameters["gain"] != 64 || units[2].Parameters["outgain"] != 50 || units[2].Parameters["auxgain"] != 64 {
+		t.Fatalf("only the gains to the main outputs should be halved, got %v", units)
+	}
+	if tracker.ScaleOutputGains(patch, 12) {
+		t.Fatalf("the gains should have been clamped to 128")
+	}
+}
@@ -1,11 +1,13 @@
 package tracker
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm/compiler"
 )
 
 type (
@@ -38,6 +40,13 @@ type (
 		tracks        []derivedTrack
 		railError     RailError
 		searchResults []string
+		dataSections  []compiler.DataSection
+		packedSizes   []compiler.SectionSize
+		// packing is true while a goroutine estimates the packed sizes; the
+		// sections that changed meanwhile wait in nextSections
+		packing         bool
+		nextSections    []compiler.DataSection
+		hasNextSections bool
 	}
 
 	derivedInstrument struct {
@@ -76,6 +85,69 @@ func (m *Model) updateDeriveData(changeType ChangeType) {
 		m.updateWires()
 		m.buildInstrumentTitles()
 	}
+	if changeType&(PatchChange|ScoreChange|BPMChange) != 0 {
+		m.updatePackedSizes()
+	}
+}
+
+// updatePackedSizes estimates the packed sizes of the data of the compiled
+// song. The estimation is slow, so it runs in a goroutine, and the sizes are
+// updated when it finishes. If the song changes meanwhile, only the latest
+// version is estimated next. Only the sections that changed are estimated
+// again, so e.g. tweaking a parameter does not pack the samples again.
+func (m *Model) updatePackedSizes() {
+	sections, err := compiler.DataSections(&m.d.Song, false)
+	if err != nil {
+		sections = nil
+	}
+	m.derived.nextSections, m.derived.hasNextSections = sections, true
+	if !m.derived.packing {
+		m.packNextSections()
+	}
+}
+
+func (m *Model) packNextSections() {
+	sections := m.derived.nextSections
+	m.derived.nextSections, m.derived.hasNextSections = nil, false
+	if sections == nil {
+		m.derived.dataSections, m.derived.packedSizes = nil, nil
+		return
+	}
+	oldSections, oldSizes := m.derived.dataSections, m.derived.packedSizes
+	m.derived.packing = true
+	go func() {
+		sizes := make([]compiler.SectionSize, 0, len(sections)+1)
+		total := compiler.SectionSize{Name: "total"}
+		for _, s := range sections {
+			size := compiler.SectionSize{Name: s.Name, Raw: len(s.Data), Packed: -1}
+			for i, old := range oldSections {
+				if old.Name == s.Name && bytes.Equal(old.Data, s.Data) {
+					size.Packed = oldSizes[i].Packed
+					break
+				}
+			}
+			if size.Packed < 0 {
+				size.Packed = compiler.EstimatePackedSize(s.Data)
+			}
+			total.Raw += size.Raw
+			total.Packed += size.Packed
+			sizes = append(sizes, size)
+		}
+		sizes = append(sizes, total)
+		// not TrySend: if the message was dropped, packing would stay true.
+		// FinishedGUI is closed when the GUI stops processing the messages to
+		// the model, so then the goroutine gives up instead of blocking forever
+		select {
+		case m.broker.ToModel <- MsgToModel{Data: func() {
+			m.derived.dataSections, m.derived.packedSizes = sections, sizes
+			m.derived.packing = false
+			if m.derived.hasNextSections {
+				m.packNextSections()
+			}
+		}}:
+		case <-m.broker.FinishedGUI:
+		}
+	}()
 }
 
 func (m *Model) buildInstrumentTitles() {
@@ -123,6 +195,12 @@ func (m *Model) deriveParams(unit *sointu.Unit, ret []Parameter) []Parameter {
 		if unit.Type == "oscillator" && unit.Parameters["type"] != sointu.Sample && (up.Name == "samplestart" || up.Name == "loopstart" || up.Name == "looplength") {
 			continue // don't show the sample related params unless necessary
 		}
+		if unit.Type == "oscillator" && unit.Parameters["type"] != sointu.Wavetable && up.Name == "wavetable" {
+			continue // the wavetable is only relevant for wavetable oscillators
+		}
+		if unit.Type == "oscillator" && (up.Name == "rate" || up.Name == "reset") && (unit.Parameters["lfo"] != 1 || up.Name == "reset" && unit.Parameters["rate"] == 0) {
+			continue // tempo sync params are only relevant for LFOs
+		}
 		if unit.Type == "send" && up.Name == "port" {
 			continue
 		}
@@ -133,7 +211,7 @@ func (m *Model) deriveParams(unit *sointu.Unit, ret []Parameter) []Parameter {
 		}
 		ret = append(ret, Parameter{m: m, unit: unit, up: &unitType[i], vtable: &namedParameter{}, port: q})
 	}
-	if unit.Type == "oscillator" && unit.Parameters["type"] == sointu.Sample {
+	if unit.Type == "oscillator" && unit.Parameters["type"] == sointu.Sample && unit.Parameters["sample"] == 0 {
 		ret = append(ret, Parameter{m: m, unit: unit, vtable: &gmDlsEntryParameter{}})
 	}
 	if unit.Type == "delay" {
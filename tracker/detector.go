@@ -2,6 +2,7 @@ package tracker
 
 import (
 	"math"
+	"slices"
 
 	"github.com/viterin/vek/vek32"
 	"github.com/vsariola/sointu"
@@ -23,6 +24,20 @@ type DetectorModel Model
 // Result returns the latest DetectorResult from the detector.
 func (m *DetectorModel) Result() DetectorResult { return m.detectorResult }
 
+// LoudnessHistory returns the short-term loudness measured every 100 ms while
+// playing, since the detector was last reset, at most MAX_INTEGRATED_DATA
+// points. Each point has the song row that was playing, so the history can be
+// plotted across the song.
+func (m *DetectorModel) LoudnessHistory() []LoudnessPoint { return m.loudnessHistory }
+
+func (m *DetectorModel) record(result DetectorResult) {
+	if !m.playing || len(m.loudnessHistory) >= MAX_INTEGRATED_DATA {
+		return
+	}
+	row := m.d.Song.Score.SongRow(m.playerStatus.SongPos)
+	m.loudnessHistory = append(m.loudnessHistory, LoudnessPoint{Row: row, Loudness: result.Loudness[LoudnessShortTerm]})
+}
+
 type (
 	DetectorResult struct {
 		Loudness LoudnessResult
@@ -35,12 +50,19 @@ type (
 	PeakType       int
 )
 
+// LoudnessPoint is the short-term loudness measured while playing a row.
+type LoudnessPoint struct {
+	Row      int // row of the song, counted from its beginning
+	Loudness Decibel
+}
+
 const (
 	LoudnessMomentary LoudnessType = iota
 	LoudnessShortTerm
 	LoudnessMaxMomentary
 	LoudnessMaxShortTerm
 	LoudnessIntegrated
+	LoudnessRange // loudness range (LRA) of the short-term loudness, in LU
 	NumLoudnessTypes
 )
 
@@ -117,7 +139,9 @@ type (
 		averagedPowers  [2][]float32
 		maxPowers       [2]float32
 		integratedPower float32
+		loudnessRange   Decibel
 		tmp, tmp2       []float32
+		tmpRange        []float32
 		tmpbool         []bool
 	}
 
@@ -291,22 +315,62 @@ func (d *loudnessDetector) update(chunk sointu.AudioBuffer) LoudnessResult {
 		ret[i+int(LoudnessMaxMomentary)] = powerToDecibel(d.maxPowers[i])
 	}
 	if len(d.averagedPowers[0])%10 == 0 { // every 10 samples of 100 ms i.e. every 1 s, we recalculate the integrated power
-		absThreshold := decibelToPower(-70) // -70 dB is the first threshold
-		b := vek32.GtNumber_Into(d.tmpbool, d.averagedPowers[0], absThreshold)
-		m2 := vek32.Select_Into(d.tmp, d.averagedPowers[0], b)
-		if len(m2) > 0 {
-			relThreshold := vek32.Mean(m2) / 10 // the relative threshold is 10 dB below the mean of the values above the absolute threshold
-			b2 := vek32.GtNumber_Into(d.tmpbool, m2, relThreshold)
-			m3 := vek32.Select_Into(d.tmp2, m2, b2)
-			if len(m3) > 0 {
-				d.integratedPower = vek32.Mean(m3)
-			}
-		}
+		d.integrate()
 	}
 	ret[LoudnessIntegrated] = powerToDecibel(d.integratedPower)
+	ret[LoudnessRange] = d.loudnessRange
 	return ret
 }
 
+// integrate recalculates the integrated power from the gated momentary powers,
+// and the loudness range from the short-term powers.
+func (d *loudnessDetector) integrate() {
+	shortTerm := d.averagedPowers[1]
+	if full := len(d.powers[1].Buffer) - 1; len(shortTerm) > full {
+		shortTerm = shortTerm[full:] // the range uses only the full 3 s windows
+	}
+	d.updateLoudnessRange(shortTerm)
+	absThreshold := decibelToPower(-70) // -70 dB is the first threshold
+	b := vek32.GtNumber_Into(d.tmpbool, d.averagedPowers[0], absThreshold)
+	m2 := vek32.Select_Into(d.tmp, d.averagedPowers[0], b)
+	if len(m2) > 0 {
+		relThreshold := vek32.Mean(m2) / 10 // the relative threshold is 10 dB below the mean of the values above the absolute threshold
+		b2 := vek32.GtNumber_Into(d.tmpbool, m2, relThreshold)
+		m3 := vek32.Select_Into(d.tmp2, m2, b2)
+		if len(m3) > 0 {
+			d.integratedPower = vek32.Mean(m3)
+		}
+	}
+}
+
+// updateLoudnessRange calculates the loudness range (LRA) from the short-term
+// powers, according to https://tech.ebu.ch/docs/tech/tech3342.pdf: the
+// powers are gated at -70 dB and at 20 dB below their mean, and the range is
+// the difference of the 95th and the 10th percentiles of the loudness.
+func (d *loudnessDetector) updateLoudnessRange(shortTermPowers []float32) {
+	absThreshold := decibelToPower(-70)
+	gated := d.tmpRange[:0]
+	var sum float64
+	for _, p := range shortTermPowers {
+		if p > absThreshold {
+			gated = append(gated, p)
+			sum += float64(p)
+		}
+	}
+	d.tmpRange = gated // keep the grown buffer for the next time
+	if len(gated) == 0 {
+		d.loudnessRange = 0
+		return
+	}
+	relThreshold := float32(sum/float64(len(gated))) / 100 // 20 dB below the mean
+	gated = slices.DeleteFunc(gated, func(p float32) bool { return p <= relThreshold })
+	slices.Sort(gated)
+	percentile := func(q float64) Decibel {
+		return powerToDecibel(gated[int(math.Round(q*float64(len(gated)-1)))])
+	}
+	d.loudnessRange = percentile(0.95) - percentile(0.10)
+}
+
 func (d *loudnessDetector) reset() {
 	for i := range d.powers {
 		d.powers[i].Cursor = 0
@@ -319,6 +383,7 @@ func (d *loudnessDetector) reset() {
 	// reset the biquad states
 	d.states = [2][3]biquadState{}
 	d.integratedPower = 0
+	d.loudnessRange = 0
 }
 
 func removeNaNsAndClamp(s float32) float32 {
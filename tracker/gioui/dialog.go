@@ -12,7 +12,7 @@ import (
 	"github.com/vsariola/sointu/tracker"
 )
 
-const DIALOG_MAX_BTNS = 3
+const DIALOG_MAX_BTNS = 4
 
 type (
 	// DialogState is the state that needs to be retained between frames
@@ -110,6 +110,7 @@ func (d *Dialog) Layout(gtx C) D {
 						fcs[0] = layout.Rigid(actBtns[0].Layout)
 						fcs[1] = layout.Rigid(actBtns[1].Layout)
 						fcs[2] = layout.Rigid(actBtns[2].Layout)
+						fcs[3] = layout.Rigid(actBtns[3].Layout)
 						gtx.Constraints.Min.Y = gtx.Dp(d.Style.Buttons.Height)
 						return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween}.Layout(gtx, fcs[:d.NumBtns]...)
 					})
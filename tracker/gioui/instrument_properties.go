@@ -3,6 +3,7 @@ package gioui
 import (
 	"image"
 	"image/color"
+	"io"
 
 	"gioui.org/layout"
 	"gioui.org/op/clip"
@@ -27,6 +28,9 @@ type (
 		voices              *NumericUpDownState
 		splitInstrumentBtn  *Clickable
 		splitInstrumentHint string
+		loadTuningBtn       *Clickable
+		clearTuningBtn      *Clickable
+		referencePitch      *NumericUpDownState
 
 		ignoreNoteOff *Clickable
 		velocity      *Clickable
@@ -49,6 +53,9 @@ func NewInstrumentProperties() *InstrumentProperties {
 		muteBtn:            new(Clickable),
 		voices:             NewNumericUpDownState(),
 		splitInstrumentBtn: new(Clickable),
+		loadTuningBtn:      new(Clickable),
+		clearTuningBtn:     new(Clickable),
+		referencePitch:     NewNumericUpDownState(),
 		threadBtns:         [4]*Clickable{new(Clickable), new(Clickable), new(Clickable), new(Clickable)},
 		ignoreNoteOff:      new(Clickable),
 		velocity:           new(Clickable),
@@ -99,7 +106,20 @@ func (ip *InstrumentProperties) layout(gtx C) D {
 			layout.Rigid(thread4btn.Layout),
 		)
 	}
-	ret := ip.list.Layout(gtx, 18, func(gtx C, index int) D {
+	for ip.loadTuningBtn.Clicked(gtx) {
+		tr.explorerChooseFile(func(r io.ReadCloser) { tr.Instrument().ReadTuning(r) }, ".scl", ".kbm")
+	}
+	tuningLine := func(gtx C) D {
+		name := Label(tr.Theme, &tr.Theme.InstrumentEditor.Properties.Label, tr.Instrument().TuningName())
+		loadBtn := IconBtn(tr.Theme, &tr.Theme.IconButton.Enabled, ip.loadTuningBtn, icons.FileFolderOpen, "Load Scala scale (.scl)\nor keyboard mapping (.kbm)")
+		clearBtn := ActionIconBtn(tr.Instrument().ClearTuning(), tr.Theme, ip.clearTuningBtn, icons.ContentClear, "Reset to 12-TET")
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(name.Layout),
+			layout.Rigid(loadBtn.Layout),
+			layout.Rigid(clearBtn.Layout),
+		)
+	}
+	ret := ip.list.Layout(gtx, 21, func(gtx C, index int) D {
 		gtx.Constraints.Max.X = min(gtx.Dp(300), gtx.Constraints.Max.X)
 		gtx.Constraints.Min.X = min(gtx.Constraints.Max.X, gtx.Constraints.Min.X)
 		switch index {
@@ -118,13 +138,18 @@ func (ip *InstrumentProperties) layout(gtx C) D {
 		case 7:
 			return layoutInstrumentPropertyLine(gtx, "Thread", threadbtnline)
 		case 9:
+			return layoutInstrumentPropertyLine(gtx, "Tuning", tuningLine)
+		case 10:
+			a4 := NumUpDown(tr.Instrument().ReferencePitch(), tr.Theme, ip.referencePitch, "Frequency of A4 in Hz")
+			return layoutInstrumentPropertyLine(gtx, "A4", a4.Layout)
+		case 12:
 			l := Label(tr.Theme, &tr.Theme.InstrumentEditor.Properties.Label, "MIDI")
 			l.Alignment = text.Middle
 			return l.Layout(gtx)
-		case 10:
+		case 13:
 			channelLine := NumUpDown(tr.MIDI().Channel(), tr.Theme, ip.midiChannel, "0 = automatic")
 			return layoutInstrumentPropertyLine(gtx, "Channel", channelLine.Layout)
-		case 11:
+		case 14:
 			start := NumUpDown(tr.MIDI().NoteStart(), tr.Theme, ip.noteStart, "Lowest note triggering\nthis instrument")
 			end := NumUpDown(tr.MIDI().NoteEnd(), tr.Theme, ip.noteEnd, "Highest note triggering\nthis instrument")
 			noteRangeLine := func(gtx C) D {
@@ -135,19 +160,19 @@ func (ip *InstrumentProperties) layout(gtx C) D {
 				)
 			}
 			return layoutInstrumentPropertyLine(gtx, "Note range", noteRangeLine)
-		case 12:
+		case 15:
 			transpose := NumUpDown(tr.MIDI().Transpose(), tr.Theme, ip.transpose, "Transpose of the MIDI values")
 			return layoutInstrumentPropertyLine(gtx, "Transpose", transpose.Layout)
-		case 13:
+		case 16:
 			velocityBtn := ToggleIconBtn(tr.MIDI().Velocity(), tr.Theme, ip.velocity, icons.ToggleCheckBoxOutlineBlank, icons.ToggleCheckBox, "Instrument triggered by\nMIDI note", "Instrument triggered by\nMIDI velocity")
 			return layoutInstrumentPropertyLine(gtx, "Velocity", velocityBtn.Layout)
-		case 14:
+		case 17:
 			retriggerBtn := ToggleIconBtn(tr.MIDI().Change(), tr.Theme, ip.change, icons.ToggleCheckBoxOutlineBlank, icons.ToggleCheckBox, "Every note/velocity retriggers", "Retrigger only when\nnote/velocity changes")
 			return layoutInstrumentPropertyLine(gtx, "No retrigger", retriggerBtn.Layout)
-		case 15:
+		case 18:
 			noteOff := ToggleIconBtn(tr.MIDI().IgnoreNoteOff(), tr.Theme, ip.ignoreNoteOff, icons.ToggleCheckBoxOutlineBlank, icons.ToggleCheckBox, "Notes released", "Notes never released")
 			return layoutInstrumentPropertyLine(gtx, "Ignore note off", noteOff.Layout)
-		case 17:
+		case 20:
 			return layout.UniformInset(unit.Dp(6)).Layout(gtx, func(gtx C) D {
 				return ip.commentEditor.Layout(gtx, tr.Instrument().Comment(), tr.Theme, &tr.Theme.InstrumentEditor.InstrumentComment, "Comment")
 			})
@@ -157,7 +182,7 @@ func (ip *InstrumentProperties) layout(gtx C) D {
 			return D{Size: image.Pt(gtx.Constraints.Max.X, px)}
 		}
 	})
-	ip.scrollBar.Layout(gtx, &tr.Theme.ScrollBar, 18, &ip.list.Position)
+	ip.scrollBar.Layout(gtx, &tr.Theme.ScrollBar, 21, &ip.list.Position)
 	return ret
 }
 
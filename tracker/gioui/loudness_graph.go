@@ -0,0 +1,95 @@
+package gioui
+
+import (
+	"math"
+	"strconv"
+)
+
+type (
+	LoudnessGraphState struct {
+		plot     *Plot
+		min, max []float32 // the range of the loudness on each row of the song, rebuilt every frame
+	}
+
+	LoudnessGraph struct {
+		Theme *Theme
+		State *LoudnessGraphState
+	}
+)
+
+const loudnessGraphFloor = 60 // the bottom of the graph, in dB below zero
+
+func NewLoudnessGraph() *LoudnessGraphState {
+	return &LoudnessGraphState{
+		plot: NewPlot(plotRange{0, 1}, plotRange{0, loudnessGraphFloor}, 0),
+	}
+}
+
+func LoudnessGraphWidget(th *Theme, st *LoudnessGraphState) LoudnessGraph {
+	return LoudnessGraph{Theme: th, State: st}
+}
+
+// Layout plots the short-term loudness history across the song: x is the row
+// of the song and y the loudness, so the loudness of each part of the order
+// list can be seen at a glance.
+func (g *LoudnessGraph) Layout(gtx C) D {
+	t := TrackerFromContext(gtx)
+	s := g.State
+	rpp := max(t.Song().RowsPerPattern().Value(), 1)
+	length := t.Song().Length().Value()
+	numRows := max(length*rpp, 1)
+	s.min = s.min[:0]
+	s.max = s.max[:0]
+	for range numRows {
+		s.min = append(s.min, float32(math.Inf(1)))
+		s.max = append(s.max, float32(math.Inf(-1)))
+	}
+	for _, p := range t.Detector().LoudnessHistory() {
+		if p.Row < 0 || p.Row >= numRows || p.Loudness != p.Loudness {
+			continue
+		}
+		l := max(float32(p.Loudness), -loudnessGraphFloor)
+		s.min[p.Row] = min(s.min[p.Row], l)
+		s.max[p.Row] = max(s.max[p.Row], l)
+	}
+	data := func(chn int, xr plotRange) (yr plotRange, ok bool) {
+		r1 := max(int(xr.a*float32(numRows)), 0)
+		r2 := min(int(xr.b*float32(numRows)), numRows-1)
+		y1 := float32(math.Inf(1))
+		y2 := float32(math.Inf(-1))
+		for r := r1; r <= r2; r++ {
+			y1 = min(y1, s.min[r])
+			y2 = max(y2, s.max[r])
+		}
+		if y1 > y2 {
+			return plotRange{}, false
+		}
+		return plotRange{-y2, -y1}, true
+	}
+	xticks := func(r plotRange, count int, yield func(pos float32, label string)) {
+		a := max(int(math.Ceil(float64(r.a*float32(numRows)/float32(rpp)))), 0)
+		b := min(int(math.Floor(float64(r.b*float32(numRows)/float32(rpp)))), length)
+		step := 1
+		for (b-a+1)/step > count {
+			step *= 2
+		}
+		a = (a / step) * step
+		for i := a; i <= b; i += step {
+			yield(float32(i*rpp)/float32(numRows), strconv.Itoa(i))
+		}
+	}
+	yticks := func(r plotRange, count int, yield func(pos float32, label string)) {
+		step := 6
+		for loudnessGraphFloor/step > count {
+			step *= 2
+		}
+		for db := 0; db <= loudnessGraphFloor; db += step {
+			yield(float32(db), strconv.Itoa(-db))
+		}
+	}
+	cursor := float32(math.NaN())
+	if t.Play().Started().Value() {
+		cursor = float32(t.Play().SongRow()) / float32(numRows)
+	}
+	return s.plot.Layout(gtx, data, xticks, yticks, cursor, 1)
+}
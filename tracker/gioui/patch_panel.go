@@ -223,7 +223,7 @@ func (it *InstrumentTools) update(gtx C, tr *Tracker) {
 		tr.Instrument().Write(writer)
 	}
 	for it.loadInstrumentBtn.Clicked(gtx) {
-		reader, err := tr.Explorer.ChooseFile(".yml", ".json", ".4ki", ".4kp")
+		reader, err := tr.Explorer.ChooseFile(".yml", ".json", ".4ki", ".4kp", ".wav", ".sf2")
 		if err != nil {
 			continue
 		}
@@ -10,11 +10,13 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"gioui.org/unit"
+	"github.com/vsariola/sointu/tracker"
 )
 
 type (
 	Preferences struct {
 		Window WindowPreferences
+		GmDls  string `yaml:",omitempty"` // path to gm.dls, if not in the default location
 	}
 
 	WindowPreferences struct {
@@ -65,3 +67,16 @@ func ReadConfig(defaultConfig []byte, path string, target any) (warn error) {
 func (p Preferences) WindowSize() (unit.Dp, unit.Dp) {
 	return unit.Dp(p.Window.Width), unit.Dp(p.Window.Height)
 }
+
+// LoadGmDls loads the gm.dls file given in the preferences, if any, with
+// tracker.LoadGmDls. The sample table is shared by all synths, so this should
+// be called before creating any players. Problems parsing the preferences are
+// ignored here, as NewTracker warns about them.
+func LoadGmDls() error {
+	var p Preferences
+	ReadConfig(defaultPreferences, "preferences.yml", &p)
+	if p.GmDls == "" {
+		return nil
+	}
+	return tracker.LoadGmDls(p.GmDls)
+}
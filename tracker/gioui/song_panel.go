@@ -28,19 +28,24 @@ type SongPanel struct {
 	ScopeExpander        *Expander
 	LoudnessExpander     *Expander
 	PeakExpander         *Expander
+	HistoryExpander      *Expander
 	CPUExpander          *Expander
+	SizeExpander         *Expander
 	SpectrumExpander     *Expander
 
 	WeightingTypeBtn  *Clickable
 	OversamplingBtn   *Clickable
 	SynthBtn          *Clickable
 	MultithreadingBtn *Clickable
+	NormalizeBtn      *Clickable
+	WriteGainBtn      *Clickable
 
 	BPM            *NumericUpDownState
 	RowsPerPattern *NumericUpDownState
 	RowsPerBeat    *NumericUpDownState
 	Step           *NumericUpDownState
 	SongLength     *NumericUpDownState
+	ExportLoudness *NumericUpDownState
 
 	weightingMenuState *MenuState
 
@@ -50,6 +55,9 @@ type SongPanel struct {
 	Scope         *OscilloscopeState
 	ScopeScaleBar *ScaleBar
 
+	LoudnessGraph         *LoudnessGraphState
+	LoudnessGraphScaleBar *ScaleBar
+
 	SpectrumState    *SpectrumState
 	SpectrumScaleBar *ScaleBar
 
@@ -64,6 +72,7 @@ func NewSongPanel(tr *Tracker) *SongPanel {
 		RowsPerBeat:    NewNumericUpDownState(),
 		Step:           NewNumericUpDownState(),
 		SongLength:     NewNumericUpDownState(),
+		ExportLoudness: NewNumericUpDownState(),
 		Scope:          NewOscilloscope(),
 		MenuBar:        NewMenuBar(tr),
 		PlayBar:        NewPlayBar(),
@@ -72,12 +81,16 @@ func NewSongPanel(tr *Tracker) *SongPanel {
 		OversamplingBtn:   new(Clickable),
 		SynthBtn:          new(Clickable),
 		MultithreadingBtn: new(Clickable),
+		NormalizeBtn:      new(Clickable),
+		WriteGainBtn:      new(Clickable),
 
 		SongSettingsExpander: &Expander{Expanded: true},
 		ScopeExpander:        &Expander{},
 		LoudnessExpander:     &Expander{},
 		PeakExpander:         &Expander{},
+		HistoryExpander:      &Expander{},
 		CPUExpander:          &Expander{},
+		SizeExpander:         &Expander{},
 		SpectrumExpander:     &Expander{},
 
 		List:      &layout.List{Axis: layout.Vertical},
@@ -88,6 +101,9 @@ func NewSongPanel(tr *Tracker) *SongPanel {
 		SpectrumState:    NewSpectrumState(),
 		SpectrumScaleBar: &ScaleBar{Axis: layout.Vertical, BarSize: 10, Size: 300},
 		ScopeScaleBar:    &ScaleBar{Axis: layout.Vertical, BarSize: 10, Size: 300},
+
+		LoudnessGraph:         NewLoudnessGraph(),
+		LoudnessGraphScaleBar: &ScaleBar{Axis: layout.Vertical, BarSize: 10, Size: 200},
 	}
 	return ret
 }
@@ -206,6 +222,26 @@ func (t *SongPanel) layoutSongOptions(gtx C) D {
 				},
 			)
 		case 2:
+			sizes := tr.Song().PackedSizes()
+			return t.SizeExpander.Layout(gtx, tr.Theme, "Size",
+				func(gtx C) D {
+					if len(sizes) == 0 {
+						return D{}
+					}
+					return Label(tr.Theme, &tr.Theme.SongPanel.RowValue, fmt.Sprintf("~%.0f B", sizes[len(sizes)-1].Packed)).Layout(gtx)
+				},
+				func(gtx C) D {
+					rows := make([]layout.FlexChild, 0, len(sizes))
+					for _, size := range sizes {
+						rows = append(rows, layout.Rigid(func(gtx C) D {
+							label := Label(tr.Theme, &tr.Theme.SongPanel.RowValue, fmt.Sprintf("%d B, packed ~%.0f B", size.Raw, size.Packed))
+							return layoutSongOptionRow(gtx, tr.Theme, size.Name, label.Layout)
+						}))
+					}
+					return layout.Flex{Axis: layout.Vertical, Alignment: layout.End}.Layout(gtx, rows...)
+				},
+			)
+		case 3:
 			return t.LoudnessExpander.Layout(gtx, tr.Theme, "Loudness",
 				func(gtx C) D {
 					loudness := tr.Model.Detector().Result().Loudness[tracker.LoudnessShortTerm]
@@ -222,6 +258,9 @@ func (t *SongPanel) layoutSongOptions(gtx C) D {
 						layout.Rigid(func(gtx C) D {
 							return layoutSongOptionRow(gtx, tr.Theme, "Integrated", dbLabel(tr.Theme, tr.Model.Detector().Result().Loudness[tracker.LoudnessIntegrated]).Layout)
 						}),
+						layout.Rigid(func(gtx C) D {
+							return layoutSongOptionRow(gtx, tr.Theme, "Range (LRA)", dbLabel(tr.Theme, tr.Model.Detector().Result().Loudness[tracker.LoudnessRange]).Layout)
+						}),
 						layout.Rigid(func(gtx C) D {
 							return layoutSongOptionRow(gtx, tr.Theme, "Max. momentary", dbLabel(tr.Theme, tr.Model.Detector().Result().Loudness[tracker.LoudnessMaxMomentary]).Layout)
 						}),
@@ -232,10 +271,22 @@ func (t *SongPanel) layoutSongOptions(gtx C) D {
 							gtx.Constraints.Min.X = 0
 							return weightingBtn.Layout(gtx, IntMenuChild(tr.Detector().Weighting(), icons.NavigationCheck))
 						}),
+						layout.Rigid(func(gtx C) D {
+							normalizeBtn := ToggleIconBtn(tr.Song().NormalizeExport(), tr.Theme, t.NormalizeBtn, icons.ToggleCheckBoxOutlineBlank, icons.ToggleCheckBox, "Exported songs are not normalized", "Exported songs are normalized to the target loudness")
+							return layoutSongOptionRow(gtx, tr.Theme, "Normalize export", normalizeBtn.Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							exportLoudness := NumUpDown(tr.Song().ExportLoudness(), tr.Theme, t.ExportLoudness, "Target loudness of the normalized exports (LUFS)")
+							return layoutSongOptionRow(gtx, tr.Theme, "Export LUFS", exportLoudness.Layout)
+						}),
+						layout.Rigid(func(gtx C) D {
+							writeGainBtn := ToggleIconBtn(tr.Song().ExportWriteGain(), tr.Theme, t.WriteGainBtn, icons.ToggleCheckBoxOutlineBlank, icons.ToggleCheckBox, "Normalizing does not change the song", "Normalizing also scales the output gains of the song")
+							return layoutSongOptionRow(gtx, tr.Theme, "Write gain", writeGainBtn.Layout)
+						}),
 					)
 				},
 			)
-		case 3:
+		case 4:
 			return t.PeakExpander.Layout(gtx, tr.Theme, "Peaks",
 				func(gtx C) D {
 					maxPeak := max(tr.Model.Detector().Result().Peaks[tracker.PeakShortTerm][0], tr.Model.Detector().Result().Peaks[tracker.PeakShortTerm][1])
@@ -263,26 +314,32 @@ func (t *SongPanel) layoutSongOptions(gtx C) D {
 					)
 				},
 			)
-		case 4:
+		case 5:
+			graph := LoudnessGraphWidget(tr.Theme, t.LoudnessGraph)
+			graphScaleBar := func(gtx C) D {
+				return t.LoudnessGraphScaleBar.Layout(gtx, graph.Layout)
+			}
+			return t.HistoryExpander.Layout(gtx, tr.Theme, "Loudness history", func(gtx C) D { return D{} }, graphScaleBar)
+		case 6:
 			scope := Scope(tr.Theme, t.Scope)
 			scopeScaleBar := func(gtx C) D {
 				return t.ScopeScaleBar.Layout(gtx, scope.Layout)
 			}
 			return t.ScopeExpander.Layout(gtx, tr.Theme, "Oscilloscope", func(gtx C) D { return D{} }, scopeScaleBar)
-		case 5:
+		case 7:
 			spectrumScaleBar := func(gtx C) D {
 				return t.SpectrumScaleBar.Layout(gtx, t.SpectrumState.Layout)
 			}
 			return t.SpectrumExpander.Layout(gtx, tr.Theme, "Spectrum", func(gtx C) D { return D{} }, spectrumScaleBar)
-		case 6:
+		case 8:
 			return Label(tr.Theme, &tr.Theme.SongPanel.Version, version.VersionOrHash).Layout(gtx)
 		default:
 			return D{}
 		}
 	}
 	gtx.Constraints.Min = gtx.Constraints.Max
-	dims := t.List.Layout(gtx, 7, listItem)
-	t.ScrollBar.Layout(gtx, &tr.Theme.SongPanel.ScrollBar, 7, &t.List.Position)
+	dims := t.List.Layout(gtx, 9, listItem)
+	t.ScrollBar.Layout(gtx, &tr.Theme.SongPanel.ScrollBar, 9, &t.List.Position)
 	tr.Spectrum().Enabled().SetValue(t.SpectrumExpander.Expanded)
 	return dims
 }
@@ -487,7 +544,7 @@ func (t *MenuBar) Layout(gtx C) D {
 			ActionMenuChild(tr.Song().Save(), "Save Song", keyActionMap["SaveSong"], icons.ContentSave),
 			ActionMenuChild(tr.Song().SaveAs(), "Save Song As...", keyActionMap["SaveSongAs"], icons.ContentSave),
 			DividerMenuChild(),
-			ActionMenuChild(tr.Song().Export(), "Export Wav...", keyActionMap["ExportWav"], icons.ImageAudiotrack),
+			ActionMenuChild(tr.Song().Export(), "Export audio...", keyActionMap["ExportWav"], icons.ImageAudiotrack),
 			DividerMenuChild(),
 			ActionMenuChild(tr.RequestQuit(), "Quit", keyActionMap["Quit"], icons.ActionExitToApp),
 		}
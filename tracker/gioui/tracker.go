@@ -304,12 +304,27 @@ func (t *Tracker) showDialog(gtx C) {
 		)
 		dialog.Layout(gtx)
 	case tracker.Export:
-		dialog := MakeDialog(t.Theme, t.DialogState, "Export format", "Choose the sample format for the exported .wav file.",
-			DialogBtn("Int16", t.Song().ExportInt16()),
-			DialogBtn("Float32", t.Song().ExportFloat()),
+		dialog := MakeDialog(t.Theme, t.DialogState, "Export format", "Choose the file format for the exported audio file.",
+			DialogBtn("WAV", t.Song().ExportFileFormat(sointu.WavFile)),
+			DialogBtn("AIFF", t.Song().ExportFileFormat(sointu.AiffFile)),
+			DialogBtn("FLAC", t.Song().ExportFileFormat(sointu.FlacFile)),
 			DialogBtn("Cancel", t.CancelDialog()),
 		)
 		dialog.Layout(gtx)
+	case tracker.ExportSampleFormatDialog:
+		fileFormat, _ := t.Song().ExportFormat()
+		var btns [DIALOG_MAX_BTNS]DialogButton
+		n := 0
+		for f := range sointu.NumSampleFormats {
+			if a := t.Song().ExportSampleFormat(f); a.Enabled() { // e.g. FLAC does not support floats
+				btns[n] = DialogBtn(f.String(), a)
+				n++
+			}
+		}
+		btns[n] = DialogBtn("Cancel", t.CancelDialog())
+		text := fmt.Sprintf("Choose the sample format for the exported %v file. Integer samples are dithered.", fileFormat.Extension())
+		dialog := MakeDialog(t.Theme, t.DialogState, "Sample format", text, btns[:n+1]...)
+		dialog.Layout(gtx)
 	case tracker.OpenSongOpenExplorer:
 		t.explorerChooseFile(t.Song().Read, ".yml", ".json")
 	case tracker.NewSongSaveExplorer, tracker.OpenSongSaveExplorer, tracker.QuitSaveExplorer, tracker.SaveAsExplorer:
@@ -318,14 +333,13 @@ func (t *Tracker) showDialog(gtx C) {
 			filename = "song.yml"
 		}
 		t.explorerCreateFile(t.Song().Write, filename)
-	case tracker.ExportFloatExplorer, tracker.ExportInt16Explorer:
-		filename := "song.wav"
+	case tracker.ExportExplorer:
+		fileFormat, _ := t.Song().ExportFormat()
+		filename := "song" + fileFormat.Extension()
 		if p := t.filePathString.Value(); p != "" {
-			filename = p[:len(p)-len(filepath.Ext(p))] + ".wav"
+			filename = p[:len(p)-len(filepath.Ext(p))] + fileFormat.Extension()
 		}
-		t.explorerCreateFile(func(wc io.WriteCloser) {
-			t.Song().WriteWav(wc, t.Dialog() == tracker.ExportInt16Explorer)
-		}, filename)
+		t.explorerCreateFile(t.Song().WriteAudio, filename)
 	case tracker.License:
 		dialog := MakeDialog(t.Theme, t.DialogState, "License", sointu.License,
 			DialogBtn("Close", t.CancelDialog()),
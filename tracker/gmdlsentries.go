@@ -1,503 +0,0 @@
-package tracker
-
-// Code generated by go generate; DO NOT EDIT.
-
-// GmDlsEntries is a list of all samples in the gm.dls file. Do not modify
-// during runtime.
-var GmDlsEntries = []GmDlsEntry{
-	{Start: 140078, LoopStart: 1353, LoopLength: 91, SuggestedTranspose: 1, Name: "101BS35"},
-	{Start: 141606, LoopStart: 1380, LoopLength: 43, SuggestedTranspose: -12, Name: "101BS48"},
-	{Start: 143113, LoopStart: 5448, LoopLength: 563, SuggestedTranspose: 5, Name: "12STR55A"},
-	{Start: 149209, LoopStart: 2337, LoopLength: 787, SuggestedTranspose: -19, Name: "12STR79A"},
-	{Start: 152418, LoopStart: 1827, LoopLength: 582, SuggestedTranspose: -29, Name: "12STR89A"},
-	{Start: 154912, LoopStart: 4157, LoopLength: 3520, SuggestedTranspose: -21, Name: "5_SAW81"},
-	{Start: 162673, LoopStart: 187, LoopLength: 56, SuggestedTranspose: -7, Name: "60ORG67"},
-	{Start: 163000, LoopStart: 86, LoopLength: 14, SuggestedTranspose: -31, Name: "60ORG91"},
-	{Start: 163184, LoopStart: 87, LoopLength: 10, SuggestedTranspose: -37, Name: "60ORG96"},
-	{Start: 163365, LoopStart: 927, LoopLength: 168, SuggestedTranspose: 12, Name: "60PNO43"},
-	{Start: 164544, LoopStart: 402, LoopLength: 252, SuggestedTranspose: 7, Name: "60PNO48"},
-	{Start: 165282, LoopStart: 909, LoopLength: 134, SuggestedTranspose: -4, Name: "60PNO58"},
-	{Start: 166409, LoopStart: 946, LoopLength: 94, SuggestedTranspose: -10, Name: "60PNO66"},
-	{Start: 167533, LoopStart: 936, LoopLength: 67, SuggestedTranspose: -23, Name: "60PNO80"},
-	{Start: 168620, LoopStart: 904, LoopLength: 53, SuggestedTranspose: -27, Name: "60PNO86"},
-	{Start: 169661, LoopStart: 695, LoopLength: 75, SuggestedTranspose: -33, Name: "60PNO92"},
-	{Start: 170515, LoopStart: 152, LoopLength: 79, SuggestedTranspose: 4, Name: "808CW64"},
-	{Start: 170822, LoopStart: 840, LoopLength: 1, SuggestedTranspose: -22, Name: "808MC64"},
-	{Start: 171746, LoopStart: 2301, LoopLength: 2614, SuggestedTranspose: 18, Name: "808OH60A"},
-	{Start: 176738, LoopStart: 1578, LoopLength: 1, SuggestedTranspose: 22, Name: "808SD60"},
-	{Start: 178400, LoopStart: 2752, LoopLength: 1009, SuggestedTranspose: -8, Name: "ACCFR68A"},
-	{Start: 182246, LoopStart: 2075, LoopLength: 84, SuggestedTranspose: -36, Name: "ACCFR96A"},
-	{Start: 184490, LoopStart: 7814, LoopLength: 426, SuggestedTranspose: 28, Name: "ACOBS32A"},
-	{Start: 192815, LoopStart: 6539, LoopLength: 451, SuggestedTranspose: 17, Name: "ACOBS43A"},
-	{Start: 199890, LoopStart: 2639, LoopLength: 85, SuggestedTranspose: 0, Name: "ACOBS60A"},
-	{Start: 202691, LoopStart: 1538, LoopLength: 1, SuggestedTranspose: -9, Name: "AGOGO60"},
-	{Start: 204313, LoopStart: 32, LoopLength: 7231, SuggestedTranspose: -38, Name: "APPLS64A"},
-	{Start: 211661, LoopStart: 5013, LoopLength: 84, SuggestedTranspose: 0, Name: "ATMOS60A"},
-	{Start: 216843, LoopStart: 3175, LoopLength: 21, SuggestedTranspose: -24, Name: "ATMOS84"},
-	{Start: 220123, LoopStart: 2359, LoopLength: 251, SuggestedTranspose: 19, Name: "A_SAX53"},
-	{Start: 222817, LoopStart: 1086, LoopLength: 180, SuggestedTranspose: 13, Name: "A_SAX59"},
-	{Start: 224167, LoopStart: 1111, LoopLength: 144, SuggestedTranspose: 9, Name: "A_SAX63"},
-	{Start: 225506, LoopStart: 1098, LoopLength: 101, SuggestedTranspose: 3, Name: "A_SAX69"},
-	{Start: 226789, LoopStart: 683, LoopLength: 64, SuggestedTranspose: -5, Name: "A_SAX77"},
-	{Start: 227620, LoopStart: 1150, LoopLength: 35, SuggestedTranspose: -15, Name: "A_SAX87"},
-	{Start: 228889, LoopStart: 678, LoopLength: 44, SuggestedTranspose: -23, Name: "A_SAX96"},
-	{Start: 229695, LoopStart: 302, LoopLength: 52, SuggestedTranspose: -32, Name: "A_SAXA5"},
-	{Start: 230133, LoopStart: 3407, LoopLength: 253, SuggestedTranspose: 6, Name: "BAGPP53A"},
-	{Start: 233878, LoopStart: 2513, LoopLength: 268, SuggestedTranspose: -5, Name: "BAGPP64A"},
-	{Start: 236744, LoopStart: 1787, LoopLength: 38, SuggestedTranspose: -15, Name: "BAGPP74A"},
-	{Start: 238654, LoopStart: 1781, LoopLength: 169, SuggestedTranspose: -25, Name: "BAGPP84A"},
-	{Start: 240689, LoopStart: 684, LoopLength: 89, SuggestedTranspose: -34, Name: "BAGPP93A"},
-	{Start: 241547, LoopStart: 723, LoopLength: 67, SuggestedTranspose: -4, Name: "BANDO64"},
-	{Start: 242421, LoopStart: 512, LoopLength: 76, SuggestedTranspose: -14, Name: "BANDO74"},
-	{Start: 243093, LoopStart: 939, LoopLength: 83, SuggestedTranspose: 0, Name: "BANJO60"},
-	{Start: 244199, LoopStart: 814, LoopLength: 188, SuggestedTranspose: -10, Name: "BANJO70"},
-	{Start: 245285, LoopStart: 521, LoopLength: 133, SuggestedTranspose: -23, Name: "BANJO83"},
-	{Start: 246023, LoopStart: 561, LoopLength: 212, SuggestedTranspose: 16, Name: "BASSN44"},
-	{Start: 246880, LoopStart: 205, LoopLength: 49, SuggestedTranspose: -9, Name: "BASSN69"},
-	{Start: 247218, LoopStart: 2901, LoopLength: 646, SuggestedTranspose: -30, Name: "BELTR60"},
-	{Start: 250849, LoopStart: 2536, LoopLength: 1486, SuggestedTranspose: -17, Name: "BIRD_60"},
-	{Start: 254955, LoopStart: 1983, LoopLength: 42, SuggestedTranspose: -12, Name: "BOTTL72"},
-	{Start: 257064, LoopStart: 987, LoopLength: 21, SuggestedTranspose: -24, Name: "BOTTL84"},
-	{Start: 258156, LoopStart: 5805, LoopLength: 66, SuggestedTranspose: -4, Name: "BRASS64"},
-	{Start: 264111, LoopStart: 7241, LoopLength: 211, SuggestedTranspose: -12, Name: "BRASS72A"},
-	{Start: 271648, LoopStart: 7893, LoopLength: 295, SuggestedTranspose: -24, Name: "BRASS84A"},
-	{Start: 279921, LoopStart: 5183, LoopLength: 43, SuggestedTranspose: -36, Name: "BRASS96A"},
-	{Start: 285224, LoopStart: 1792, LoopLength: 1, SuggestedTranspose: -17, Name: "BSLAP60"},
-	{Start: 287100, LoopStart: 24, LoopLength: 9096, SuggestedTranspose: 20, Name: "BSWRL60"},
-	{Start: 296304, LoopStart: 3213, LoopLength: 169, SuggestedTranspose: 12, Name: "BS_LD48"},
-	{Start: 299770, LoopStart: 2555, LoopLength: 42, SuggestedTranspose: -12, Name: "BS_LD72"},
-	{Start: 302451, LoopStart: 2344, LoopLength: 21, SuggestedTranspose: -36, Name: "BS_LD96"},
-	{Start: 304900, LoopStart: 33, LoopLength: 7168, SuggestedTranspose: -38, Name: "BUBLE68A"},
-	{Start: 312186, LoopStart: 553, LoopLength: 17648, SuggestedTranspose: -11, Name: "BURST64A"},
-	{Start: 330472, LoopStart: 464, LoopLength: 299, SuggestedTranspose: 22, Name: "B_SAX38"},
-	{Start: 331319, LoopStart: 438, LoopLength: 168, SuggestedTranspose: 12, Name: "B_SAX48"},
-	{Start: 332009, LoopStart: 571, LoopLength: 90, SuggestedTranspose: 1, Name: "B_SAX59"},
-	{Start: 332754, LoopStart: 193, LoopLength: 19, SuggestedTranspose: -26, Name: "B_SAX86"},
-	{Start: 333050, LoopStart: 63, LoopLength: 10, SuggestedTranspose: -49, Name: "B_SAXB0A"},
-	{Start: 333200, LoopStart: 2253, LoopLength: 1, SuggestedTranspose: 20, Name: "B_TAP64"},
-	{Start: 335529, LoopStart: 1106, LoopLength: 1, SuggestedTranspose: -9, Name: "CABA_60"},
-	{Start: 336719, LoopStart: 2088, LoopLength: 3546, SuggestedTranspose: -12, Name: "CALLI72"},
-	{Start: 342437, LoopStart: 2192, LoopLength: 4125, SuggestedTranspose: -24, Name: "CALLI84"},
-	{Start: 348830, LoopStart: 374, LoopLength: 1, SuggestedTranspose: -25, Name: "CAST_60"},
-	{Start: 349288, LoopStart: 729, LoopLength: 67, SuggestedTranspose: -4, Name: "CELLH64"},
-	{Start: 350168, LoopStart: 603, LoopLength: 501, SuggestedTranspose: 7, Name: "CELLL64"},
-	{Start: 351356, LoopStart: 1251, LoopLength: 531, SuggestedTranspose: 32, Name: "CELLO28"},
-	{Start: 353222, LoopStart: 813, LoopLength: 253, SuggestedTranspose: 19, Name: "CELLO41"},
-	{Start: 354372, LoopStart: 453, LoopLength: 33, SuggestedTranspose: -16, Name: "CELLO76"},
-	{Start: 354942, LoopStart: 292, LoopLength: 252, SuggestedTranspose: -17, Name: "CELST77"},
-	{Start: 355570, LoopStart: 81, LoopLength: 62, SuggestedTranspose: -41, Name: "CELSTA1"},
-	{Start: 355797, LoopStart: 5542, LoopLength: 42, SuggestedTranspose: -12, Name: "CHFFR72A"},
-	{Start: 361466, LoopStart: 5913, LoopLength: 22, SuggestedTranspose: -23, Name: "CHFFR84"},
-	{Start: 367477, LoopStart: 1343, LoopLength: 1, SuggestedTranspose: 18, Name: "CHH__60A"},
-	{Start: 368905, LoopStart: 2067, LoopLength: 7039, SuggestedTranspose: 7, Name: "CHINA60A"},
-	{Start: 378096, LoopStart: 3689, LoopLength: 8712, SuggestedTranspose: -8, Name: "CHOIR65A"},
-	{Start: 390582, LoopStart: 5879, LoopLength: 253, SuggestedTranspose: 0, Name: "CHRNG60"},
-	{Start: 396798, LoopStart: 3188, LoopLength: 43, SuggestedTranspose: -24, Name: "CHRNG84"},
-	{Start: 400105, LoopStart: 389, LoopLength: 1, SuggestedTranspose: -22, Name: "CLAVE60"},
-	{Start: 400578, LoopStart: 467, LoopLength: 168, SuggestedTranspose: 12, Name: "CLAVI48"},
-	{Start: 401297, LoopStart: 290, LoopLength: 84, SuggestedTranspose: 0, Name: "CLAVI60"},
-	{Start: 401755, LoopStart: 206, LoopLength: 42, SuggestedTranspose: -12, Name: "CLAVI72"},
-	{Start: 402087, LoopStart: 76, LoopLength: 21, SuggestedTranspose: -24, Name: "CLAVI84"},
-	{Start: 402260, LoopStart: 66, LoopLength: 1, SuggestedTranspose: 28, Name: "CLICK60"},
-	{Start: 402410, LoopStart: 4686, LoopLength: 338, SuggestedTranspose: 12, Name: "CLNGT48"},
-	{Start: 407518, LoopStart: 3433, LoopLength: 337, SuggestedTranspose: 0, Name: "CLNGT60"},
-	{Start: 411372, LoopStart: 2877, LoopLength: 226, SuggestedTranspose: -7, Name: "CLNGT67"},
-	{Start: 414559, LoopStart: 2013, LoopLength: 337, SuggestedTranspose: -12, Name: "CLNGT72"},
-	{Start: 416993, LoopStart: 2263, LoopLength: 22, SuggestedTranspose: -23, Name: "CLNGT84"},
-	{Start: 419362, LoopStart: 425, LoopLength: 90, SuggestedTranspose: 1, Name: "CONBH41"},
-	{Start: 419961, LoopStart: 1128, LoopLength: 239, SuggestedTranspose: 18, Name: "CONBL41"},
-	{Start: 421412, LoopStart: 2415, LoopLength: 501, SuggestedTranspose: 31, Name: "CONBS28"},
-	{Start: 424412, LoopStart: 48, LoopLength: 89, SuggestedTranspose: -5, Name: "COS__59"},
-	{Start: 424633, LoopStart: 17, LoopLength: 33, SuggestedTranspose: -10, Name: "COS__76"},
-	{Start: 424759, LoopStart: 2114, LoopLength: 1, SuggestedTranspose: -2, Name: "COWBL66"},
-	{Start: 426957, LoopStart: 13, LoopLength: 6500, SuggestedTranspose: -17, Name: "CPASS64"},
-	{Start: 433554, LoopStart: 2615, LoopLength: 5676, SuggestedTranspose: 12, Name: "CRASH60B"},
-	{Start: 441930, LoopStart: 7, LoopLength: 5976, SuggestedTranspose: -26, Name: "CRASH65"},
-	{Start: 447989, LoopStart: 5178, LoopLength: 1, SuggestedTranspose: -24, Name: "CREAK64"},
-	{Start: 453251, LoopStart: 15, LoopLength: 2950, SuggestedTranspose: -11, Name: "CSTOP64"},
-	{Start: 456300, LoopStart: 1752, LoopLength: 372, SuggestedTranspose: 14, Name: "C_ORG46A"},
-	{Start: 458509, LoopStart: 914, LoopLength: 627, SuggestedTranspose: 4, Name: "C_ORG56A"},
-	{Start: 460135, LoopStart: 593, LoopLength: 235, SuggestedTranspose: -6, Name: "C_ORG66A"},
-	{Start: 461048, LoopStart: 1211, LoopLength: 59, SuggestedTranspose: -18, Name: "C_ORG78A"},
-	{Start: 462403, LoopStart: 764, LoopLength: 29, SuggestedTranspose: -30, Name: "C_ORG90A"},
-	{Start: 463281, LoopStart: 6178, LoopLength: 755, SuggestedTranspose: 0, Name: "DELP160A"},
-	{Start: 470299, LoopStart: 2112, LoopLength: 105, SuggestedTranspose: -24, Name: "DELP184A"},
-	{Start: 472601, LoopStart: 1365, LoopLength: 3682, SuggestedTranspose: -18, Name: "DELP278"},
-	{Start: 477732, LoopStart: 5339, LoopLength: 126, SuggestedTranspose: 7, Name: "DOCSL53"},
-	{Start: 483281, LoopStart: 3461, LoopLength: 63, SuggestedTranspose: -17, Name: "DOCSL77"},
-	{Start: 486881, LoopStart: 2071, LoopLength: 1, SuggestedTranspose: -11, Name: "DOGBW60"},
-	{Start: 489036, LoopStart: 903, LoopLength: 3766, SuggestedTranspose: -30, Name: "DORG190"},
-	{Start: 493789, LoopStart: 3314, LoopLength: 337, SuggestedTranspose: 24, Name: "DSTGT48"},
-	{Start: 497524, LoopStart: 3044, LoopLength: 237, SuggestedTranspose: 18, Name: "DSTGT54"},
-	{Start: 500889, LoopStart: 2091, LoopLength: 178, SuggestedTranspose: 13, Name: "DSTGT59"},
-	{Start: 503242, LoopStart: 1671, LoopLength: 133, SuggestedTranspose: 8, Name: "DSTGT64"},
-	{Start: 505130, LoopStart: 850, LoopLength: 89, SuggestedTranspose: 1, Name: "DSTGT71"},
-	{Start: 506153, LoopStart: 1062, LoopLength: 63, SuggestedTranspose: -5, Name: "DSTGT77"},
-	{Start: 507362, LoopStart: 661, LoopLength: 52, SuggestedTranspose: -20, Name: "DSTGT92"},
-	{Start: 508159, LoopStart: 394, LoopLength: 31, SuggestedTranspose: -29, Name: "DSTGTA1"},
-	{Start: 508668, LoopStart: 503, LoopLength: 10, SuggestedTranspose: -37, Name: "DSTGTA9"},
-	{Start: 509257, LoopStart: 517, LoopLength: 1, SuggestedTranspose: -5, Name: "DWCUT60"},
-	{Start: 509858, LoopStart: 815, LoopLength: 127, SuggestedTranspose: 7, Name: "EHORN53A"},
-	{Start: 510885, LoopStart: 822, LoopLength: 80, SuggestedTranspose: -1, Name: "EHORN61A"},
-	{Start: 511872, LoopStart: 616, LoopLength: 48, SuggestedTranspose: -10, Name: "EHORN70A"},
-	{Start: 512621, LoopStart: 731, LoopLength: 24, SuggestedTranspose: -22, Name: "EHORN82A"},
-	{Start: 513453, LoopStart: 2070, LoopLength: 1, SuggestedTranspose: -11, Name: "ELESD60A"},
-	{Start: 515608, LoopStart: 800, LoopLength: 160, SuggestedTranspose: 11, Name: "ELP1_49"},
-	{Start: 516652, LoopStart: 731, LoopLength: 107, SuggestedTranspose: 4, Name: "ELP1_56"},
-	{Start: 517574, LoopStart: 361, LoopLength: 56, SuggestedTranspose: -7, Name: "ELP1_67"},
-	{Start: 518075, LoopStart: 442, LoopLength: 53, SuggestedTranspose: -20, Name: "ELP1_80"},
-	{Start: 518654, LoopStart: 104, LoopLength: 10, SuggestedTranspose: -37, Name: "ELP1_97"},
-	{Start: 518852, LoopStart: 1835, LoopLength: 168, SuggestedTranspose: 12, Name: "ELP2_60"},
-	{Start: 520939, LoopStart: 1206, LoopLength: 106, SuggestedTranspose: 4, Name: "ELP2_68"},
-	{Start: 522335, LoopStart: 897, LoopLength: 67, SuggestedTranspose: -4, Name: "ELP2_76"},
-	{Start: 523383, LoopStart: 501, LoopLength: 42, SuggestedTranspose: -12, Name: "ELP2_84"},
-	{Start: 524010, LoopStart: 351, LoopLength: 53, SuggestedTranspose: -20, Name: "ELP2_92"},
-	{Start: 524498, LoopStart: 303, LoopLength: 50, SuggestedTranspose: -28, Name: "ELP2_A0"},
-	{Start: 524935, LoopStart: 190, LoopLength: 95, SuggestedTranspose: -36, Name: "ELP2_A8"},
-	{Start: 525304, LoopStart: 4726, LoopLength: 2221, SuggestedTranspose: -19, Name: "ENGIN64"},
-	{Start: 532335, LoopStart: 465, LoopLength: 79, SuggestedTranspose: -13, Name: "EORG173"},
-	{Start: 532963, LoopStart: 236, LoopLength: 40, SuggestedTranspose: -25, Name: "EORG185"},
-	{Start: 533323, LoopStart: 124, LoopLength: 20, SuggestedTranspose: -37, Name: "EORG197"},
-	{Start: 533551, LoopStart: 3682, LoopLength: 253, SuggestedTranspose: 7, Name: "EORG253"},
-	{Start: 537570, LoopStart: 1663, LoopLength: 107, SuggestedTranspose: -8, Name: "EORG268"},
-	{Start: 539424, LoopStart: 1340, LoopLength: 75, SuggestedTranspose: -26, Name: "EORG286"},
-	{Start: 540923, LoopStart: 1236, LoopLength: 2261, SuggestedTranspose: -11, Name: "EORG367"},
-	{Start: 544504, LoopStart: 1353, LoopLength: 2317, SuggestedTranspose: -23, Name: "EORG379"},
-	{Start: 548258, LoopStart: 1260, LoopLength: 2184, SuggestedTranspose: -35, Name: "EORG391"},
-	{Start: 551786, LoopStart: 3820, LoopLength: 1645, SuggestedTranspose: 7, Name: "FANTA53"},
-	{Start: 557335, LoopStart: 2374, LoopLength: 821, SuggestedTranspose: -21, Name: "FANTA81"},
-	{Start: 560606, LoopStart: 4276, LoopLength: 1, SuggestedTranspose: 22, Name: "FATSD60A"},
-	{Start: 564967, LoopStart: 5356, LoopLength: 422, SuggestedTranspose: 0, Name: "FHORN60"},
-	{Start: 570829, LoopStart: 3831, LoopLength: 253, SuggestedTranspose: -12, Name: "FHORN72"},
-	{Start: 574997, LoopStart: 1920, LoopLength: 64, SuggestedTranspose: -5, Name: "FLUTE65A"},
-	{Start: 577066, LoopStart: 1948, LoopLength: 80, SuggestedTranspose: -13, Name: "FLUTE73A"},
-	{Start: 579179, LoopStart: 1793, LoopLength: 29, SuggestedTranspose: -18, Name: "FLUTE79A"},
-	{Start: 581086, LoopStart: 1261, LoopLength: 34, SuggestedTranspose: -28, Name: "FLUTE88A"},
-	{Start: 582466, LoopStart: 1044, LoopLength: 11, SuggestedTranspose: -35, Name: "FLUTE96A"},
-	{Start: 583606, LoopStart: 681, LoopLength: 13, SuggestedTranspose: -44, Name: "FLUTEA5A"},
-	{Start: 584385, LoopStart: 844, LoopLength: 2376, SuggestedTranspose: -6, Name: "FMVOX66A"},
-	{Start: 587690, LoopStart: 684, LoopLength: 2583, SuggestedTranspose: -26, Name: "FMVOX86A"},
-	{Start: 591042, LoopStart: 1428, LoopLength: 201, SuggestedTranspose: -28, Name: "FNTBL53C"},
-	{Start: 592756, LoopStart: 9308, LoopLength: 337, SuggestedTranspose: 24, Name: "FRTLS36A"},
-	{Start: 602486, LoopStart: 5344, LoopLength: 169, SuggestedTranspose: 12, Name: "FRTLS48A"},
-	{Start: 608084, LoopStart: 2496, LoopLength: 84, SuggestedTranspose: 0, Name: "FRTLS60A"},
-	{Start: 610741, LoopStart: 6018, LoopLength: 1, SuggestedTranspose: 16, Name: "GATSD60A"},
-	{Start: 616836, LoopStart: 2487, LoopLength: 1, SuggestedTranspose: 4, Name: "GFRET69"},
-	{Start: 619407, LoopStart: 326, LoopLength: 118, SuggestedTranspose: -25, Name: "GLKN285"},
-	{Start: 619935, LoopStart: 232, LoopLength: 84, SuggestedTranspose: -31, Name: "GLKN385"},
-	{Start: 620335, LoopStart: 125, LoopLength: 44, SuggestedTranspose: -42, Name: "GLKN585"},
-	{Start: 620588, LoopStart: 21, LoopLength: 6246, SuggestedTranspose: -24, Name: "GOBLN84A"},
-	{Start: 626940, LoopStart: 2, LoopLength: 106, SuggestedTranspose: 4, Name: "HARMO68"},
-	{Start: 627132, LoopStart: 1, LoopLength: 67, SuggestedTranspose: -4, Name: "HARMO76"},
-	{Start: 627284, LoopStart: 3, LoopLength: 42, SuggestedTranspose: -12, Name: "HARMO84"},
-	{Start: 627413, LoopStart: 2, LoopLength: 53, SuggestedTranspose: -20, Name: "HARMO92"},
-	{Start: 627552, LoopStart: 1, LoopLength: 50, SuggestedTranspose: -28, Name: "HARMOA0"},
-	{Start: 627687, LoopStart: 1, LoopLength: 21, SuggestedTranspose: -36, Name: "HARMOA8"},
-	{Start: 627793, LoopStart: 3340, LoopLength: 501, SuggestedTranspose: 3, Name: "HARP_57A"},
-	{Start: 631719, LoopStart: 2937, LoopLength: 520, SuggestedTranspose: -10, Name: "HARP_70A"},
-	{Start: 635261, LoopStart: 2290, LoopLength: 469, SuggestedTranspose: -23, Name: "HARP_83A"},
-	{Start: 638105, LoopStart: 2033, LoopLength: 148, SuggestedTranspose: -36, Name: "HARP_96A"},
-	{Start: 640363, LoopStart: 1341, LoopLength: 1, SuggestedTranspose: -2, Name: "HBNGO60"},
-	{Start: 641780, LoopStart: 2423, LoopLength: 1, SuggestedTranspose: 21, Name: "HCLAP60"},
-	{Start: 644279, LoopStart: 1447, LoopLength: 1, SuggestedTranspose: -35, Name: "HEART64"},
-	{Start: 645810, LoopStart: 7, LoopLength: 3878, SuggestedTranspose: -2, Name: "HELI_60"},
-	{Start: 649771, LoopStart: 640, LoopLength: 1, SuggestedTranspose: 33, Name: "HI_Q_60"},
-	{Start: 650495, LoopStart: 5284, LoopLength: 711, SuggestedTranspose: 18, Name: "HONKY42A"},
-	{Start: 656575, LoopStart: 2699, LoopLength: 224, SuggestedTranspose: -2, Name: "HONKY62B"},
-	{Start: 659583, LoopStart: 2848, LoopLength: 89, SuggestedTranspose: -11, Name: "HONKY71A"},
-	{Start: 662605, LoopStart: 2585, LoopLength: 21, SuggestedTranspose: -24, Name: "HONKY84A"},
-	{Start: 665296, LoopStart: 1802, LoopLength: 201, SuggestedTranspose: -36, Name: "HONKY96A"},
-	{Start: 667384, LoopStart: 6, LoopLength: 3797, SuggestedTranspose: -32, Name: "HORSE64"},
-	{Start: 671271, LoopStart: 784, LoopLength: 71, SuggestedTranspose: -7, Name: "HRMGT52"},
-	{Start: 672210, LoopStart: 691, LoopLength: 18, SuggestedTranspose: -22, Name: "HRMGT76"},
-	{Start: 673003, LoopStart: 5506, LoopLength: 1180, SuggestedTranspose: 12, Name: "HRPSI48A"},
-	{Start: 679774, LoopStart: 4501, LoopLength: 674, SuggestedTranspose: 0, Name: "HRPSI60A"},
-	{Start: 685034, LoopStart: 3291, LoopLength: 716, SuggestedTranspose: -12, Name: "HRPSI72A"},
-	{Start: 689126, LoopStart: 3169, LoopLength: 496, SuggestedTranspose: -27, Name: "HRPSI87A"},
-	{Start: 692876, LoopStart: 2901, LoopLength: 1852, SuggestedTranspose: -7, Name: "ICE__60A"},
-	{Start: 697714, LoopStart: 2237, LoopLength: 1660, SuggestedTranspose: -31, Name: "ICE__84A"},
-	{Start: 701696, LoopStart: 4982, LoopLength: 1502, SuggestedTranspose: 10, Name: "JAZGT50A"},
-	{Start: 708265, LoopStart: 4518, LoopLength: 1875, SuggestedTranspose: 1, Name: "JAZGT59A"},
-	{Start: 714743, LoopStart: 4663, LoopLength: 796, SuggestedTranspose: -8, Name: "JAZGT68A"},
-	{Start: 720287, LoopStart: 3895, LoopLength: 526, SuggestedTranspose: -14, Name: "JAZGT74A"},
-	{Start: 724793, LoopStart: 4102, LoopLength: 886, SuggestedTranspose: -24, Name: "JAZGT84A"},
-	{Start: 729866, LoopStart: 2882, LoopLength: 677, SuggestedTranspose: -33, Name: "JAZGT93A"},
-	{Start: 733510, LoopStart: 1843, LoopLength: 647, SuggestedTranspose: -41, Name: "JAZGTA1A"},
-	{Start: 736077, LoopStart: 5557, LoopLength: 1, SuggestedTranspose: -25, Name: "JBELL60"},
-	{Start: 741710, LoopStart: 140, LoopLength: 1, SuggestedTranspose: 0, Name: "KEY__60"},
-	{Start: 741926, LoopStart: 1034, LoopLength: 1, SuggestedTranspose: 26, Name: "KICK264"},
-	{Start: 743044, LoopStart: 1446, LoopLength: 48, SuggestedTranspose: -10, Name: "KLMBA70"},
-	{Start: 744622, LoopStart: 1755, LoopLength: 88, SuggestedTranspose: 1, Name: "KOTO_57"},
-	{Start: 746549, LoopStart: 1322, LoopLength: 26, SuggestedTranspose: -20, Name: "KOTO_80"},
-	{Start: 747981, LoopStart: 212, LoopLength: 14, SuggestedTranspose: -43, Name: "KOTO_A2A"},
-	{Start: 748284, LoopStart: 5393, LoopLength: 1, SuggestedTranspose: -25, Name: "LASER64A"},
-	{Start: 753754, LoopStart: 17433, LoopLength: 1, SuggestedTranspose: 4, Name: "LAUGH59A"},
-	{Start: 771264, LoopStart: 2280, LoopLength: 1, SuggestedTranspose: -1, Name: "LBNGO60"},
-	{Start: 773620, LoopStart: 4376, LoopLength: 1, SuggestedTranspose: -19, Name: "LGUIR60"},
-	{Start: 778072, LoopStart: 7813, LoopLength: 1, SuggestedTranspose: -12, Name: "LWHSL60A"},
-	{Start: 785962, LoopStart: 1166, LoopLength: 1, SuggestedTranspose: -10, Name: "MARCS60"},
-	{Start: 787204, LoopStart: 634, LoopLength: 1, SuggestedTranspose: -3, Name: "MCNGA60"},
-	{Start: 787914, LoopStart: 2029, LoopLength: 1, SuggestedTranspose: -28, Name: "MCUIC60"},
-	{Start: 790027, LoopStart: 750, LoopLength: 129, SuggestedTranspose: 26, Name: "METRO60"},
-	{Start: 790990, LoopStart: 15, LoopLength: 201, SuggestedTranspose: 15, Name: "MGSQR45"},
-	{Start: 791290, LoopStart: 14, LoopLength: 106, SuggestedTranspose: 4, Name: "MGSQR57"},
-	{Start: 791494, LoopStart: 14, LoopLength: 53, SuggestedTranspose: -8, Name: "MGSQR69"},
-	{Start: 791645, LoopStart: 17, LoopLength: 27, SuggestedTranspose: -20, Name: "MGSQR81"},
-	{Start: 791773, LoopStart: 15, LoopLength: 14, SuggestedTranspose: -31, Name: "MGSQR93"},
-	{Start: 791886, LoopStart: 2717, LoopLength: 106, SuggestedTranspose: 4, Name: "MMBAS40"},
-	{Start: 794793, LoopStart: 2472, LoopLength: 89, SuggestedTranspose: 1, Name: "MMBAS43"},
-	{Start: 797438, LoopStart: 997, LoopLength: 134, SuggestedTranspose: -16, Name: "MMBAS62"},
-	{Start: 798645, LoopStart: 2004, LoopLength: 1, SuggestedTranspose: 18, Name: "MONDO60"},
-	{Start: 800733, LoopStart: 1317, LoopLength: 594, SuggestedTranspose: 6, Name: "MRMBA50"},
-	{Start: 802728, LoopStart: 1528, LoopLength: 74, SuggestedTranspose: -2, Name: "MRMBA62"},
-	{Start: 804414, LoopStart: 299, LoopLength: 15, SuggestedTranspose: -30, Name: "MRMBA90"},
-	{Start: 804812, LoopStart: 1184, LoopLength: 225, SuggestedTranspose: 17, Name: "MUTGT43"},
-	{Start: 806305, LoopStart: 1007, LoopLength: 67, SuggestedTranspose: -4, Name: "MUTGT64"},
-	{Start: 807463, LoopStart: 485, LoopLength: 25, SuggestedTranspose: -21, Name: "MUTGT81"},
-	{Start: 808057, LoopStart: 4115, LoopLength: 67, SuggestedTranspose: -4, Name: "MUTTP64B"},
-	{Start: 812324, LoopStart: 3422, LoopLength: 80, SuggestedTranspose: -13, Name: "MUTTP73B"},
-	{Start: 815911, LoopStart: 3254, LoopLength: 113, SuggestedTranspose: -19, Name: "MUTTP79B"},
-	{Start: 819363, LoopStart: 2835, LoopLength: 38, SuggestedTranspose: -26, Name: "MUTTP86B"},
-	{Start: 822321, LoopStart: 583, LoopLength: 213, SuggestedTranspose: -22, Name: "M_BOX82"},
-	{Start: 823201, LoopStart: 7, LoopLength: 834, SuggestedTranspose: -11, Name: "M_GUN64"},
-	{Start: 824126, LoopStart: 3460, LoopLength: 1011, SuggestedTranspose: 12, Name: "NYLON48A"},
-	{Start: 828682, LoopStart: 3565, LoopLength: 602, SuggestedTranspose: 3, Name: "NYLON57A"},
-	{Start: 832934, LoopStart: 2820, LoopLength: 520, SuggestedTranspose: -10, Name: "NYLON70A"},
-	{Start: 836359, LoopStart: 2787, LoopLength: 113, SuggestedTranspose: -19, Name: "NYLON79A"},
-	{Start: 839344, LoopStart: 1727, LoopLength: 84, SuggestedTranspose: -28, Name: "NYLON88A"},
-	{Start: 841240, LoopStart: 1008, LoopLength: 80, SuggestedTranspose: -37, Name: "NYLON97A"},
-	{Start: 842413, LoopStart: 3308, LoopLength: 13, SuggestedTranspose: -44, Name: "NYLONA5A"},
-	{Start: 845819, LoopStart: 2165, LoopLength: 80, SuggestedTranspose: -1, Name: "OBOE_61A"},
-	{Start: 848149, LoopStart: 1579, LoopLength: 51, SuggestedTranspose: -9, Name: "OBOE_69A"},
-	{Start: 849864, LoopStart: 1579, LoopLength: 32, SuggestedTranspose: -17, Name: "OBOE_77A"},
-	{Start: 851560, LoopStart: 201, LoopLength: 25, SuggestedTranspose: -21, Name: "OBOE_81"},
-	{Start: 851870, LoopStart: 825, LoopLength: 80, SuggestedTranspose: -25, Name: "OBOE_85A"},
-	{Start: 852860, LoopStart: 3081, LoopLength: 43, SuggestedTranspose: -12, Name: "OCARI72A"},
-	{Start: 856069, LoopStart: 2581, LoopLength: 22, SuggestedTranspose: -23, Name: "OCARI84A"},
-	{Start: 858757, LoopStart: 2880, LoopLength: 11, SuggestedTranspose: -35, Name: "OCARI96A"},
-	{Start: 861725, LoopStart: 2786, LoopLength: 1, SuggestedTranspose: -5, Name: "OCNGA60"},
-	{Start: 864587, LoopStart: 2474, LoopLength: 1, SuggestedTranspose: -29, Name: "OCUIC60"},
-	{Start: 867145, LoopStart: 5808, LoopLength: 3761, SuggestedTranspose: 15, Name: "OHH__60B"},
-	{Start: 876799, LoopStart: 4335, LoopLength: 424, SuggestedTranspose: 28, Name: "OVDRV44"},
-	{Start: 881642, LoopStart: 3585, LoopLength: 318, SuggestedTranspose: 23, Name: "OVDRV49"},
-	{Start: 885629, LoopStart: 2433, LoopLength: 169, SuggestedTranspose: 12, Name: "OVDRV60"},
-	{Start: 888315, LoopStart: 4190, LoopLength: 169, SuggestedTranspose: 0, Name: "OVDRV60A"},
-	{Start: 892759, LoopStart: 1213, LoopLength: 88, SuggestedTranspose: 1, Name: "OVDRV70"},
-	{Start: 894144, LoopStart: 1253, LoopLength: 80, SuggestedTranspose: -1, Name: "OVDRV73"},
-	{Start: 895561, LoopStart: 867, LoopLength: 63, SuggestedTranspose: -5, Name: "OVDRV77"},
-	{Start: 896575, LoopStart: 643, LoopLength: 31, SuggestedTranspose: -17, Name: "OVDRV89"},
-	{Start: 897333, LoopStart: 378, LoopLength: 21, SuggestedTranspose: -24, Name: "OVDRV96"},
-	{Start: 897816, LoopStart: 291, LoopLength: 59, SuggestedTranspose: -34, Name: "OVDRVA6"},
-	{Start: 898250, LoopStart: 13377, LoopLength: 2014, SuggestedTranspose: -8, Name: "O_HIT68"},
-	{Start: 913725, LoopStart: 942, LoopLength: 840, SuggestedTranspose: -24, Name: "PANNS84"},
-	{Start: 915591, LoopStart: 484, LoopLength: 399, SuggestedTranspose: -36, Name: "PANNS96"},
-	{Start: 916558, LoopStart: 6692, LoopLength: 478, SuggestedTranspose: 30, Name: "PCKBS30A"},
-	{Start: 923813, LoopStart: 6302, LoopLength: 534, SuggestedTranspose: 20, Name: "PCKBS40A"},
-	{Start: 930734, LoopStart: 3397, LoopLength: 301, SuggestedTranspose: 10, Name: "PCKBS50A"},
-	{Start: 934517, LoopStart: 11989, LoopLength: 1682, SuggestedTranspose: 24, Name: "PIANO36"},
-	{Start: 948272, LoopStart: 10830, LoopLength: 2272, SuggestedTranspose: 19, Name: "PIANO41"},
-	{Start: 961458, LoopStart: 9313, LoopLength: 1854, SuggestedTranspose: 12, Name: "PIANO48"},
-	{Start: 972709, LoopStart: 8309, LoopLength: 1487, SuggestedTranspose: 4, Name: "PIANO56"},
-	{Start: 982589, LoopStart: 9176, LoopLength: 1339, SuggestedTranspose: -4, Name: "PIANO64"},
-	{Start: 993188, LoopStart: 6194, LoopLength: 1393, SuggestedTranspose: -13, Name: "PIANO73"},
-	{Start: 1000859, LoopStart: 6447, LoopLength: 1221, SuggestedTranspose: -18, Name: "PIANO78"},
-	{Start: 1008611, LoopStart: 5976, LoopLength: 1368, SuggestedTranspose: -24, Name: "PIANO84"},
-	{Start: 1016039, LoopStart: 5392, LoopLength: 1049, SuggestedTranspose: -32, Name: "PIANO92"},
-	{Start: 1022564, LoopStart: 4686, LoopLength: 155, SuggestedTranspose: -43, Name: "PIANOA3"},
-	{Start: 1027489, LoopStart: 1115, LoopLength: 82, SuggestedTranspose: -19, Name: "PICLO79"},
-	{Start: 1028770, LoopStart: 668, LoopLength: 21, SuggestedTranspose: -24, Name: "PICLO84"},
-	{Start: 1029543, LoopStart: 357, LoopLength: 10, SuggestedTranspose: -37, Name: "PICLO98"},
-	{Start: 1029986, LoopStart: 4121, LoopLength: 1, SuggestedTranspose: -30, Name: "PISTL60"},
-	{Start: 1034191, LoopStart: 549, LoopLength: 137, SuggestedTranspose: -36, Name: "PIZZH84"},
-	{Start: 1034961, LoopStart: 2073, LoopLength: 398, SuggestedTranspose: -11, Name: "PIZZ_71"},
-	{Start: 1037516, LoopStart: 1680, LoopLength: 2165, SuggestedTranspose: -13, Name: "PNFLT72"},
-	{Start: 1041445, LoopStart: 6350, LoopLength: 169, SuggestedTranspose: 12, Name: "POLY_48A"},
-	{Start: 1048049, LoopStart: 5713, LoopLength: 84, SuggestedTranspose: 0, Name: "POLY_60A"},
-	{Start: 1053931, LoopStart: 4758, LoopLength: 43, SuggestedTranspose: -12, Name: "POLY_72A"},
-	{Start: 1058817, LoopStart: 4663, LoopLength: 22, SuggestedTranspose: -23, Name: "POLY_84A"},
-	{Start: 1063587, LoopStart: 3536, LoopLength: 11, SuggestedTranspose: -35, Name: "POLY_96A"},
-	{Start: 1067219, LoopStart: 918, LoopLength: 1750, SuggestedTranspose: -10, Name: "POPVX64A"},
-	{Start: 1069972, LoopStart: 1315, LoopLength: 1945, SuggestedTranspose: -19, Name: "POPVX71A"},
-	{Start: 1073317, LoopStart: 1445, LoopLength: 1827, SuggestedTranspose: -30, Name: "POPVX74A"},
-	{Start: 1076666, LoopStart: 3108, LoopLength: 1, SuggestedTranspose: -11, Name: "PUNCH64"},
-	{Start: 1079858, LoopStart: 4976, LoopLength: 3960, SuggestedTranspose: 1, Name: "RDBEL64B"},
-	{Start: 1088879, LoopStart: 4913, LoopLength: 4113, SuggestedTranspose: 10, Name: "RDCYM60B"},
-	{Start: 1097990, LoopStart: 934, LoopLength: 60, SuggestedTranspose: -6, Name: "RECDR66A"},
-	{Start: 1099069, LoopStart: 1034, LoopLength: 36, SuggestedTranspose: -15, Name: "RECDR75A"},
-	{Start: 1100224, LoopStart: 733, LoopLength: 23, SuggestedTranspose: -23, Name: "RECDR83A"},
-	{Start: 1101065, LoopStart: 309, LoopLength: 13, SuggestedTranspose: -33, Name: "RECDR93A"},
-	{Start: 1101472, LoopStart: 190, LoopLength: 16, SuggestedTranspose: -41, Name: "RECDRA3A"},
-	{Start: 1101763, LoopStart: 41, LoopLength: 254, SuggestedTranspose: 7, Name: "R_ORG53"},
-	{Start: 1102142, LoopStart: 12, LoopLength: 43, SuggestedTranspose: -12, Name: "R_ORG72"},
-	{Start: 1102281, LoopStart: 50, LoopLength: 50, SuggestedTranspose: -28, Name: "R_ORG88"},
-	{Start: 1102465, LoopStart: 1112, LoopLength: 410, SuggestedTranspose: -14, Name: "SANTR74"},
-	{Start: 1104071, LoopStart: 5111, LoopLength: 10132, SuggestedTranspose: 12, Name: "SAW0_48"},
-	{Start: 1119398, LoopStart: 4408, LoopLength: 5903, SuggestedTranspose: 0, Name: "SAW0_60"},
-	{Start: 1129793, LoopStart: 3892, LoopLength: 6127, SuggestedTranspose: -12, Name: "SAW0_72"},
-	{Start: 1139896, LoopStart: 265, LoopLength: 265, SuggestedTranspose: 20, Name: "SAW__40"},
-	{Start: 1140510, LoopStart: 132, LoopLength: 132, SuggestedTranspose: 8, Name: "SAW__52"},
-	{Start: 1140858, LoopStart: 66, LoopLength: 66, SuggestedTranspose: -4, Name: "SAW__64"},
-	{Start: 1141074, LoopStart: 33, LoopLength: 33, SuggestedTranspose: -16, Name: "SAW__76"},
-	{Start: 1141224, LoopStart: 17, LoopLength: 17, SuggestedTranspose: -28, Name: "SAW__88"},
-	{Start: 1141342, LoopStart: 8, LoopLength: 16, SuggestedTranspose: -41, Name: "SAW__A0A"},
-	{Start: 1141451, LoopStart: 6, LoopLength: 12, SuggestedTranspose: -46, Name: "SAW__A7A"},
-	{Start: 1141554, LoopStart: 4, LoopLength: 12, SuggestedTranspose: -53, Name: "SAW__B2A"},
-	{Start: 1141655, LoopStart: 3, LoopLength: 12, SuggestedTranspose: -58, Name: "SAW__B9B"},
-	{Start: 1141755, LoopStart: 5639, LoopLength: 2360, SuggestedTranspose: 12, Name: "SBRS148A"},
-	{Start: 1149839, LoopStart: 3829, LoopLength: 2191, SuggestedTranspose: 0, Name: "SBRS160A"},
-	{Start: 1155944, LoopStart: 4020, LoopLength: 1981, SuggestedTranspose: -12, Name: "SBRS172A"},
-	{Start: 1162030, LoopStart: 2775, LoopLength: 1264, SuggestedTranspose: -24, Name: "SBRS184A"},
-	{Start: 1166154, LoopStart: 3023, LoopLength: 11, SuggestedTranspose: -35, Name: "SBRS196A"},
-	{Start: 1169273, LoopStart: 8834, LoopLength: 675, SuggestedTranspose: 17, Name: "SBRS243"},
-	{Start: 1178866, LoopStart: 5417, LoopLength: 338, SuggestedTranspose: 5, Name: "SBRS255"},
-	{Start: 1184705, LoopStart: 3494, LoopLength: 43, SuggestedTranspose: -12, Name: "SBRS272"},
-	{Start: 1188326, LoopStart: 2406, LoopLength: 22, SuggestedTranspose: -35, Name: "SBRS296"},
-	{Start: 1190830, LoopStart: 6474, LoopLength: 1, SuggestedTranspose: -8, Name: "SCRCH64"},
-	{Start: 1197380, LoopStart: 9032, LoopLength: 1, SuggestedTranspose: -4, Name: "SCREM64A"},
-	{Start: 1206489, LoopStart: 4046, LoopLength: 1, SuggestedTranspose: -15, Name: "SDOOR64"},
-	{Start: 1210619, LoopStart: 1, LoopLength: 13667, SuggestedTranspose: -17, Name: "SEA__60"},
-	{Start: 1224363, LoopStart: 681, LoopLength: 1, SuggestedTranspose: -13, Name: "SGUIR60"},
-	{Start: 1225128, LoopStart: 3559, LoopLength: 2336, SuggestedTranspose: -3, Name: "SHAKU63"},
-	{Start: 1231107, LoopStart: 2962, LoopLength: 1891, SuggestedTranspose: -10, Name: "SHAKU70"},
-	{Start: 1236044, LoopStart: 2509, LoopLength: 1994, SuggestedTranspose: -18, Name: "SHAKU78"},
-	{Start: 1240631, LoopStart: 1065, LoopLength: 103, SuggestedTranspose: 3, Name: "SHAMI53"},
-	{Start: 1241883, LoopStart: 806, LoopLength: 52, SuggestedTranspose: -8, Name: "SHAMI65"},
-	{Start: 1242825, LoopStart: 776, LoopLength: 27, SuggestedTranspose: -20, Name: "SHAMI77"},
-	{Start: 1243712, LoopStart: 349, LoopLength: 15, SuggestedTranspose: -30, Name: "SHAMI89"},
-	{Start: 1244152, LoopStart: 1199, LoopLength: 1, SuggestedTranspose: 29, Name: "SIDE_60"},
-	{Start: 1245435, LoopStart: 100, LoopLength: 133, SuggestedTranspose: 17, Name: "SINE_52"},
-	{Start: 1245752, LoopStart: 51, LoopLength: 67, SuggestedTranspose: -4, Name: "SINE_64"},
-	{Start: 1245954, LoopStart: 25, LoopLength: 34, SuggestedTranspose: -16, Name: "SINE_76"},
-	{Start: 1246097, LoopStart: 13, LoopLength: 17, SuggestedTranspose: 7, Name: "SINE_88"},
-	{Start: 1246211, LoopStart: 11, LoopLength: 18, SuggestedTranspose: -39, Name: "SINE_A0A"},
-	{Start: 1246325, LoopStart: 16, LoopLength: 10, SuggestedTranspose: -49, Name: "SINE_B2A"},
-	{Start: 1246436, LoopStart: 17, LoopLength: 25, SuggestedTranspose: -59, Name: "SINE_B9"},
-	{Start: 1246562, LoopStart: 32, LoopLength: 1553, SuggestedTranspose: -23, Name: "SIREN64"},
-	{Start: 1248231, LoopStart: 469, LoopLength: 83, SuggestedTranspose: 0, Name: "SITAR60"},
-	{Start: 1248867, LoopStart: 445, LoopLength: 33, SuggestedTranspose: -16, Name: "SITAR76"},
-	{Start: 1249429, LoopStart: 345, LoopLength: 22, SuggestedTranspose: -35, Name: "SITAR95"},
-	{Start: 1249872, LoopStart: 2863, LoopLength: 1, SuggestedTranspose: 29, Name: "SLAP_60"},
-	{Start: 1252819, LoopStart: 6513, LoopLength: 905, SuggestedTranspose: 29, Name: "SLPBS31"},
-	{Start: 1260321, LoopStart: 3908, LoopLength: 1579, SuggestedTranspose: 17, Name: "SLPBS43"},
-	{Start: 1265892, LoopStart: 328, LoopLength: 22, SuggestedTranspose: -23, Name: "SLPBS84"},
-	{Start: 1266326, LoopStart: 1260, LoopLength: 537, SuggestedTranspose: 8, Name: "SLPMT52A"},
-	{Start: 1268200, LoopStart: 2464, LoopLength: 1, SuggestedTranspose: 24, Name: "SOLKK60A"},
-	{Start: 1270749, LoopStart: 8409, LoopLength: 3376, SuggestedTranspose: 0, Name: "SOUND60"},
-	{Start: 1282618, LoopStart: 6590, LoopLength: 2902, SuggestedTranspose: -24, Name: "SOUND84"},
-	{Start: 1292194, LoopStart: 1346, LoopLength: 5219, SuggestedTranspose: -19, Name: "SPACE79"},
-	{Start: 1298835, LoopStart: 1827, LoopLength: 1, SuggestedTranspose: 22, Name: "SPULL60"},
-	{Start: 1300738, LoopStart: 1515, LoopLength: 1, SuggestedTranspose: 24, Name: "SPUSH60"},
-	{Start: 1302337, LoopStart: 2991, LoopLength: 3031, SuggestedTranspose: 19, Name: "SQUAR41"},
-	{Start: 1308443, LoopStart: 5198, LoopLength: 2694, SuggestedTranspose: 12, Name: "SQUAR48"},
-	{Start: 1316419, LoopStart: 4000, LoopLength: 3551, SuggestedTranspose: 0, Name: "SQUAR60"},
-	{Start: 1324054, LoopStart: 1641, LoopLength: 5313, SuggestedTranspose: -7, Name: "SQUAR67"},
-	{Start: 1331092, LoopStart: 2032, LoopLength: 4789, SuggestedTranspose: -19, Name: "SQUAR79"},
-	{Start: 1337997, LoopStart: 828, LoopLength: 1800, SuggestedTranspose: -36, Name: "SQUAR96"},
-	{Start: 1340709, LoopStart: 169, LoopLength: 10805, SuggestedTranspose: 12, Name: "SSTR148"},
-	{Start: 1351767, LoopStart: 284, LoopLength: 10741, SuggestedTranspose: -9, Name: "SSTR162A"},
-	{Start: 1362877, LoopStart: 82, LoopLength: 9507, SuggestedTranspose: -26, Name: "SSTR186A"},
-	{Start: 1372551, LoopStart: 2336, LoopLength: 12051, SuggestedTranspose: -7, Name: "SSTR367"},
-	{Start: 1387022, LoopStart: 983, LoopLength: 5067, SuggestedTranspose: -22, Name: "SSTR382"},
-	{Start: 1393156, LoopStart: 2324, LoopLength: 2277, SuggestedTranspose: -1, Name: "STAR_60A"},
-	{Start: 1397842, LoopStart: 2504, LoopLength: 21, SuggestedTranspose: -25, Name: "STAR_84A"},
-	{Start: 1400444, LoopStart: 1125, LoopLength: 1, SuggestedTranspose: -19, Name: "STEPS64"},
-	{Start: 1401645, LoopStart: 1557, LoopLength: 1, SuggestedTranspose: 24, Name: "STICK64"},
-	{Start: 1403286, LoopStart: 2661, LoopLength: 461, SuggestedTranspose: -23, Name: "STLDR72"},
-	{Start: 1406492, LoopStart: 8352, LoopLength: 1008, SuggestedTranspose: 19, Name: "STLGT41B"},
-	{Start: 1415937, LoopStart: 4790, LoopLength: 1114, SuggestedTranspose: 11, Name: "STLGT49B"},
-	{Start: 1421926, LoopStart: 4724, LoopLength: 301, SuggestedTranspose: 3, Name: "STLGT57B"},
-	{Start: 1427036, LoopStart: 4886, LoopLength: 253, SuggestedTranspose: -5, Name: "STLGT65B"},
-	{Start: 1432260, LoopStart: 3596, LoopLength: 188, SuggestedTranspose: -14, Name: "STLGT74B"},
-	{Start: 1436129, LoopStart: 2763, LoopLength: 190, SuggestedTranspose: -24, Name: "STLGT84B"},
-	{Start: 1439167, LoopStart: 611, LoopLength: 8934, SuggestedTranspose: -19, Name: "STREM67B"},
-	{Start: 1448797, LoopStart: 251, LoopLength: 9710, SuggestedTranspose: 3, Name: "STRNG52B"},
-	{Start: 1458843, LoopStart: 147, LoopLength: 11272, SuggestedTranspose: -11, Name: "STRNG71B"},
-	{Start: 1470347, LoopStart: 136, LoopLength: 7411, SuggestedTranspose: -28, Name: "STRNG88B"},
-	{Start: 1477979, LoopStart: 11, LoopLength: 1505, SuggestedTranspose: -17, Name: "STSHP64"},
-	{Start: 1479579, LoopStart: 3574, LoopLength: 1317, SuggestedTranspose: -38, Name: "SURDO60"},
-	{Start: 1484546, LoopStart: 1867, LoopLength: 1, SuggestedTranspose: -11, Name: "SWHSL60A"},
-	{Start: 1486498, LoopStart: 16476, LoopLength: 403, SuggestedTranspose: 27, Name: "SYBS133"},
-	{Start: 1503461, LoopStart: 3475, LoopLength: 170, SuggestedTranspose: 12, Name: "SYBS148A"},
-	{Start: 1507191, LoopStart: 2445, LoopLength: 42, SuggestedTranspose: -12, Name: "SYBS172A"},
-	{Start: 1509763, LoopStart: 1356, LoopLength: 21, SuggestedTranspose: -36, Name: "SYBS196A"},
-	{Start: 1511225, LoopStart: 9501, LoopLength: 401, SuggestedTranspose: 27, Name: "SYBS233"},
-	{Start: 1521211, LoopStart: 2768, LoopLength: 340, SuggestedTranspose: 12, Name: "SYBS248A"},
-	{Start: 1524404, LoopStart: 2956, LoopLength: 42, SuggestedTranspose: -12, Name: "SYBS272A"},
-	{Start: 1527487, LoopStart: 8625, LoopLength: 338, SuggestedTranspose: 24, Name: "SYBS336A"},
-	{Start: 1536535, LoopStart: 5591, LoopLength: 509, SuggestedTranspose: 31, Name: "SYBS429A"},
-	{Start: 1542720, LoopStart: 113, LoopLength: 112, SuggestedTranspose: 5, Name: "SYNCL55"},
-	{Start: 1543029, LoopStart: 70, LoopLength: 68, SuggestedTranspose: -4, Name: "SYNCL64"},
-	{Start: 1543251, LoopStart: 48, LoopLength: 47, SuggestedTranspose: -10, Name: "SYNCL70"},
-	{Start: 1543430, LoopStart: 36, LoopLength: 35, SuggestedTranspose: -15, Name: "SYNCL75"},
-	{Start: 1543585, LoopStart: 38, LoopLength: 18, SuggestedTranspose: -27, Name: "SYNCL87"},
-	{Start: 1543725, LoopStart: 831, LoopLength: 104, SuggestedTranspose: -23, Name: "SYNDR56"},
-	{Start: 1544744, LoopStart: 18, LoopLength: 5360, SuggestedTranspose: -14, Name: "SYNVX70"},
-	{Start: 1550206, LoopStart: 29, LoopLength: 1560, SuggestedTranspose: -46, Name: "SYNVXA6A"},
-	{Start: 1551880, LoopStart: 1856, LoopLength: 95, SuggestedTranspose: 2, Name: "S_SAX70"},
-	{Start: 1553915, LoopStart: 1220, LoopLength: 68, SuggestedTranspose: -4, Name: "S_SAX76"},
-	{Start: 1555287, LoopStart: 3761, LoopLength: 48, SuggestedTranspose: -10, Name: "S_SAX82"},
-	{Start: 1559180, LoopStart: 3225, LoopLength: 34, SuggestedTranspose: -16, Name: "S_SAX88"},
-	{Start: 1562523, LoopStart: 2235, LoopLength: 67, SuggestedTranspose: -23, Name: "S_SAX95"},
-	{Start: 1564909, LoopStart: 458, LoopLength: 16, SuggestedTranspose: -29, Name: "S_SAXA2"},
-	{Start: 1565467, LoopStart: 366, LoopLength: 44, SuggestedTranspose: -35, Name: "S_SAXA8"},
-	{Start: 1565953, LoopStart: 2192, LoopLength: 1, SuggestedTranspose: 6, Name: "TAMBO60"},
-	{Start: 1568229, LoopStart: 21, LoopLength: 839, SuggestedTranspose: -12, Name: "TELE260"},
-	{Start: 1569173, LoopStart: 10, LoopLength: 1505, SuggestedTranspose: -8, Name: "TELE_60"},
-	{Start: 1570772, LoopStart: 4443, LoopLength: 3535, SuggestedTranspose: -53, Name: "THUND64"},
-	{Start: 1578826, LoopStart: 3960, LoopLength: 1, SuggestedTranspose: -10, Name: "TIMBL60"},
-	{Start: 1582870, LoopStart: 4574, LoopLength: 337, SuggestedTranspose: -7, Name: "TKOTO67"},
-	{Start: 1587865, LoopStart: 3431, LoopLength: 112, SuggestedTranspose: -19, Name: "TKOTO79"},
-	{Start: 1591484, LoopStart: 6768, LoopLength: 1, SuggestedTranspose: 0, Name: "TMPNI47"},
-	{Start: 1598336, LoopStart: 152, LoopLength: 77, SuggestedTranspose: -45, Name: "TNKBLA5"},
-	{Start: 1598649, LoopStart: 5463, LoopLength: 1003, SuggestedTranspose: -4, Name: "TOM__60"},
-	{Start: 1605199, LoopStart: 15, LoopLength: 7168, SuggestedTranspose: -28, Name: "TRAIN64"},
-	{Start: 1612466, LoopStart: 833, LoopLength: 961, SuggestedTranspose: -21, Name: "TRI__60"},
-	{Start: 1614344, LoopStart: 1941, LoopLength: 268, SuggestedTranspose: 20, Name: "TRMBN40A"},
-	{Start: 1616638, LoopStart: 2724, LoopLength: 169, SuggestedTranspose: 12, Name: "TRMBN48A"},
-	{Start: 1619616, LoopStart: 2251, LoopLength: 113, SuggestedTranspose: 5, Name: "TRMBN55A"},
-	{Start: 1622065, LoopStart: 1916, LoopLength: 76, SuggestedTranspose: -2, Name: "TRMBN62A"},
-	{Start: 1624142, LoopStart: 2652, LoopLength: 54, SuggestedTranspose: -8, Name: "TRMBN68A"},
-	{Start: 1626933, LoopStart: 1569, LoopLength: 32, SuggestedTranspose: -17, Name: "TRMBN77A"},
-	{Start: 1628619, LoopStart: 893, LoopLength: 57, SuggestedTranspose: -26, Name: "TRMBN86A"},
-	{Start: 1629654, LoopStart: 460, LoopLength: 13, SuggestedTranspose: -32, Name: "TRMBN93A"},
-	{Start: 1630212, LoopStart: 1275, LoopLength: 127, SuggestedTranspose: 7, Name: "TRMPT65"},
-	{Start: 1631698, LoopStart: 1210, LoopLength: 80, SuggestedTranspose: -1, Name: "TRMPT73"},
-	{Start: 1633072, LoopStart: 942, LoopLength: 100, SuggestedTranspose: -9, Name: "TRMPT81"},
-	{Start: 1634198, LoopStart: 721, LoopLength: 38, SuggestedTranspose: -14, Name: "TRMPT86"},
-	{Start: 1635041, LoopStart: 560, LoopLength: 27, SuggestedTranspose: -20, Name: "TRMPT92"},
-	{Start: 1635712, LoopStart: 444, LoopLength: 16, SuggestedTranspose: -29, Name: "TRMPTA1"},
-	{Start: 1636256, LoopStart: 208, LoopLength: 18, SuggestedTranspose: -39, Name: "TRMPTB1A"},
-	{Start: 1636567, LoopStart: 1372, LoopLength: 281, SuggestedTranspose: 21, Name: "TUBA_34"},
-	{Start: 1638304, LoopStart: 1032, LoopLength: 147, SuggestedTranspose: 10, Name: "TUBA_45"},
-	{Start: 1639567, LoopStart: 294, LoopLength: 872, SuggestedTranspose: -24, Name: "TUBLR84"},
-	{Start: 1640817, LoopStart: 1201, LoopLength: 380, SuggestedTranspose: 26, Name: "T_SAX46"},
-	{Start: 1642482, LoopStart: 1014, LoopLength: 201, SuggestedTranspose: 15, Name: "T_SAX57"},
-	{Start: 1643781, LoopStart: 856, LoopLength: 142, SuggestedTranspose: 9, Name: "T_SAX63"},
-	{Start: 1644863, LoopStart: 726, LoopLength: 106, SuggestedTranspose: 4, Name: "T_SAX68"},
-	{Start: 1645779, LoopStart: 936, LoopLength: 76, SuggestedTranspose: -2, Name: "T_SAX74"},
-	{Start: 1646875, LoopStart: 616, LoopLength: 50, SuggestedTranspose: -9, Name: "T_SAX81"},
-	{Start: 1647625, LoopStart: 547, LoopLength: 32, SuggestedTranspose: -17, Name: "T_SAX89"},
-	{Start: 1648288, LoopStart: 365, LoopLength: 20, SuggestedTranspose: -25, Name: "T_SAX97"},
-	{Start: 1648757, LoopStart: 403, LoopLength: 11, SuggestedTranspose: -35, Name: "T_SAXA8"},
-	{Start: 1649247, LoopStart: 2957, LoopLength: 1, SuggestedTranspose: 20, Name: "T_SNR60A"},
-	{Start: 1652281, LoopStart: 766, LoopLength: 1, SuggestedTranspose: -6, Name: "UPCUT60"},
-	{Start: 1653131, LoopStart: 1643, LoopLength: 2405, SuggestedTranspose: -2, Name: "VBSLP64"},
-	{Start: 1657263, LoopStart: 5878, LoopLength: 669, SuggestedTranspose: 8, Name: "VIBE_52A"},
-	{Start: 1663895, LoopStart: 5704, LoopLength: 268, SuggestedTranspose: -4, Name: "VIBE_64A"},
-	{Start: 1669952, LoopStart: 3300, LoopLength: 235, SuggestedTranspose: -16, Name: "VIBE_76A"},
-	{Start: 1673572, LoopStart: 2264, LoopLength: 118, SuggestedTranspose: -28, Name: "VIBE_88A"},
-	{Start: 1676039, LoopStart: 2428, LoopLength: 59, SuggestedTranspose: -40, Name: "VIBE_A0A"},
-	{Start: 1678611, LoopStart: 1341, LoopLength: 106, SuggestedTranspose: 4, Name: "VIOLN68"},
-	{Start: 1680142, LoopStart: 1483, LoopLength: 95, SuggestedTranspose: 2, Name: "VIOLN70"},
-	{Start: 1681804, LoopStart: 1330, LoopLength: 67, SuggestedTranspose: -4, Name: "VIOLN76"},
-	{Start: 1683285, LoopStart: 1298, LoopLength: 53, SuggestedTranspose: -8, Name: "VIOLN80"},
-	{Start: 1684720, LoopStart: 1115, LoopLength: 37, SuggestedTranspose: -14, Name: "VIOLN86"},
-	{Start: 1685956, LoopStart: 934, LoopLength: 22, SuggestedTranspose: -23, Name: "VIOLN95"},
-	{Start: 1686996, LoopStart: 868, LoopLength: 53, SuggestedTranspose: -32, Name: "VIOLNA4"},
-	{Start: 1688001, LoopStart: 3310, LoopLength: 9890, SuggestedTranspose: 0, Name: "WCHIM60A"},
-	{Start: 1701286, LoopStart: 21, LoopLength: 9362, SuggestedTranspose: 5, Name: "WIND_60"},
-	{Start: 1710745, LoopStart: 1730, LoopLength: 1, SuggestedTranspose: -8, Name: "WODBK60"},
-	{Start: 1712559, LoopStart: 399, LoopLength: 5380, SuggestedTranspose: -14, Name: "WRMPD72A"},
-	{Start: 1718423, LoopStart: 959, LoopLength: 125, SuggestedTranspose: -12, Name: "XYLO_72"},
-	{Start: 1719591, LoopStart: 545, LoopLength: 49, SuggestedTranspose: -33, Name: "XYLO_93"},
-}
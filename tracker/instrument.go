@@ -3,11 +3,14 @@ package tracker
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/vsariola/sointu"
 	"github.com/vsariola/sointu/vm"
@@ -74,6 +77,107 @@ func (m *splitInstrument) Do() {
 	m.d.Song.Patch = append(m.d.Song.Patch, right...)
 }
 
+// ReferencePitch returns an Int controlling the frequency of A4 of the
+// currently selected instrument, in Hz.
+func (m *InstrModel) ReferencePitch() Int { return MakeInt((*instrumentReferencePitch)(m)) }
+
+type instrumentReferencePitch InstrModel
+
+func (m *instrumentReferencePitch) Value() int {
+	if m.d.InstrIndex < 0 || m.d.InstrIndex >= len(m.d.Song.Patch) {
+		return 440
+	}
+	t := m.d.Song.Patch[m.d.InstrIndex].Tuning
+	if t == nil || t.ReferenceFreq <= 0 {
+		return 440
+	}
+	return int(math.Round(tuningA4(t)))
+}
+func (m *instrumentReferencePitch) SetValue(value int) bool {
+	if m.d.InstrIndex < 0 || m.d.InstrIndex >= len(m.d.Song.Patch) {
+		return false
+	}
+	defer (*Model)(m).change("ReferencePitch", PatchChange, MinorChange)()
+	instr := &m.d.Song.Patch[m.d.InstrIndex]
+	if instr.Tuning == nil {
+		t := sointu.DefaultTuning()
+		instr.Tuning = &t
+	}
+	instr.Tuning.ReferenceFreq *= float64(value) / tuningA4(instr.Tuning)
+	if reflect.DeepEqual(*instr.Tuning, sointu.DefaultTuning()) {
+		instr.Tuning = nil // back to the standard tuning
+	}
+	return true
+}
+func (m *instrumentReferencePitch) Range() RangeInclusive { return RangeInclusive{400, 480} }
+
+// tuningA4 returns the frequency of A4 (note 81) in the tuning. The reference
+// note is not necessarily A4, so it is calculated from the note table.
+func tuningA4(t *sointu.Tuning) float64 {
+	return 440 * math.Exp2(float64(t.NoteTable()[81]-81)/12)
+}
+
+// ClearTuning returns an Action to remove the tuning of the currently selected
+// instrument, returning it to 12-tone equal temperament with A4 at 440 Hz.
+func (m *InstrModel) ClearTuning() Action { return MakeAction((*clearTuning)(m)) }
+
+type clearTuning InstrModel
+
+func (m *clearTuning) Enabled() bool {
+	return m.d.InstrIndex >= 0 && m.d.InstrIndex < len(m.d.Song.Patch) && m.d.Song.Patch[m.d.InstrIndex].Tuning != nil
+}
+func (m *clearTuning) Do() {
+	defer (*Model)(m).change("ClearTuning", PatchChange, MajorChange)()
+	m.d.Song.Patch[m.d.InstrIndex].Tuning = nil
+}
+
+// TuningName returns the name of the tuning of the currently selected
+// instrument.
+func (m *InstrModel) TuningName() string {
+	if m.d.InstrIndex < 0 || m.d.InstrIndex >= len(m.d.Song.Patch) {
+		return ""
+	}
+	t := m.d.Song.Patch[m.d.InstrIndex].Tuning
+	switch {
+	case t == nil:
+		return "12-TET"
+	case t.Name == "" && len(t.Scale) == 0:
+		return "12-TET, mapped"
+	case t.Name == "":
+		return fmt.Sprintf("%d-note scale", len(t.Scale))
+	}
+	return t.Name
+}
+
+// ReadTuning reads a Scala scale (.scl) or keyboard mapping (.kbm) file and
+// applies it to the tuning of the currently selected instrument, keeping the
+// other half of the tuning. Files with the .kbm extension are read as keyboard
+// mappings, everything else as scales.
+func (m *InstrModel) ReadTuning(r io.ReadCloser) bool {
+	if m.d.InstrIndex < 0 || m.d.InstrIndex >= len(m.d.Song.Patch) {
+		return false
+	}
+	defer r.Close()
+	instr := &m.d.Song.Patch[m.d.InstrIndex]
+	t := sointu.DefaultTuning()
+	if instr.Tuning != nil {
+		t = *instr.Tuning.Copy()
+	}
+	var err error
+	if f, ok := r.(*os.File); ok && strings.EqualFold(filepath.Ext(f.Name()), ".kbm") {
+		err = t.ReadKbm(r)
+	} else {
+		err = t.ReadScl(r)
+	}
+	if err != nil {
+		(*Model)(m).Alerts().Add(fmt.Sprintf("Error reading a tuning file: %v", err), Error)
+		return false
+	}
+	defer (*Model)(m).change("ReadTuning", PatchChange, MajorChange)()
+	instr.Tuning = &t
+	return true
+}
+
 // Item returns information about the instrument at a given index.
 func (v *InstrModel) Item(i int) (name, title string, maxLevel float32, mute bool, ok bool) {
 	if i < 0 || i >= len(v.d.Song.Patch) {
@@ -409,9 +513,11 @@ func (m *InstrModel) Write(w io.WriteCloser) bool {
 	var err error
 	instr := m.d.Song.Patch[m.d.InstrIndex]
 	instr2 := sointu.Instrument{ // save only the relevant fields
-		Name:    instr.Name,
-		Comment: instr.Comment,
-		Units:   instr.Units,
+		Name:       instr.Name,
+		Comment:    instr.Comment,
+		Units:      instr.Units,
+		Samples:    instr.Samples,
+		Wavetables: instr.Wavetables,
 	}
 	if _, ok := w.(*os.File); ok {
 		instr2.Name = "" // don't save the instrument name to a file; we'll replace the instruments name with the filename when loading from a file
@@ -432,7 +538,9 @@ func (m *InstrModel) Write(w io.WriteCloser) bool {
 
 // Read reads an instrument from the given io.ReadCloser and sets it as the
 // currently selected instrument. The format is determined by trying JSON first, then
-// YAML, then 4klang Patch, then 4klang Instrument.
+// YAML, then 4klang Patch, then 4klang Instrument, then WAV sample, then
+// SoundFont. A WAV sample or the first preset of a SoundFont becomes an
+// instrument playing the sample with a sample oscillator.
 func (m *InstrModel) Read(r io.ReadCloser) bool {
 	if m.d.InstrIndex < 0 {
 		return false
@@ -443,7 +551,9 @@ func (m *InstrModel) Read(r io.ReadCloser) bool {
 	}
 	r.Close() // if we can't close the file, it's not a big deal, so ignore the error
 	var instrument sointu.Instrument
-	var errJSON, errYaml, err4ki, err4kp error
+	var errJSON, errYaml, err4ki, err4kp, errWav, errSF2 error
+	var data sointu.SampleData
+	var sf2 *sointu.SF2
 	var patch sointu.Patch
 	errJSON = json.Unmarshal(b, &instrument)
 	if errJSON == nil {
@@ -463,7 +573,23 @@ func (m *InstrModel) Read(r io.ReadCloser) bool {
 	if err4ki == nil {
 		goto success
 	}
-	(*Model)(m).Alerts().Add(fmt.Sprintf("Error unmarshaling an instrument file: %v / %v / %v / %v", errYaml, errJSON, err4ki, err4kp), Error)
+	data, errWav = sointu.ReadWavSample(bytes.NewReader(b))
+	if errWav == nil {
+		if instrument, errWav = sampleInstrument(sointu.UserSample{Name: "sample", Data: data}, 0, 0, 0); errWav == nil {
+			goto success
+		}
+		(*Model)(m).Alerts().Add(fmt.Sprintf("Error loading the sample: %v", errWav), Error)
+		return false
+	}
+	sf2, errSF2 = sointu.ReadSF2(bytes.NewReader(b))
+	if errSF2 == nil {
+		if instrument, errSF2 = sf2Instrument(sf2); errSF2 == nil {
+			goto success
+		}
+		(*Model)(m).Alerts().Add(fmt.Sprintf("Error loading the SoundFont: %v", errSF2), Error)
+		return false
+	}
+	(*Model)(m).Alerts().Add(fmt.Sprintf("Error unmarshaling an instrument file: %v / %v / %v / %v / %v / %v", errYaml, errJSON, err4ki, err4kp, errWav, errSF2), Error)
 	return false
 success:
 	if f, ok := r.(*os.File); ok {
@@ -479,5 +605,84 @@ success:
 	m.d.Song.Patch[m.d.InstrIndex].Name = instrument.Name // only copy the relevant fields to preserve the user defined values e.g. NumVoices and MIDI configuration
 	m.d.Song.Patch[m.d.InstrIndex].Comment = instrument.Comment
 	m.d.Song.Patch[m.d.InstrIndex].Units = instrument.Units
+	m.d.Song.Patch[m.d.InstrIndex].Samples = instrument.Samples
+	m.d.Song.Patch[m.d.InstrIndex].Wavetables = instrument.Wavetables
 	return true
 }
+
+// sampleInstrument builds an instrument that plays the given sample. If
+// loopLength is 0, the sample is played once, holding the last sample value
+// after the end. The transpose is in semitones and the fractional part goes to
+// the detune. Returns an error if the sample or its loop is too long for the
+// loopstart and looplength parameters, instead of cutting the sample short.
+func sampleInstrument(sample sointu.UserSample, loopStart, loopLength int, transpose float64) (sointu.Instrument, error) {
+	if loopLength <= 0 || loopStart+loopLength > len(sample.Data) {
+		loopStart, loopLength = max(len(sample.Data)-1, 0), 1
+	}
+	if loopStart > 65535 || loopLength > 65535 {
+		return sointu.Instrument{}, fmt.Errorf("the sample would play %v samples before looping, but the sample oscillator can play at most 65536", loopStart+loopLength)
+	}
+	sample.Data = sample.Data[:min(loopStart+loopLength, len(sample.Data))] // the rest is never played
+	semitones := math.Round(transpose)
+	return sointu.Instrument{
+		Units: []sointu.Unit{
+			{Type: "envelope", Parameters: map[string]int{"stereo": 0, "attack": 0, "decay": 64, "sustain": 64, "release": 64, "gain": 128}},
+			{Type: "oscillator", Parameters: map[string]int{"stereo": 0, "transpose": clamp(64+int(semitones), 0, 128), "detune": clamp(64+int(math.Round((transpose-semitones)*64)), 0, 128), "phase": 0, "color": 128, "shape": 64, "gain": 128, "type": sointu.Sample, "sample": 1, "samplestart": 0, "loopstart": loopStart, "looplength": loopLength}},
+			{Type: "mulp", Parameters: map[string]int{"stereo": 0}},
+			{Type: "out", Parameters: map[string]int{"stereo": 1, "gain": 128}},
+		},
+		Samples: []sointu.UserSample{sample},
+	}, nil
+}
+
+// sf2Instrument builds a sample instrument from the first preset of a
+// SoundFont. The sample oscillator can only play one sample, so the zone
+// playing middle C is used, with the generators of its preset zone applied.
+func sf2Instrument(sf *sointu.SF2) (sointu.Instrument, error) {
+	if len(sf.Presets) == 0 {
+		return sointu.Instrument{}, errors.New("the SoundFont has no presets")
+	}
+	preset := sf.Presets[0]
+	var zones []sointu.SF2Zone
+	for _, presetZone := range preset.Zones {
+		for _, instrZone := range sf.Instruments[presetZone.Instrument].Zones {
+			if z, ok := sointu.ApplyPresetZone(presetZone, instrZone); ok {
+				zones = append(zones, z)
+			}
+		}
+	}
+	zone, ok := sf2ZoneForKey(zones, 60)
+	if !ok {
+		return sointu.Instrument{}, fmt.Errorf("preset %v has no samples", preset.Name)
+	}
+	data, loopStart, loopLength, err := sf.ZoneSample(zone)
+	if err != nil {
+		return sointu.Instrument{}, err
+	}
+	key, cents := sf.RootKey(zone)
+	rate := float64(sf.Samples[zone.Sample].SampleRate)
+	if rate <= 0 {
+		rate = 44100
+	}
+	// a sointu note sounds an octave below the MIDI note of the same number,
+	// so MIDI key k plays at sointu note k+12. The sample oscillator plays a
+	// 44100 Hz sample at its original pitch at note 84, hence 84-(k+12) = 72-k
+	transpose := 72 - float64(key) + float64(cents)/100 + 12*math.Log2(rate/44100)
+	ret, err := sampleInstrument(sointu.UserSample{Name: sf.Samples[zone.Sample].Name, Data: data}, loopStart, loopLength, transpose)
+	ret.Name = preset.Name
+	return ret, err
+}
+
+// sf2ZoneForKey returns the zone that covers the given key, or the first zone
+// if none does.
+func sf2ZoneForKey(zones []sointu.SF2Zone, key int) (sointu.SF2Zone, bool) {
+	if len(zones) == 0 {
+		return sointu.SF2Zone{}, false
+	}
+	for _, z := range zones {
+		if z.KeyLow <= key && key <= z.KeyHigh {
+			return z, true
+		}
+	}
+	return zones[0], true
+}
@@ -0,0 +1,36 @@
+package tracker_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/tracker"
+	"github.com/vsariola/sointu/vm"
+)
+
+func TestLoadSampleInstrument(t *testing.T) {
+	for _, c := range []struct {
+		length int
+		ok     bool
+	}{{65536, true}, {65537, false}} {
+		model := tracker.NewModel(tracker.NewBroker(), []sointu.Synther{vm.GoSynther{}}, tracker.NullMIDIContext{}, "")
+		wav, err := make(sointu.AudioBuffer, c.length).Wav(true)
+		if err != nil {
+			t.Fatalf("could not create the wav file: %v", err)
+		}
+		if ok := model.Instrument().Read(io.NopCloser(bytes.NewReader(wav))); ok != c.ok {
+			t.Errorf("loading a sample of %v samples returned %v, expected %v", c.length, ok, c.ok)
+		}
+		alerted := false
+		for _, a := range model.Alerts().Iterate {
+			alerted = alerted || (a.Priority == tracker.Error && strings.Contains(a.Message, "sample"))
+		}
+		if alerted == c.ok {
+			t.Errorf("loading a sample of %v samples should alert only if it fails", c.length)
+		}
+		model.Close()
+	}
+}
@@ -69,14 +69,21 @@ type (
 
 		playerStatus PlayerStatus
 
-		scopeData      scopeData
-		detectorResult DetectorResult
+		scopeData       scopeData
+		detectorResult  DetectorResult
+		loudnessHistory []LoudnessPoint
 
 		spectrum *Spectrum
 
 		weightingType WeightingType
 		oversampling  bool
 
+		exportNormalize    bool                   // normalize the loudness of the exported audio files
+		exportLoudness     int                    // target integrated loudness of the normalization, in LUFS
+		exportWriteGain    bool                   // write the normalization gain back into the song
+		exportFileFormat   sointu.AudioFileFormat // container of the exported audio files
+		exportSampleFormat sointu.SampleFormat    // sample format of the exported audio files
+
 		specAnSettings specAnSettings
 		specAnEnabled  bool
 
@@ -152,8 +159,8 @@ const (
 	OpenSongSaveExplorer
 	OpenSongOpenExplorer
 	Export
-	ExportFloatExplorer
-	ExportInt16Explorer
+	ExportSampleFormatDialog
+	ExportExplorer
 	QuitChanges
 	QuitSaveExplorer
 	License
@@ -182,6 +189,7 @@ func NewModel(broker *Broker, synthers []sointu.Synther, midiContext MIDIContext
 	m.broker = broker
 	m.d.Octave = 4
 	m.linkInstrTrack = true
+	m.exportLoudness = -14
 	m.d.RecoveryFilePath = recoveryFilePath
 	m.spectrum = broker.GetSpectrum()
 	m.Song().reset()
@@ -348,12 +356,14 @@ func (m *Model) ProcessMsg(msg MsgToModel) {
 	}
 	if msg.HasDetectorResult {
 		m.detectorResult = msg.DetectorResult
+		m.Detector().record(msg.DetectorResult)
 	}
 	if msg.TriggerChannel > 0 {
 		m.Scope().trigger(msg.TriggerChannel)
 	}
 	if msg.Reset {
 		m.Scope().reset()
+		m.loudnessHistory = m.loudnessHistory[:0]
 		TrySend(m.broker.ToDetector, MsgToDetector{Reset: true}) // chain the messages: when the signal analyzer is reset, also reset the detector
 	}
 	switch e := msg.Data.(type) {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/vsariola/sointu"
 	"github.com/vsariola/sointu/tracker"
@@ -302,3 +303,20 @@ func FuzzModel(f *testing.F) {
 		broker.CloseDetector <- struct{}{}
 	})
 }
+
+func TestPackedSizesEstimatedInBackground(t *testing.T) {
+	broker := tracker.NewBroker()
+	model := tracker.NewModel(broker, []sointu.Synther{vm.GoSynther{}}, tracker.NullMIDIContext{}, "")
+	defer model.Close()
+	for model.Song().PackedSizes() == nil {
+		msg, ok := tracker.TimeoutReceive(broker.ToModel, 10*time.Second)
+		if !ok {
+			t.Fatalf("the packed sizes were not estimated")
+		}
+		model.ProcessMsg(msg)
+	}
+	sizes := model.Song().PackedSizes()
+	if total := sizes[len(sizes)-1]; total.Name != "total" || total.Raw <= 0 || total.Packed <= 0 {
+		t.Errorf("expected the total as the last section, got %+v", total)
+	}
+}
@@ -3,6 +3,7 @@ package tracker
 import (
 	"fmt"
 	"math"
+	"os"
 	"slices"
 	"strconv"
 
@@ -426,14 +427,72 @@ type GmDlsEntry struct {
 	Name               string // sample Name
 }
 
+// GmDlsEntries is a list of all samples in the gm.dls file, parsed from the
+// file found with sointu.FindGmDls. Empty if gm.dls could not be found. Do not
+// modify during runtime; use LoadGmDls instead.
+var GmDlsEntries []GmDlsEntry
+
 // gmDlsEntryMap is a reverse map, to find the index of the GmDlsEntry in the
+// GmDlsEntries
 var gmDlsEntryMap = make(map[vm.SampleOffset]int)
 
 func init() {
+	if path := sointu.FindGmDls(); path != "" {
+		readGmDlsEntries(path) // ignore errors, the sample picker is just empty without gm.dls
+	}
+}
+
+// LoadGmDls loads the gm.dls file from the given path, both to the sample
+// table of the Go synth and to the sample picker. Should be called before any
+// synths are running.
+func LoadGmDls(path string) error {
+	if err := vm.LoadGmDls(path); err != nil {
+		return err
+	}
+	return readGmDlsEntries(path)
+}
+
+func readGmDlsEntries(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open gm.dls: %v", err)
+	}
+	defer f.Close()
+	dls, err := sointu.ReadDLS(f)
+	if err != nil {
+		return fmt.Errorf("could not parse gm.dls: %v", err)
+	}
+	entries := make([]GmDlsEntry, 0, len(dls.Waves))
+	for i, w := range dls.Waves {
+		if w.BitsPerSample != 16 || w.Channels != 1 {
+			continue // the sample oscillator can only play 16-bit mono samples
+		}
+		e := GmDlsEntry{
+			Start:              w.Start,
+			LoopStart:          w.LoopStart,
+			LoopLength:         w.LoopLength,
+			SuggestedTranspose: 60 - w.UnityNote,
+			Name:               w.Name,
+		}
+		if e.LoopLength == 0 { // not looped, so hold the last sample
+			e.LoopStart = w.Length - 1
+			e.LoopLength = 1
+		}
+		if e.Start+e.LoopStart+e.LoopLength > vm.SampleBankStart || e.LoopStart > 65535 || e.LoopLength > 65535 {
+			continue // the sample table and the oscillator parameters cover only the beginning of the original gm.dls
+		}
+		if e.Name == "" {
+			e.Name = fmt.Sprintf("#%v", i)
+		}
+		entries = append(entries, e)
+	}
+	GmDlsEntries = entries
+	clear(gmDlsEntryMap)
 	for i, e := range GmDlsEntries {
 		key := vm.SampleOffset{Start: uint32(e.Start), LoopStart: uint16(e.LoopStart), LoopLength: uint16(e.LoopLength)}
 		gmDlsEntryMap[key] = i
 	}
+	return nil
 }
 
 // gmDlsEntryParameter vtable
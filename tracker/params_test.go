@@ -0,0 +1,37 @@
+package tracker
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vsariola/sointu/internal/rifftest"
+	"github.com/vsariola/sointu/vm"
+)
+
+func TestReadGmDlsEntriesSkipsWavesBeyondSampleTable(t *testing.T) {
+	entries, entryMap := GmDlsEntries, maps.Clone(gmDlsEntryMap)
+	t.Cleanup(func() { // restore the gm.dls found at startup, or none
+		GmDlsEntries = entries
+		clear(gmDlsEntryMap)
+		maps.Copy(gmDlsEntryMap, entryMap)
+	})
+	// only the first 3440660 bytes of gm.dls are loaded into the sample table,
+	// so the waves after the padding cannot be played
+	dls := rifftest.Chunk("RIFF", []byte("DLS "), rifftest.List("wvpl",
+		rifftest.DLSWave("first", 16, 1, nil, make([]byte, 200)),
+		rifftest.Chunk("JUNK", make([]byte, vm.SampleBankStart*2)),
+		rifftest.DLSWave("beyond", 16, 1, nil, make([]byte, 200)),
+	))
+	path := filepath.Join(t.TempDir(), "gm.dls")
+	if err := os.WriteFile(path, dls, 0644); err != nil {
+		t.Fatalf("could not write the DLS file: %v", err)
+	}
+	if err := readGmDlsEntries(path); err != nil {
+		t.Fatalf("readGmDlsEntries failed: %v", err)
+	}
+	if len(GmDlsEntries) != 1 || GmDlsEntries[0].Name != "first" {
+		t.Errorf("expected only the first wave to be listed, got %+v", GmDlsEntries)
+	}
+}
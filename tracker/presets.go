@@ -15,7 +15,6 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-//go:generate go run generate/gmdls_entries.go
 //go:generate go run generate/clean_presets.go
 
 // Preset returns a PresetModel, a view of the model used to manipulate
@@ -168,6 +167,8 @@ func (m *presetResultList) SetSelected(i int) {
 	m.d.Song.Patch[m.d.InstrIndex].Name = newInstr.Name // only copy the relevant fields to preserve the  user defined values e.g. NumVoices and MIDI configuration
 	m.d.Song.Patch[m.d.InstrIndex].Comment = newInstr.Comment
 	m.d.Song.Patch[m.d.InstrIndex].Units = newInstr.Units
+	m.d.Song.Patch[m.d.InstrIndex].Samples = newInstr.Samples
+	m.d.Song.Patch[m.d.InstrIndex].Wavetables = newInstr.Wavetables
 }
 
 // SearchResult returns the search result at the given index in the search
@@ -435,7 +436,7 @@ func instrumentNameToFilename(name string) string {
 func checkNeedsGmDls(instr sointu.Instrument) bool {
 	for _, u := range instr.Units {
 		if u.Type == "oscillator" {
-			if u.Parameters["type"] == sointu.Sample {
+			if u.Parameters["type"] == sointu.Sample && u.Parameters["sample"] == 0 {
 				return true
 			}
 		}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 
 	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm/compiler"
 	"gopkg.in/yaml.v3"
 )
 
@@ -30,6 +31,11 @@ type songFilePath SongModel
 func (v *songFilePath) Value() string              { return v.d.FilePath }
 func (v *songFilePath) SetValue(value string) bool { v.d.FilePath = value; return true }
 
+// PackedSizes returns the estimated packed sizes of the data sections of the
+// current song, when compiled into a player, with the total as the last
+// section. Returns nil if the song cannot be compiled.
+func (m *SongModel) PackedSizes() []compiler.SectionSize { return m.derived.packedSizes }
+
 // BPM returns an Int representing the BPM of the current song.
 func (m *SongModel) BPM() Int { return MakeInt((*songBpm)(m)) }
 
@@ -181,13 +187,15 @@ var defaultUnits = map[string]sointu.Unit{
 	"outaux":     {Type: "outaux", Parameters: map[string]int{"stereo": 1, "outgain": 64, "auxgain": 64}},
 	"aux":        {Type: "aux", Parameters: map[string]int{"stereo": 1, "gain": 64, "channel": 2}},
 	"delay": {Type: "delay",
-		Parameters: map[string]int{"damp": 0, "dry": 128, "feedback": 96, "notetracking": 2, "pregain": 40, "stereo": 0},
+		Parameters: map[string]int{"damp": 0, "dry": 128, "feedback": 96, "notetracking": 2, "interpolation": 0, "pregain": 40, "stereo": 0},
 		VarArgs:    []int{48}},
 	"in":         {Type: "in", Parameters: map[string]int{"stereo": 1, "channel": 2}},
 	"speed":      {Type: "speed", Parameters: map[string]int{}},
-	"compressor": {Type: "compressor", Parameters: map[string]int{"stereo": 0, "attack": 64, "release": 64, "invgain": 64, "threshold": 64, "ratio": 64}},
+	"compressor": {Type: "compressor", Parameters: map[string]int{"stereo": 0, "attack": 64, "release": 64, "invgain": 64, "threshold": 64, "ratio": 64, "sidechain": 0}},
 	"send":       {Type: "send", Parameters: map[string]int{"stereo": 0, "amount": 64, "voice": 0, "unit": 0, "port": 0, "sendpop": 1}},
 	"sync":       {Type: "sync", Parameters: map[string]int{}},
+	"limiter":    {Type: "limiter", Parameters: map[string]int{"stereo": 1, "ceiling": 128, "release": 64, "lookahead": 32}},
+	"width":      {Type: "width", Parameters: map[string]int{"width": 64}},
 	"belleq":     {Type: "belleq", Parameters: map[string]int{"stereo": 0, "frequency": 64, "bandwidth": 64, "gain": 64}},
 }
 
@@ -276,6 +284,9 @@ func (m *SongModel) Read(r io.ReadCloser) {
 		// when the song is loaded from a file, we are quite confident that the file is persisted and thus
 		// we can close sointu without worrying about losing changes
 		m.d.ChangedSinceSave = false
+		if err := m.d.Song.Patch.LoadSamples(filepath.Dir(f.Name())); err != nil {
+			(*Model)(m).Alerts().Add(fmt.Sprintf("Error loading samples: %v", err), Error)
+		}
 	}
 	f()
 	(*SongModel)(m).completeAction(false)
@@ -316,20 +327,58 @@ func (m *SongModel) Write(w io.WriteCloser) {
 	(*SongModel)(m).completeAction(false)
 }
 
-// Export returns an Action to show the wav export dialog.
+// Export returns an Action to show the export dialog, which asks first the
+// file format and then the sample format of the exported audio file.
 func (m *SongModel) Export() Action { return MakeAction((*exportAction)(m)) }
 
 type exportAction SongModel
 
 func (m *exportAction) Do() { m.dialog = Export }
 
+// ExportFileFormat returns an Action to choose the file format of the exported
+// audio file and then to ask its sample format.
+func (m *SongModel) ExportFileFormat(format sointu.AudioFileFormat) Action {
+	return MakeAction(chooseExportFileFormat{Format: format, Model: (*Model)(m)})
+}
+
+type chooseExportFileFormat struct {
+	Format sointu.AudioFileFormat
+	*Model
+}
+
+func (e chooseExportFileFormat) Do() {
+	e.exportFileFormat = e.Format
+	e.dialog = ExportSampleFormatDialog
+}
+
+// ExportSampleFormat returns an Action to choose the sample format of the
+// exported audio file and then to start exporting it. The action is disabled
+// if the chosen file format does not support the sample format.
+func (m *SongModel) ExportSampleFormat(format sointu.SampleFormat) Action {
+	return MakeAction(chooseExportSampleFormat{Format: format, Model: (*Model)(m)})
+}
+
+type chooseExportSampleFormat struct {
+	Format sointu.SampleFormat
+	*Model
+}
+
+func (e chooseExportSampleFormat) Enabled() bool { return e.exportFileFormat.Supports(e.Format) }
+func (e chooseExportSampleFormat) Do() {
+	e.exportSampleFormat = e.Format
+	e.dialog = ExportExplorer
+}
+
 // ExportFloat returns an Action to start exporting the song as a wav file with
 // 32-bit float samples.
 func (m *SongModel) ExportFloat() Action { return MakeAction((*exportFloat)(m)) }
 
 type exportFloat SongModel
 
-func (m *exportFloat) Do() { m.dialog = ExportFloatExplorer }
+func (m *exportFloat) Do() {
+	m.exportFileFormat, m.exportSampleFormat = sointu.WavFile, sointu.SampleFloat32
+	m.dialog = ExportExplorer
+}
 
 // ExportInt16 returns an Action to start exporting the song as a wav file with
 // 16-bit integer samples.
@@ -337,14 +386,52 @@ func (m *SongModel) ExportInt16() Action { return MakeAction((*exportInt16)(m))
 
 type exportInt16 SongModel
 
-func (m *exportInt16) Do() { m.dialog = ExportInt16Explorer }
+func (m *exportInt16) Do() {
+	m.exportFileFormat, m.exportSampleFormat = sointu.WavFile, sointu.SampleInt16
+	m.dialog = ExportExplorer
+}
+
+// ExportFormat returns the file format and the sample format chosen for the
+// exported audio file.
+func (m *SongModel) ExportFormat() (sointu.AudioFileFormat, sointu.SampleFormat) {
+	return m.exportFileFormat, m.exportSampleFormat
+}
+
+// ExportMaxPeak is the highest true peak allowed when normalizing the
+// loudness of an exported song, in dB.
+const ExportMaxPeak Decibel = -1
+
+// NormalizeExport returns a Bool controlling whether the exported audio files
+// are normalized to the loudness given by ExportLoudness.
+func (m *SongModel) NormalizeExport() Bool { return MakeBoolFromPtr(&m.exportNormalize) }
+
+// ExportLoudness returns an Int representing the target integrated loudness
+// of the normalized audio exports, in LUFS.
+func (m *SongModel) ExportLoudness() Int { return MakeInt((*songExportLoudness)(m)) }
 
-// WriteWav renders the song as a wav file and outputs it to the given
-// io.WriteCloser. If the pcm16 is true, the sample format is 16-bit unsigned
-// shorts, otherwise it's 32-bit floats.
-func (m *SongModel) WriteWav(w io.WriteCloser, pcm16 bool) {
+type songExportLoudness SongModel
+
+func (v *songExportLoudness) Value() int { return v.exportLoudness }
+func (v *songExportLoudness) SetValue(value int) bool {
+	v.exportLoudness = value
+	return true
+}
+func (v *songExportLoudness) Range() RangeInclusive { return RangeInclusive{-60, 0} }
+
+// ExportWriteGain returns a Bool controlling whether the gain applied when
+// normalizing an audio export is also written into the song, by scaling the gains
+// of the output units, so that the song plays at the target loudness.
+func (m *SongModel) ExportWriteGain() Bool { return MakeBoolFromPtr(&m.exportWriteGain) }
+
+// WriteAudio renders the song as an audio file, in the file format and sample
+// format given by ExportFormat, and outputs it to the given io.WriteCloser. If
+// NormalizeExport is true, the rendered song is normalized to ExportLoudness,
+// without the true peaks exceeding ExportMaxPeak.
+func (m *SongModel) WriteAudio(w io.WriteCloser) {
 	m.dialog = NoDialog
 	song := m.d.Song.Copy()
+	fileFormat, sampleFormat := m.exportFileFormat, m.exportSampleFormat
+	normalize, target, writeGain := m.exportNormalize, Decibel(m.exportLoudness), m.exportWriteGain
 	go func() {
 		b := make([]byte, 32+2)
 		rand.Read(b)
@@ -358,9 +445,28 @@ func (m *SongModel) WriteWav(w io.WriteCloser, pcm16 bool) {
 			TrySend(m.broker.ToModel, MsgToModel{Data: Alert{Message: txt, Priority: Error, Name: name, Duration: defaultAlertDuration}})
 			return
 		}
-		buffer, err := data.Wav(pcm16)
+		if normalize {
+			gain := NormalizationGain(AnalyzeLoudness(data, KWeighting, true), target, ExportMaxPeak)
+			ApplyGain(data, gain)
+			txt := fmt.Sprintf("Normalized the exported song by %+.1f dB", gain)
+			if writeGain {
+				// not TrySend: the gains are reported as written only if they
+				// really were, so the message must not be dropped
+				txt += " and scaled the output gains to match, rounded to whole steps"
+				select {
+				case m.broker.ToModel <- MsgToModel{Data: func() {
+					(*SongModel)(m).scaleOutputGains(gain)
+					(*Model)(m).Alerts().AddAlert(Alert{Message: txt, Priority: Info, Name: name, Duration: defaultAlertDuration})
+				}}:
+				case <-m.broker.FinishedGUI:
+				}
+			} else {
+				TrySend(m.broker.ToModel, MsgToModel{Data: Alert{Message: txt, Priority: Info, Name: name, Duration: defaultAlertDuration}})
+			}
+		}
+		buffer, err := data.Encode(fileFormat, sampleFormat)
 		if err != nil {
-			txt := fmt.Sprintf("Error converting to .wav: %v", err)
+			txt := fmt.Sprintf("Error converting to %v: %v", fileFormat.Extension(), err)
 			TrySend(m.broker.ToModel, MsgToModel{Data: Alert{Message: txt, Priority: Error, Name: name, Duration: defaultAlertDuration}})
 			return
 		}
@@ -368,3 +474,10 @@ func (m *SongModel) WriteWav(w io.WriteCloser, pcm16 bool) {
 		w.Close()
 	}()
 }
+
+func (m *SongModel) scaleOutputGains(gain Decibel) {
+	defer (*Model)(m).change("ScaleOutputGains", PatchChange, MajorChange)()
+	if !ScaleOutputGains(m.d.Song.Patch, gain) {
+		(*Model)(m).Alerts().Add("Some output gains were already at maximum; the song plays quieter than the exported file", Warning)
+	}
+}
@@ -0,0 +1,228 @@
+package sointu
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tuning maps the notes to pitches, for microtonal music. It is usually read
+// from Scala scale (.scl) and keyboard mapping (.kbm) files. Without a Tuning,
+// the notes are in 12-tone equal temperament.
+type Tuning struct {
+	Name string `yaml:",omitempty"`
+	// Scale contains the scale degrees in cents, excluding the unison. The
+	// last degree is the period of the scale, usually 1200 cents i.e. an
+	// octave. Empty Scale means 12-tone equal temperament.
+	Scale []float64 `yaml:",flow,omitempty"`
+	// Map contains the scale degree of each key, starting from MiddleNote and
+	// repeating every len(Map) keys, with each repetition advancing MapPeriod
+	// degrees. -1 means the key is not mapped and plays in 12-tone equal
+	// temperament. Empty Map maps the keys linearly to the degrees.
+	Map       []int `yaml:",flow,omitempty"`
+	MapPeriod int   `yaml:",omitempty"` // 0 means len(Scale)
+	// MiddleNote is the note where the first degree of the scale is mapped.
+	MiddleNote int
+	// ReferenceNote plays at ReferenceFreq. Without a tuning, note 69 (A-3 in
+	// the tracker) plays at 220 Hz, so ReferenceNote 69 and ReferenceFreq 216
+	// tune the whole scale down to A4 = 432 Hz. Note that a Sointu note sounds
+	// an octave below the MIDI note of the same number, so a .kbm file mapping
+	// note 69 to 440 Hz plays an octave higher than without a tuning.
+	ReferenceNote int
+	ReferenceFreq float64
+}
+
+// note69Freq is the frequency of note 69 without a tuning, in Hz.
+const note69Freq = 220
+
+// DefaultTuning returns the 12-tone equal temperament with A4 (note 81) at
+// 440 Hz.
+func DefaultTuning() Tuning {
+	return Tuning{MiddleNote: 60, ReferenceNote: 69, ReferenceFreq: note69Freq}
+}
+
+// Copy makes a deep copy of a tuning.
+func (t *Tuning) Copy() *Tuning {
+	if t == nil {
+		return nil
+	}
+	ret := *t
+	ret.Scale = append([]float64(nil), t.Scale...)
+	ret.Map = append([]int(nil), t.Map...)
+	return &ret
+}
+
+// NoteTable returns, for each note, the note in 12-tone equal temperament
+// that has the same pitch as the note in this tuning. The values are
+// fractional. For nil tuning, each note maps to itself.
+func (t *Tuning) NoteTable() (ret [128]float32) {
+	for n := range ret {
+		ret[n] = float32(n)
+	}
+	if t == nil {
+		return
+	}
+	refDegree, ok := t.degree(t.ReferenceNote)
+	if !ok {
+		refDegree = t.ReferenceNote - t.MiddleNote
+	}
+	refFreq := t.ReferenceFreq
+	if refFreq <= 0 {
+		refFreq = note69Freq
+	}
+	offset := 69 + 12*math.Log2(refFreq/note69Freq) - t.cents(refDegree)/100
+	for n := range ret {
+		if d, ok := t.degree(n); ok {
+			ret[n] = float32(offset + t.cents(d)/100)
+		}
+	}
+	return
+}
+
+func (t *Tuning) degree(note int) (int, bool) {
+	i := note - t.MiddleNote
+	if len(t.Map) == 0 {
+		return i, true
+	}
+	period := t.MapPeriod
+	if period == 0 {
+		period = len(t.Scale)
+	}
+	if period == 0 {
+		period = 12
+	}
+	m := len(t.Map)
+	octave := floorDiv(i, m)
+	d := t.Map[i-octave*m]
+	if d < 0 {
+		return 0, false
+	}
+	return octave*period + d, true
+}
+
+func (t *Tuning) cents(degree int) float64 {
+	n := len(t.Scale)
+	if n == 0 {
+		return float64(degree) * 100
+	}
+	octave := floorDiv(degree, n)
+	ret := float64(octave) * t.Scale[n-1]
+	if r := degree - octave*n; r > 0 {
+		ret += t.Scale[r-1]
+	}
+	return ret
+}
+
+// ReadScl reads a Scala scale (.scl) file from r into Name and Scale.
+func (t *Tuning) ReadScl(r io.Reader) error {
+	lines, err := scalaLines(r)
+	if err != nil {
+		return err
+	}
+	if len(lines) < 2 {
+		return errors.New("scl: missing description or number of notes")
+	}
+	count, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil || count < 0 {
+		return fmt.Errorf("scl: invalid number of notes %q", lines[1])
+	}
+	if len(lines) < 2+count {
+		return fmt.Errorf("scl: expected %v notes, got %v", count, len(lines)-2)
+	}
+	scale := make([]float64, count)
+	for i, line := range lines[2 : 2+count] {
+		f := firstField(line)
+		if strings.Contains(f, ".") { // cents
+			if scale[i], err = strconv.ParseFloat(f, 64); err != nil {
+				return fmt.Errorf("scl: invalid cents %q", f)
+			}
+			continue
+		}
+		num, den, found := strings.Cut(f, "/")
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := 1.0, error(nil)
+		if found {
+			d, err2 = strconv.ParseFloat(den, 64)
+		}
+		if err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+			return fmt.Errorf("scl: invalid ratio %q", f)
+		}
+		scale[i] = 1200 * math.Log2(n/d)
+	}
+	t.Name = strings.TrimSpace(lines[0])
+	t.Scale = scale
+	return nil
+}
+
+// ReadKbm reads a Scala keyboard mapping (.kbm) file from r into Map,
+// MapPeriod, MiddleNote, ReferenceNote and ReferenceFreq. The range of notes
+// to retune is ignored, all notes are retuned.
+func (t *Tuning) ReadKbm(r io.Reader) error {
+	lines, err := scalaLines(r)
+	if err != nil {
+		return err
+	}
+	if len(lines) < 7 {
+		return errors.New("kbm: missing header lines")
+	}
+	var header [7]float64
+	for i := range header {
+		if header[i], err = strconv.ParseFloat(firstField(lines[i]), 64); err != nil {
+			return fmt.Errorf("kbm: invalid header line %q", lines[i])
+		}
+	}
+	size := int(header[0])
+	if size < 0 || len(lines) < 7+size {
+		return fmt.Errorf("kbm: expected %v mapping entries", size)
+	}
+	m := make([]int, size)
+	for i, line := range lines[7 : 7+size] {
+		f := firstField(line)
+		if f == "x" || f == "X" {
+			m[i] = -1
+			continue
+		}
+		if m[i], err = strconv.Atoi(f); err != nil || m[i] < 0 {
+			return fmt.Errorf("kbm: invalid mapping entry %q", f)
+		}
+	}
+	t.Map = m
+	t.MiddleNote = int(header[3])
+	t.ReferenceNote = int(header[4])
+	t.ReferenceFreq = header[5]
+	t.MapPeriod = int(header[6])
+	return nil
+}
+
+// scalaLines returns the non-comment lines of a Scala file.
+func scalaLines(r io.Reader) ([]string, error) {
+	var ret []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		ret = append(ret, line)
+	}
+	return ret, scanner.Err()
+}
+
+func firstField(s string) string {
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,140 @@
+package sointu_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/vsariola/sointu"
+)
+
+const testScl = `! meantone.scl
+!
+1/4-comma meantone, with a comment line inside
+ 4
+!
+ 193.157
+ 5/4 some text after the ratio
+ 3/2
+ 2
+`
+
+func TestReadScl(t *testing.T) {
+	var tuning sointu.Tuning
+	if err := tuning.ReadScl(strings.NewReader(strings.ReplaceAll(testScl, "\n", "\r\n"))); err != nil {
+		t.Fatalf("ReadScl failed: %v", err)
+	}
+	if tuning.Name != "1/4-comma meantone, with a comment line inside" {
+		t.Errorf("wrong name %q", tuning.Name)
+	}
+	expected := []float64{193.157, 1200 * math.Log2(5.0/4), 1200 * math.Log2(3.0/2), 1200}
+	if len(tuning.Scale) != len(expected) {
+		t.Fatalf("expected %v degrees, got %v", len(expected), tuning.Scale)
+	}
+	for i, e := range expected {
+		if math.Abs(tuning.Scale[i]-e) > 1e-9 {
+			t.Errorf("degree %v: expected %v cents, got %v", i+1, e, tuning.Scale[i])
+		}
+	}
+}
+
+func TestReadSclErrors(t *testing.T) {
+	for _, tc := range []struct{ name, scl string }{
+		{"empty", "! only a comment\n"},
+		{"invalid count", "name\nfour\n"},
+		{"too few notes", "name\n3\n100.0\n2/1\n"},
+		{"invalid ratio", "name\n1\n3/0\n"},
+		{"invalid cents", "name\n1\n1.2.3\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var tuning sointu.Tuning
+			if err := tuning.ReadScl(strings.NewReader(tc.scl)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadKbm(t *testing.T) {
+	kbm := `! a mapping that skips the black keys
+12
+0
+127
+60
+69
+432.0
+7
+! mapping
+0
+x
+1
+x
+2
+3
+x
+4
+x
+5
+x
+6
+`
+	var tuning sointu.Tuning
+	if err := tuning.ReadKbm(strings.NewReader(kbm)); err != nil {
+		t.Fatalf("ReadKbm failed: %v", err)
+	}
+	if tuning.MiddleNote != 60 || tuning.ReferenceNote != 69 || tuning.ReferenceFreq != 432 || tuning.MapPeriod != 7 {
+		t.Errorf("wrong header: %+v", tuning)
+	}
+	expected := []int{0, -1, 1, -1, 2, 3, -1, 4, -1, 5, -1, 6}
+	if len(tuning.Map) != len(expected) {
+		t.Fatalf("expected %v mapping entries, got %v", len(expected), tuning.Map)
+	}
+	for i, e := range expected {
+		if tuning.Map[i] != e {
+			t.Errorf("key %v: expected degree %v, got %v", i, e, tuning.Map[i])
+		}
+	}
+	for _, bad := range []string{"12\n0\n127\n60\n69\n440.0\n", "1\n0\n127\n60\n69\n440.0\n12\n-3\n"} {
+		if err := tuning.ReadKbm(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestNoteTable(t *testing.T) {
+	equal := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-4 }
+	var nilTuning *sointu.Tuning
+	def := sointu.DefaultTuning()
+	for _, table := range [][128]float32{nilTuning.NoteTable(), def.NoteTable()} {
+		for n, v := range table {
+			if !equal(v, float32(n)) {
+				t.Errorf("12-tone equal temperament should map note %v to itself, got %v", n, v)
+			}
+		}
+	}
+	lower := sointu.DefaultTuning()
+	lower.ReferenceFreq = 216
+	if table := lower.NoteTable(); !equal(table[69], float32(69+12*math.Log2(432.0/440))) || !equal(table[81]-table[69], 12) {
+		t.Errorf("A4 = 432 Hz should shift all notes down by the same amount, got %v %v", table[69], table[81])
+	}
+	// note 69 plays at 220 Hz without a tuning, so a tuning playing it at 440 Hz
+	// maps it to note 81
+	absolute := sointu.Tuning{MiddleNote: 60, ReferenceNote: 69, ReferenceFreq: 440}
+	if table := absolute.NoteTable(); !equal(table[69], 81) || !equal(table[60], 72) {
+		t.Errorf("note 69 at 440 Hz should play like note 81 without a tuning, got %v %v", table[69], table[60])
+	}
+	edo19 := sointu.Tuning{Scale: make([]float64, 19), MiddleNote: 60, ReferenceNote: 60, ReferenceFreq: 220 * math.Pow(2, -9.0/12)}
+	for i := range edo19.Scale {
+		edo19.Scale[i] = float64(i+1) * 1200 / 19
+	}
+	if table := edo19.NoteTable(); !equal(table[60], 60) || !equal(table[61], 60+12.0/19) || !equal(table[79], 72) || !equal(table[41], 48) {
+		t.Errorf("19-EDO should have 19 notes per octave, got %v %v %v %v", table[60], table[61], table[79], table[41])
+	}
+	whiteKeys := sointu.Tuning{Scale: []float64{200, 400, 500, 700, 900, 1100, 1200}, Map: []int{0, -1, 1, -1, 2, 3, -1, 4, -1, 5, -1, 6}, MiddleNote: 60, ReferenceNote: 69, ReferenceFreq: 220}
+	table := whiteKeys.NoteTable()
+	for n, e := range map[int]float32{60: 60, 61: 61, 62: 62, 64: 64, 67: 67, 69: 69, 72: 72, 48: 48, 59: 59} {
+		if !equal(table[n], e) {
+			t.Errorf("the white keys of a major scale should play in 12-tone equal temperament: note %v plays %v, expected %v", n, table[n], e)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package vm
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 
 	"github.com/vsariola/sointu"
 )
@@ -33,8 +37,31 @@ type (
 		// a particular sample in the sample data loaded from gm.dls. The sample
 		// offsets are used by the oscillator units that are configured to use
 		// samples. The unit only stores the index pointing to this table.
+		// Offsets at or above SampleBankStart point to SampleBank instead.
 		SampleOffsets []SampleOffset
 
+		// SampleBank contains the data of all the user provided samples used
+		// by the patch, one after another. In the sample offset table, the
+		// sample bank starts right after the gm.dls data i.e. at
+		// SampleBankStart.
+		SampleBank []int16
+
+		// WavetableOffsets is a table telling where to find the frames of a
+		// particular wavetable in WavetableBank. The wavetable oscillators
+		// only store the index pointing to this table.
+		WavetableOffsets []WavetableOffset
+
+		// WavetableBank contains the frames of all the wavetables used by the
+		// patch, one after another, each sointu.WavetableFrameLength samples
+		// long. Only the wavetables used by the patch are included.
+		WavetableBank []int16
+
+		// NoteTable maps the notes to fractional notes in 12-tone equal
+		// temperament, for instruments with a Tuning. Indexed by voice*128 +
+		// note. nil when no instrument has a tuning, in which case the notes
+		// are used as such.
+		NoteTable []float32
+
 		// PolyphonyBitmask is a rather peculiar bitmask used by Sointu VM to store
 		// the information about which voices use which instruments: bit MAXVOICES -
 		// n - 1 corresponds to voice n. If the bit 1, the next voice uses the same
@@ -46,6 +73,10 @@ type (
 
 		// NumVoices is the total number of voices in the patch
 		NumVoices uint32
+
+		// instrumentOperandEnds tells where the operands of each instrument
+		// end in Operands, so that the code can be split between threads
+		instrumentOperandEnds []int
 	}
 
 	// SampleOffset is an entry in the sample offset table
@@ -54,10 +85,23 @@ type (
 		LoopStart  uint16 // loop start offset in words, relative to Start
 		LoopLength uint16 // loop length in words
 	}
+
+	// WavetableOffset is an entry in the wavetable offset table
+	WavetableOffset struct {
+		Start     uint16 // index of the first frame of the wavetable in the wavetable bank
+		NumFrames uint16 // number of frames in the wavetable
+	}
 )
 
+// SampleBankStart is the offset (in words) of the first user provided sample in
+// the sample offset table: user samples are addressed as if they were stored
+// right after the gm.dls sample data.
+const SampleBankStart = 1720330
+
 type bytecodeBuilder struct {
 	sampleOffsetMap map[SampleOffset]int
+	sampleBankMap   map[[2]int]int
+	wavetableMap    map[[2]int]int
 	globalAddrs     map[int]uint16
 	globalFixups    map[int]([]int)
 	localAddrs      map[int]uint16
@@ -65,6 +109,7 @@ type bytecodeBuilder struct {
 	voiceNo         int
 	delayIndices    [][]int
 	unitNo          int
+	bpm             int
 	Bytecode
 }
 
@@ -93,11 +138,25 @@ func NewBytecode(patch sointu.Patch, featureSet FeatureSet, bpm int) (*Bytecode,
 			case "oscillator":
 				color := p["color"]
 				if unit.Parameters["type"] == 4 {
-					color = b.getSampleIndex(unit)
+					var err error
+					color, err = b.getSampleIndex(instrIndex, instr, unit)
+					if err != nil {
+						return nil, err
+					}
 					if color > 255 {
 						return nil, errors.New("Patch uses over 256 samples")
 					}
 				}
+				if unit.Parameters["type"] == sointu.Wavetable {
+					var err error
+					color, err = b.getWavetableIndex(instrIndex, instr, unit)
+					if err != nil {
+						return nil, err
+					}
+					if color > 255 {
+						return nil, errors.New("Patch uses over 256 wavetables")
+					}
+				}
 				flags := 0
 				switch p["type"] {
 				case sointu.Sine:
@@ -110,6 +169,8 @@ func NewBytecode(patch sointu.Patch, featureSet FeatureSet, bpm int) (*Bytecode,
 					flags = 0x04
 				case sointu.Sample:
 					flags = 0x80
+				case sointu.Wavetable:
+					flags = 0x00 // wavetable is the only type without a type bit
 				}
 				if p["lfo"] == 1 {
 					flags += 0x08
@@ -117,6 +178,20 @@ func NewBytecode(patch sointu.Patch, featureSet FeatureSet, bpm int) (*Bytecode,
 				flags += p["unison"]
 				b.op(opcode + p["stereo"])
 				b.operand(p["transpose"], p["detune"], p["phase"], color, p["shape"], p["gain"], flags)
+				if featureSet.SupportsParamValueOtherThan("oscillator", "rate", 0) {
+					// tempo synced LFOs store their frequency (cycles per sample)
+					// as a float operand; 0 means free running and negative
+					// means that the phase is locked to the song time instead
+					// of being reset on note trigger
+					var omega float32
+					if r := p["rate"]; p["lfo"] == 1 && r > 0 && r < len(sointu.LFORateTicks) {
+						omega = float32(48*b.bpm) / float32(44100*60*sointu.LFORateTicks[r])
+						if p["reset"] == 1 {
+							omega = -omega
+						}
+					}
+					b.floatOperand(omega)
+				}
 			case "delay":
 				count := len(unit.VarArgs)
 				if unit.Parameters["stereo"] == 1 {
@@ -128,6 +203,9 @@ func NewBytecode(patch sointu.Patch, featureSet FeatureSet, bpm int) (*Bytecode,
 				countTrack := count*2 - 1 + (unit.Parameters["notetracking"] & 1) // 1 means no note tracking and 1 delay, 2 means notetracking with 1 delay, 3 means no note tracking and 2 delays etc.
 				b.op(opcode + p["stereo"])
 				b.defOperands(unit)
+				if featureSet.SupportsParamValue("delay", "interpolation", 1) {
+					b.operand(p["interpolation"])
+				}
 				b.operand(b.delayIndices[instrIndex][unitIndex], countTrack)
 			case "aux", "in":
 				b.op(opcode + p["stereo"])
@@ -153,6 +231,16 @@ func NewBytecode(patch sointu.Patch, featureSet FeatureSet, bpm int) (*Bytecode,
 				b.op(opcode + p["stereo"])
 				b.defOperands(unit)
 				b.operand(flags)
+			case "limiter":
+				b.op(opcode + p["stereo"])
+				b.defOperands(unit)
+				b.operand(p["lookahead"])
+			case "compressor":
+				b.op(opcode + p["stereo"])
+				b.defOperands(unit)
+				if featureSet.SupportsParamValue("compressor", "sidechain", 1) {
+					b.operand(p["sidechain"])
+				}
 			case "send":
 				targetID := unit.Parameters["target"]
 				targetInstrIndex, _, err := patch.FindUnit(targetID)
@@ -212,6 +300,27 @@ func NewBytecode(patch sointu.Patch, featureSet FeatureSet, bpm int) (*Bytecode,
 	return &b.Bytecode, nil
 }
 
+// ThreadCode returns the opcodes and operands executed by the given thread of
+// a multithreaded player. The instruments are split between the threads like
+// in MultithreadSynth, but the instruments not rendered by the thread still
+// get their end markers, so that the voices have the same positions in all
+// threads and the notes can be triggered the same way as in a single thread.
+func (b *Bytecode) ThreadCode(patch sointu.Patch, thread int) (opcodes []byte, operands []byte) {
+	opcodeStart, operandStart := 0, 0
+	for i, instr := range patch {
+		opcodeEnd := opcodeStart + bytes.IndexByte(b.Opcodes[opcodeStart:], 0) + 1
+		operandEnd := b.instrumentOperandEnds[i]
+		if (instr.ThreadMaskM1+1)&(1<<thread) != 0 {
+			opcodes = append(opcodes, b.Opcodes[opcodeStart:opcodeEnd]...)
+			operands = append(operands, b.Operands[operandStart:operandEnd]...)
+		} else {
+			opcodes = append(opcodes, 0)
+		}
+		opcodeStart, operandStart = opcodeEnd, operandEnd
+	}
+	return
+}
+
 func newBytecodeBuilder(patch sointu.Patch, bpm int) *bytecodeBuilder {
 	var polyphonyBitmask uint32 = 0
 	for _, instr := range patch {
@@ -226,16 +335,35 @@ func newBytecodeBuilder(patch sointu.Patch, bpm int) *bytecodeBuilder {
 		delayTimesU16[i] = uint16(d)
 	}
 	c := bytecodeBuilder{
-		Bytecode:        Bytecode{PolyphonyBitmask: polyphonyBitmask, NumVoices: uint32(patch.NumVoices()), DelayTimes: delayTimesU16},
+		Bytecode:        Bytecode{PolyphonyBitmask: polyphonyBitmask, NumVoices: uint32(patch.NumVoices()), DelayTimes: delayTimesU16, NoteTable: constructNoteTable(patch)},
 		sampleOffsetMap: map[SampleOffset]int{},
+		sampleBankMap:   map[[2]int]int{},
+		wavetableMap:    map[[2]int]int{},
 		globalAddrs:     map[int]uint16{},
 		globalFixups:    map[int]([]int){},
 		localAddrs:      map[int]uint16{},
 		localFixups:     map[int]([]int){},
-		delayIndices:    delayIndices}
+		delayIndices:    delayIndices,
+		bpm:             bpm}
 	return &c
 }
 
+// constructNoteTable returns the note tables of all voices one after another,
+// or nil if none of the instruments has a tuning
+func constructNoteTable(patch sointu.Patch) []float32 {
+	if !slices.ContainsFunc(patch, func(instr sointu.Instrument) bool { return instr.Tuning != nil }) {
+		return nil
+	}
+	ret := make([]float32, 0, patch.NumVoices()*128)
+	for _, instr := range patch {
+		table := instr.Tuning.NoteTable()
+		for range instr.NumVoices {
+			ret = append(ret, table[:]...)
+		}
+	}
+	return ret
+}
+
 // op adds a command to the bytecode, and increments the unit number
 func (b *bytecodeBuilder) op(opcode int) {
 	b.Opcodes = append(b.Opcodes, byte(opcode))
@@ -246,6 +374,7 @@ func (b *bytecodeBuilder) op(opcode int) {
 // local addresses are forgotten when instrument ends
 func (b *bytecodeBuilder) opFinish(instr sointu.Instrument) {
 	b.Opcodes = append(b.Opcodes, 0)
+	b.instrumentOperandEnds = append(b.instrumentOperandEnds, len(b.Operands))
 	b.unitNo = 0
 	b.voiceNo += instr.NumVoices
 	b.localAddrs = map[int]uint16{}
@@ -259,6 +388,12 @@ func (b *bytecodeBuilder) operand(operands ...int) {
 	}
 }
 
+// floatOperand appends a float32 to the operand stream, in little endian byte
+// order
+func (b *bytecodeBuilder) floatOperand(value float32) {
+	b.Operands = binary.LittleEndian.AppendUint32(b.Operands, math.Float32bits(value))
+}
+
 // defOperands appends the operands to the stream for all parameters that can be
 // modulated and set
 func (b *bytecodeBuilder) defOperands(unit sointu.Unit) {
@@ -312,8 +447,25 @@ func (b *bytecodeBuilder) fixUp(positions []int, delta uint16) {
 }
 
 // getSampleIndex returns the index of the sample in the sample offset table; if the sample has not been seen yet, it is added to the table
-func (b *bytecodeBuilder) getSampleIndex(unit sointu.Unit) int {
-	s := SampleOffset{Start: uint32(unit.Parameters["samplestart"]), LoopStart: uint16(unit.Parameters["loopstart"]), LoopLength: uint16(unit.Parameters["looplength"])}
+func (b *bytecodeBuilder) getSampleIndex(instrIndex int, instr sointu.Instrument, unit sointu.Unit) (int, error) {
+	start := unit.Parameters["samplestart"]
+	if n := unit.Parameters["sample"]; n > 0 {
+		if n > len(instr.Samples) {
+			return 0, fmt.Errorf("instrument %v has no sample %v", instrIndex, n)
+		}
+		sample := instr.Samples[n-1]
+		if len(sample.Data) == 0 {
+			return 0, fmt.Errorf("sample %v of instrument %v has no data", n, instrIndex)
+		}
+		bankOffset, ok := b.sampleBankMap[[2]int{instrIndex, n}]
+		if !ok {
+			bankOffset = len(b.SampleBank)
+			b.sampleBankMap[[2]int{instrIndex, n}] = bankOffset
+			b.SampleBank = append(b.SampleBank, sample.Data...)
+		}
+		start = SampleBankStart + bankOffset + min(start, len(sample.Data)-1)
+	}
+	s := SampleOffset{Start: uint32(start), LoopStart: uint16(unit.Parameters["loopstart"]), LoopLength: uint16(unit.Parameters["looplength"])}
 	if s.LoopLength == 0 {
 		// hacky quick fix: looplength 0 causes div by zero so avoid crashing
 		s.LoopLength = 1
@@ -324,5 +476,31 @@ func (b *bytecodeBuilder) getSampleIndex(unit sointu.Unit) int {
 		b.sampleOffsetMap[s] = index
 		b.SampleOffsets = append(b.SampleOffsets, s)
 	}
-	return index
+	return index, nil
+}
+
+// getWavetableIndex returns the index of the wavetable in the wavetable offset
+// table; if the wavetable has not been seen yet, its frames are added to the
+// wavetable bank
+func (b *bytecodeBuilder) getWavetableIndex(instrIndex int, instr sointu.Instrument, unit sointu.Unit) (int, error) {
+	n := unit.Parameters["wavetable"]
+	w, ok := instr.Wavetable(n)
+	if !ok {
+		return 0, fmt.Errorf("instrument %v has no wavetable %v", instrIndex, n)
+	}
+	if w.NumFrames() == 0 {
+		return 0, fmt.Errorf("wavetable %v of instrument %v has no frames", n, instrIndex)
+	}
+	key := [2]int{instrIndex, n}
+	if n < sointu.NumBuiltinWavetables {
+		key[0] = -1 // built-in wavetables are shared by all instruments
+	}
+	index, ok := b.wavetableMap[key]
+	if !ok {
+		index = len(b.WavetableOffsets)
+		b.wavetableMap[key] = index
+		b.WavetableOffsets = append(b.WavetableOffsets, WavetableOffset{Start: uint16(len(b.WavetableBank) / sointu.WavetableFrameLength), NumFrames: uint16(w.NumFrames())})
+		b.WavetableBank = append(b.WavetableBank, w.Data[:w.NumFrames()*sointu.WavetableFrameLength]...)
+	}
+	return index, nil
 }
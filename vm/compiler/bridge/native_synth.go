@@ -2,6 +2,8 @@ package bridge
 
 // #cgo CFLAGS: -I"${SRCDIR}/../../../build/"
 // #cgo LDFLAGS: "${SRCDIR}/../../../build/libsointu.a"
+// #include <stdlib.h>
+// #include <string.h>
 // #include <sointu.h>
 import "C"
 import (
@@ -9,6 +11,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unsafe"
 
 	"github.com/vsariola/sointu"
 	"github.com/vsariola/sointu/vm"
@@ -20,6 +23,9 @@ type NativeSynther struct {
 type NativeSynth struct {
 	csynth  C.Synth
 	cpuLoad sointu.CPULoad
+	// capacities of the arrays of csynth, which are allocated from C memory,
+	// as cgo does not allow passing Go memory with Go pointers to C
+	numDelayLines, numDelayTimes, numSampleOffsets, numOpcodes, numOperands int
 }
 
 func (s NativeSynther) Name() string                 { return "Native" }
@@ -31,48 +37,25 @@ func (s NativeSynther) Synth(patch sointu.Patch, bpm int) (sointu.Synth, error)
 }
 
 func Synth(patch sointu.Patch, bpm int) (*NativeSynth, error) {
-	s := new(C.Synth)
-	if n := patch.NumDelayLines(); n > 128 {
-		return nil, fmt.Errorf("native bridge has currently a hard limit of 128 delaylines; patch uses %v", n)
+	s := new(NativeSynth)
+	s.csynth.RandSeed = 1
+	if err := s.Update(patch, bpm); err != nil {
+		s.Close()
+		return nil, err
 	}
-	comPatch, err := vm.NewBytecode(patch, vm.AllFeatures{}, bpm)
-	if err != nil {
-		return nil, fmt.Errorf("error compiling patch: %v", err)
-	}
-	if len(comPatch.Opcodes) > 2048 { // TODO: 2048 could probably be pulled automatically from cgo
-		return nil, errors.New("bridge supports at most 2048 opcodes; the compiled patch has more")
-	}
-	if len(comPatch.Operands) > 16384 { // TODO: 16384 could probably be pulled automatically from cgo
-		return nil, errors.New("bridge supports at most 16384 operands; the compiled patch has more")
-	}
-	// if the patch is empty, we still need to initialize the synth with a single opcode
-	if len(comPatch.Opcodes) == 0 {
-		s.Opcodes[0] = 0
-		s.NumVoices = 1
-		s.Polyphony = 0
-		return &NativeSynth{csynth: *s}, nil
-	}
-	for i, v := range comPatch.Opcodes {
-		s.Opcodes[i] = (C.uchar)(v)
-	}
-	for i, v := range comPatch.Operands {
-		s.Operands[i] = (C.uchar)(v)
-	}
-	for i, v := range comPatch.DelayTimes {
-		s.DelayTimes[i] = (C.ushort)(v)
-	}
-	for i, v := range comPatch.SampleOffsets {
-		s.SampleOffsets[i].Start = (C.uint)(v.Start)
-		s.SampleOffsets[i].LoopStart = (C.ushort)(v.LoopStart)
-		s.SampleOffsets[i].LoopLength = (C.ushort)(v.LoopLength)
-	}
-	s.NumVoices = C.uint(comPatch.NumVoices)
-	s.Polyphony = C.uint(comPatch.PolyphonyBitmask)
-	s.RandSeed = 1
-	return &NativeSynth{csynth: *s}, nil
+	return s, nil
 }
 
-func (s *NativeSynth) Close() {}
+// Close frees the arrays of the synth, which were allocated from C memory.
+func (s *NativeSynth) Close() {
+	C.free(unsafe.Pointer(s.csynth.DelayWrks))
+	C.free(unsafe.Pointer(s.csynth.DelayTimes))
+	C.free(unsafe.Pointer(s.csynth.SampleOffsets))
+	C.free(unsafe.Pointer(s.csynth.Opcodes))
+	C.free(unsafe.Pointer(s.csynth.Operands))
+	s.csynth.DelayWrks, s.csynth.DelayTimes, s.csynth.SampleOffsets, s.csynth.Opcodes, s.csynth.Operands = nil, nil, nil, nil, nil
+	s.numDelayLines, s.numDelayTimes, s.numSampleOffsets, s.numOpcodes, s.numOperands = 0, 0, 0, 0, 0
+}
 
 func (s *NativeSynth) CPULoad(loads []sointu.CPULoad) int {
 	if len(loads) < 1 {
@@ -82,6 +65,23 @@ func (s *NativeSynth) CPULoad(loads []sointu.CPULoad) int {
 	return 1
 }
 
+// reserve grows the C allocated array *ptr to have room for at least n
+// elements, keeping the old elements and zeroing the new ones, and returns the
+// first n elements as a slice. If the memory cannot be allocated, the array is
+// left as it was.
+func reserve[T any](ptr **T, capacity *int, n int) ([]T, error) {
+	if n > *capacity {
+		size := int(unsafe.Sizeof(*new(T)))
+		p := C.realloc(unsafe.Pointer(*ptr), C.size_t(n*size))
+		if p == nil {
+			return nil, fmt.Errorf("bridge could not allocate memory for %v elements of %v bytes", n, size)
+		}
+		C.memset(unsafe.Add(p, *capacity*size), 0, C.size_t((n-*capacity)*size))
+		*ptr, *capacity = (*T)(p), n
+	}
+	return unsafe.Slice(*ptr, n), nil
+}
+
 // Render renders until the buffer is full or the modulated time is reached, whichever
 // happens first.
 // Parameters:
@@ -143,43 +143,67 @@ func (bridgesynth *NativeSynth) Release(voice int) {
 // Update
 func (bridgesynth *NativeSynth) Update(patch sointu.Patch, bpm int) error {
 	s := &bridgesynth.csynth
-	if n := patch.NumDelayLines(); n > 128 {
-		return fmt.Errorf("native bridge has currently a hard limit of 128 delaylines; patch uses %v", n)
-	}
 	comPatch, err := vm.NewBytecode(patch, vm.AllFeatures{}, bpm)
 	if err != nil {
 		return fmt.Errorf("error compiling patch: %v", err)
 	}
-	if len(comPatch.Opcodes) > 2048 { // TODO: 2048 could probably be pulled automatically from cgo
-		return errors.New("bridge supports at most 2048 opcodes; the compiled patch has more")
+	if len(comPatch.SampleBank) > 0 {
+		return errors.New("bridge does not support user samples yet; use the Go synth instead")
+	}
+	if len(comPatch.WavetableBank) > 0 {
+		return errors.New("bridge does not support wavetables yet; use the Go synth instead")
+	}
+	if comPatch.NoteTable != nil {
+		return errors.New("bridge does not support tunings yet; use the Go synth instead")
 	}
-	if len(comPatch.Operands) > 16384 { // TODO: 16384 could probably be pulled automatically from cgo
-		return errors.New("bridge supports at most 16384 operands; the compiled patch has more")
+	// the arrays only grow, so the delay lines keep ringing when the patch changes
+	if _, err := reserve(&s.DelayWrks, &bridgesynth.numDelayLines, patch.NumDelayLines()); err != nil {
+		return err
 	}
 	// if the patch is empty, we still need to initialize the synth with a single opcode
 	if len(comPatch.Opcodes) == 0 {
-		s.Opcodes[0] = 0
+		opcodes, err := reserve(&s.Opcodes, &bridgesynth.numOpcodes, 1)
+		if err != nil {
+			return err
+		}
+		opcodes[0] = 0
 		s.NumVoices = 1
 		s.Polyphony = 0
 		return nil
 	}
 	needsRefresh := false
+	opcodes, err := reserve(&s.Opcodes, &bridgesynth.numOpcodes, len(comPatch.Opcodes))
+	if err != nil {
+		return err
+	}
 	for i, v := range comPatch.Opcodes {
-		if cmdChar := (C.uchar)(v); s.Opcodes[i] != cmdChar {
-			s.Opcodes[i] = cmdChar
+		if cmdChar := (C.uchar)(v); opcodes[i] != cmdChar {
+			opcodes[i] = cmdChar
 			needsRefresh = true // if any of the opcodes change, we retrigger all units
 		}
 	}
+	operands, err := reserve(&s.Operands, &bridgesynth.numOperands, len(comPatch.Operands))
+	if err != nil {
+		return err
+	}
 	for i, v := range comPatch.Operands {
-		s.Operands[i] = (C.uchar)(v)
+		operands[i] = (C.uchar)(v)
+	}
+	delayTimes, err := reserve(&s.DelayTimes, &bridgesynth.numDelayTimes, len(comPatch.DelayTimes))
+	if err != nil {
+		return err
 	}
 	for i, v := range comPatch.DelayTimes {
-		s.DelayTimes[i] = (C.ushort)(v)
+		delayTimes[i] = (C.ushort)(v)
+	}
+	sampleOffsets, err := reserve(&s.SampleOffsets, &bridgesynth.numSampleOffsets, len(comPatch.SampleOffsets))
+	if err != nil {
+		return err
 	}
 	for i, v := range comPatch.SampleOffsets {
-		s.SampleOffsets[i].Start = (C.uint)(v.Start)
-		s.SampleOffsets[i].LoopStart = (C.ushort)(v.LoopStart)
-		s.SampleOffsets[i].LoopLength = (C.ushort)(v.LoopLength)
+		sampleOffsets[i].Start = (C.uint)(v.Start)
+		sampleOffsets[i].LoopStart = (C.ushort)(v.LoopStart)
+		sampleOffsets[i].LoopLength = (C.ushort)(v.LoopLength)
 	}
 	s.NumVoices = C.uint(comPatch.NumVoices)
 	s.Polyphony = C.uint(comPatch.PolyphonyBitmask)
@@ -101,6 +101,48 @@ func TestRenderSamples(t *testing.T) {
 	compareToRawFloat32(t, buffer, "test_render_samples.raw")
 }
 
+func TestManyDelayLines(t *testing.T) {
+	delayTimes := make([]int, 200) // more delay lines than the native synth used to have room for
+	for i := range delayTimes {
+		delayTimes[i] = 100 + i*10
+	}
+	patch := sointu.Patch{sointu.Instrument{NumVoices: 1, Units: []sointu.Unit{
+		{Type: "envelope", Parameters: map[string]int{"stereo": 0, "attack": 32, "decay": 32, "sustain": 64, "release": 64, "gain": 128}},
+		{Type: "oscillator", Parameters: map[string]int{"stereo": 0, "transpose": 64, "detune": 64, "phase": 0, "color": 96, "shape": 64, "gain": 128, "type": sointu.Sine, "lfo": 0, "unison": 0}},
+		{Type: "mulp", Parameters: map[string]int{"stereo": 0}},
+		{Type: "delay", Parameters: map[string]int{"stereo": 0, "pregain": 40, "dry": 128, "feedback": 96, "damp": 64, "notetracking": 0}, VarArgs: delayTimes},
+		{Type: "pan", Parameters: map[string]int{"stereo": 0, "panning": 64}},
+		{Type: "out", Parameters: map[string]int{"stereo": 1, "gain": 128}},
+	}}}
+	synth, err := bridge.Synth(patch[:0], 120)
+	if err != nil {
+		t.Fatalf("bridge compile error: %v", err)
+	}
+	defer synth.Close()
+	if err := synth.Update(patch, 120); err != nil {
+		t.Fatalf("updating to a patch with %v delay lines failed: %v", len(delayTimes), err)
+	}
+	synth.Trigger(0, 64)
+	buffer := make(sointu.AudioBuffer, su_max_samples)
+	if err := buffer.Fill(synth); err != nil {
+		t.Fatalf("render gave an error: %v", err)
+	}
+	for _, frame := range buffer[len(buffer)/2:] {
+		if frame[0] != 0 {
+			return
+		}
+	}
+	t.Fatalf("the delays should still be ringing at the end of the buffer")
+}
+
+// unsupportedByBridge lists the regression tests using features that the
+// native bridge rejects by design: user samples, wavetables and tunings.
+var unsupportedByBridge = map[string]bool{
+	"test_oscillat_userwav":   true,
+	"test_oscillat_wavetable": true,
+	"test_tuning":             true,
+}
+
 func TestAllRegressionTests(t *testing.T) {
 	_, myname, _, _ := runtime.Caller(0)
 	files, err := filepath.Glob(path.Join(path.Dir(myname), "..", "..", "..", "tests", "*.yml"))
@@ -125,10 +167,13 @@ func TestAllRegressionTests(t *testing.T) {
 				t.Fatalf("could not parse the .yml file: %v", err)
 			}
 			buffer, err := sointu.Play(bridge.NativeSynther{}, song, nil)
-			buffer = buffer[:song.Score.LengthInRows()*song.SamplesPerRow()] // extend to the nominal length always.
+			if err != nil && unsupportedByBridge[testname] {
+				t.Skipf("Native bridge does not support the song: %v", err)
+			}
 			if err != nil {
 				t.Fatalf("Play failed: %v", err)
 			}
+			buffer = buffer[:song.Score.LengthInRows()*song.SamplesPerRow()] // extend to the nominal length always.
 			if os.Getenv("SOINTU_TEST_SAVE_OUTPUT") == "YES" {
 				outputpath := path.Join(path.Dir(myname), "actual_output")
 				if _, err := os.Stat(outputpath); os.IsNotExist(err) {
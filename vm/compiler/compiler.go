@@ -3,8 +3,12 @@ package compiler
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
+	"go/format"
 	"path/filepath"
+	"slices"
+	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
@@ -18,20 +22,20 @@ type Compiler struct {
 	Arch        string
 	Output16Bit bool
 	RowSync     bool
+	GoPackage   string // package name of the generated Go player; "song" if empty
+	// TransposePatterns enables reusing the transposed patterns, see
+	// TransposePatterns. Songs where it saves nothing compile identically.
+	TransposePatterns bool
 }
 
-//go:embed templates/amd64-386/* templates/wasm/*
+//go:embed templates/amd64-386/* templates/wasm/* templates/c/* templates/go/*
 var templateFS embed.FS
 
 // New returns a new compiler using the default .asm templates
 func New(os string, arch string, output16Bit bool, rowsync bool) (*Compiler, error) {
-	var subdir string
-	if arch == "386" || arch == "amd64" {
-		subdir = "amd64-386"
-	} else if arch == "wasm" {
-		subdir = "wasm"
-	} else {
-		return nil, fmt.Errorf("compiler.New failed, because only amd64, 386 and wasm archs are supported (targeted architecture was %v)", arch)
+	subdir, err := templateSubdir(arch)
+	if err != nil {
+		return nil, fmt.Errorf("compiler.New failed, because %v", err)
 	}
 	tmpl, err := template.New("base").Funcs(sprig.TxtFuncMap()).ParseFS(templateFS, "templates/"+subdir+"/*.*")
 	if err != nil {
@@ -50,23 +54,47 @@ func NewFromTemplates(os string, arch string, output16Bit bool, rowsync bool, te
 }
 
 func (com *Compiler) Library() (map[string]string, error) {
-	if com.Arch != "386" && com.Arch != "amd64" {
-		return nil, fmt.Errorf(`compiling as a library is supported only on 386 and amd64 architectures (targeted architecture was %v)`, com.Arch)
+	if com.Arch != "386" && com.Arch != "amd64" && com.Arch != "wasm" {
+		return nil, fmt.Errorf(`compiling as a library is supported only on 386, amd64 and wasm architectures (targeted architecture was %v)`, com.Arch)
+	}
+	if com.Arch == "wasm" && com.Output16Bit {
+		return nil, errors.New(`the wasm library always outputs floats; 16-bit output is not supported`)
+	}
+	if err := com.checkTemplates(true); err != nil {
+		return nil, err
 	}
-	templates := []string{"library.asm", "library.h"}
+	templates := libraryTemplates(com.Arch)
 	features := vm.AllFeatures{}
 	retmap := map[string]string{}
 	for _, templateName := range templates {
 		compilerMacros := *NewCompilerMacros(*com)
 		compilerMacros.Library = true
 		featureSetMacros := FeatureSetMacros{features}
-		x86Macros := *NewX86Macros(com.OS, com.Arch == "amd64", features, false)
-		data := struct {
-			CompilerMacros
-			FeatureSetMacros
-			X86Macros
-		}{compilerMacros, featureSetMacros, x86Macros}
-		populatedTemplate, extension, err := com.compile(templateName, &data)
+		var populatedTemplate, extension string
+		var err error
+		if com.Arch == "wasm" {
+			wasmMacros := *NewWasmMacros()
+			data := struct {
+				CompilerMacros
+				FeatureSetMacros
+				WasmMacros
+				NoteTable        []float32
+				WavetableOffsets []vm.WavetableOffset
+			}{compilerMacros, featureSetMacros, wasmMacros, nil, nil}
+			populatedTemplate, extension, err = com.compile(templateName, &data)
+		} else {
+			x86Macros := *NewX86Macros(com.OS, com.Arch == "amd64", features, false)
+			data := struct {
+				CompilerMacros
+				FeatureSetMacros
+				X86Macros
+				UsesGmDls        bool
+				SampleBank       []int16
+				NoteTable        []float32
+				WavetableOffsets []vm.WavetableOffset
+			}{compilerMacros, featureSetMacros, x86Macros, true, nil, nil, nil}
+			populatedTemplate, extension, err = com.compile(templateName, &data)
+		}
 		if err != nil {
 			return nil, fmt.Errorf(`could not execute template "%v": %v`, templateName, err)
 		}
@@ -76,25 +104,56 @@ func (com *Compiler) Library() (map[string]string, error) {
 }
 
 func (com *Compiler) Song(song *sointu.Song) (map[string]string, error) {
-	if com.Arch != "386" && com.Arch != "amd64" && com.Arch != "wasm" {
-		return nil, fmt.Errorf(`compiling a song player is supported only on 386, amd64 and wasm architectures (targeted architecture was %v)`, com.Arch)
+	if com.Arch != "386" && com.Arch != "amd64" && com.Arch != "wasm" && com.Arch != "c" && com.Arch != "go" {
+		return nil, fmt.Errorf(`compiling a song player is supported only on 386, amd64, wasm, c and go architectures (targeted architecture was %v)`, com.Arch)
 	}
-	var templates []string
-	if com.Arch == "386" || com.Arch == "amd64" {
-		templates = []string{"player.asm", "player.h", "player.inc"}
-	} else if com.Arch == "wasm" {
-		templates = []string{"player.wat"}
+	if err := com.checkTemplates(false); err != nil {
+		return nil, err
 	}
+	templates := songTemplates(com.Arch)
 	features := vm.NecessaryFeaturesFor(song.Patch)
 	retmap := map[string]string{}
 	encodedPatch, err := vm.NewBytecode(song.Patch, features, song.BPM)
 	if err != nil {
 		return nil, fmt.Errorf(`could not encode patch: %v`, err)
 	}
+	usesGmDls := false
+	for _, o := range encodedPatch.SampleOffsets {
+		if o.Start < vm.SampleBankStart {
+			usesGmDls = true
+		}
+	}
+	if !usesGmDls && len(encodedPatch.SampleBank) > 0 {
+		// gm.dls is not needed, so the user samples can form the whole sample
+		// table of the player
+		offsets := make([]vm.SampleOffset, len(encodedPatch.SampleOffsets))
+		for i, o := range encodedPatch.SampleOffsets {
+			o.Start -= vm.SampleBankStart
+			offsets[i] = o
+		}
+		encodedPatch.SampleOffsets = offsets
+	}
+	if t := encodedPatch.NoteTable; len(t) > 128 {
+		// the players have only one note table for all voices
+		for i := 128; i < len(t); i += 128 {
+			if !slices.Equal(t[:128], t[i:i+128]) {
+				return nil, errors.New(`compiled players support only one tuning for the whole song; use the same tuning for all instruments`)
+			}
+		}
+		encodedPatch.NoteTable = t[:128]
+	}
+	var threads []Thread
+	if com.Arch == "386" || com.Arch == "amd64" {
+		threads = splitThreads(song, encodedPatch, com.RowSync || slices.Contains(features.Instructions(), "sync"))
+	}
 	patterns, sequences, err := ConstructPatterns(song)
 	if err != nil {
 		return nil, fmt.Errorf(`could not encode song: %v`, err)
 	}
+	var transpositions [][]int8
+	if com.TransposePatterns {
+		patterns, sequences, transpositions, _ = TransposePatterns(patterns, sequences)
+	}
 	for _, templateName := range templates {
 		compilerMacros := *NewCompilerMacros(*com)
 		featureSetMacros := FeatureSetMacros{features}
@@ -111,10 +170,13 @@ func (com *Compiler) Song(song *sointu.Song) (map[string]string, error) {
 				*vm.Bytecode
 				Patterns       [][]byte
 				Sequences      [][]byte
+				Transpositions [][]int8
 				PatternLength  int
 				SequenceLength int
 				Hold           int
-			}{compilerMacros, featureSetMacros, x86Macros, songMacros, encodedPatch, patterns, sequences, len(patterns[0]), len(sequences[0]), 1}
+				UsesGmDls      bool
+				Threads        []Thread
+			}{compilerMacros, featureSetMacros, x86Macros, songMacros, encodedPatch, patterns, sequences, transpositions, len(patterns[0]), len(sequences[0]), 1, usesGmDls, threads}
 			populatedTemplate, extension, err = com.compile(templateName, &data)
 		} else if com.Arch == "wasm" {
 			wasmMacros := *NewWasmMacros()
@@ -126,11 +188,54 @@ func (com *Compiler) Song(song *sointu.Song) (map[string]string, error) {
 				*vm.Bytecode
 				Patterns       [][]byte
 				Sequences      [][]byte
+				Transpositions [][]int8
 				PatternLength  int
 				SequenceLength int
 				Hold           int
-			}{compilerMacros, featureSetMacros, wasmMacros, songMacros, encodedPatch, patterns, sequences, len(patterns[0]), len(sequences[0]), 1}
+				UsesGmDls      bool
+			}{compilerMacros, featureSetMacros, wasmMacros, songMacros, encodedPatch, patterns, sequences, transpositions, len(patterns[0]), len(sequences[0]), 1, usesGmDls}
 			populatedTemplate, extension, err = com.compile(templateName, &data)
+		} else if com.Arch == "c" {
+			data := struct {
+				CompilerMacros
+				FeatureSetMacros
+				SongMacros
+				*vm.Bytecode
+				Patterns       [][]byte
+				Sequences      [][]byte
+				Transpositions [][]int8
+				PatternLength  int
+				SequenceLength int
+				Hold           int
+				UsesGmDls      bool
+			}{compilerMacros, featureSetMacros, songMacros, encodedPatch, patterns, sequences, transpositions, len(patterns[0]), len(sequences[0]), 1, usesGmDls}
+			populatedTemplate, extension, err = com.compile(templateName, &data)
+		} else if com.Arch == "go" {
+			goPackage := com.GoPackage
+			if goPackage == "" {
+				goPackage = "song"
+			}
+			data := struct {
+				CompilerMacros
+				FeatureSetMacros
+				SongMacros
+				*vm.Bytecode
+				Patterns       [][]byte
+				Sequences      [][]byte
+				Transpositions [][]int8
+				PatternLength  int
+				SequenceLength int
+				Hold           int
+				UsesGmDls      bool
+				GoPackage      string
+			}{compilerMacros, featureSetMacros, songMacros, encodedPatch, patterns, sequences, transpositions, len(patterns[0]), len(sequences[0]), 1, usesGmDls, goPackage}
+			populatedTemplate, extension, err = com.compile(templateName, &data)
+			if err == nil {
+				var formatted []byte
+				if formatted, err = format.Source([]byte(populatedTemplate)); err == nil { // the template is not careful about the alignment gofmt wants
+					populatedTemplate = string(formatted)
+				}
+			}
 		}
 		if err != nil {
 			return nil, fmt.Errorf(`could not execute template "%v": %v`, templateName, err)
@@ -140,9 +245,39 @@ func (com *Compiler) Song(song *sointu.Song) (map[string]string, error) {
 	return retmap, nil
 }
 
+// Thread is the code run by one thread of a multithreaded player
+type Thread struct {
+	Opcodes       []byte
+	Operands      []byte
+	NumDelayLines int
+}
+
+// splitThreads splits the patch between the threads of a multithreaded x86
+// player, like vm.MultithreadSynth does. Returns nil if the song needs only one
+// thread, or if it uses syncs, which the multithreaded players do not support:
+// such songs are rendered by the single-threaded player.
+func splitThreads(song *sointu.Song, bytecode *vm.Bytecode, usesSync bool) []Thread {
+	numThreads := min(song.Patch.NumThreads(), vm.MAX_THREADS)
+	if numThreads <= 1 || usesSync {
+		return nil
+	}
+	threads := make([]Thread, numThreads)
+	for t := range threads {
+		threads[t].Opcodes, threads[t].Operands = bytecode.ThreadCode(song.Patch, t)
+		var patch sointu.Patch
+		for _, instr := range song.Patch {
+			if (instr.ThreadMaskM1+1)&(1<<t) != 0 {
+				patch = append(patch, instr)
+			}
+		}
+		threads[t].NumDelayLines = patch.NumDelayLines()
+	}
+	return threads
+}
+
 func (com *Compiler) compile(templateName string, data interface{}) (string, string, error) {
 	result := bytes.NewBufferString("")
 	err := com.Template.ExecuteTemplate(result, templateName, data)
-	extension := filepath.Ext(templateName)
+	extension := filepath.Ext(strings.TrimSuffix(templateName, ".tmpl")) // .tmpl is used for templates that would otherwise be mistaken as source files, e.g. .go
 	return result.String(), extension, err
 }
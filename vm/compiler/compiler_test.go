@@ -0,0 +1,54 @@
+package compiler_test
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm/compiler"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMultithreadedSongWithSyncs(t *testing.T) {
+	_, myname, _, _ := runtime.Caller(0)
+	songBytes, err := os.ReadFile(path.Join(path.Dir(myname), "..", "..", "tests", "test_multithread.yml"))
+	if err != nil {
+		t.Fatalf("cannot read song: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		syncUnit bool
+		rowSync  bool
+	}{
+		{"no syncs", false, false},
+		{"sync unit", true, false},
+		{"row sync", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var song sointu.Song
+			if err := yaml.Unmarshal(songBytes, &song); err != nil {
+				t.Fatalf("cannot unmarshal song: %v", err)
+			}
+			if tc.syncUnit {
+				units := song.Patch[0].Units
+				song.Patch[0].Units = append(units[:3:3], append([]sointu.Unit{{Type: "sync", Parameters: map[string]int{}}}, units[3:]...)...)
+			}
+			comp, err := compiler.New("linux", "amd64", false, tc.rowSync)
+			if err != nil {
+				t.Fatalf("could not create compiler: %v", err)
+			}
+			code, err := comp.Song(&song)
+			if err != nil {
+				t.Fatalf("compiling a multithreaded song failed: %v", err)
+			}
+			// songs with syncs fall back to the single-threaded player
+			multithreaded := strings.Contains(code[".asm"], "su_synth_obj_1:")
+			if expected := !tc.syncUnit && !tc.rowSync; multithreaded != expected {
+				t.Fatalf("expected multithreaded player: %v, got %v", expected, multithreaded)
+			}
+		})
+	}
+}
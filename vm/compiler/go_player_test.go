@@ -0,0 +1,185 @@
+package compiler_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm/compiler"
+	"gopkg.in/yaml.v3"
+)
+
+// goPlayerTest is a regression test song compiled into a Go player
+type goPlayerTest struct {
+	name              string // name of the expected output
+	song              string // name of the song
+	output16Bit       bool
+	rowSync           bool
+	transposePatterns bool
+}
+
+// TestGoPlayers compiles all the regression test songs into Go players,
+// builds them into one program, which renders all the songs, and compares the
+// renders to the expected outputs.
+func TestGoPlayers(t *testing.T) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found, cannot build the Go players")
+	}
+	_, myname, _, _ := runtime.Caller(0)
+	testsDir := path.Join(path.Dir(myname), "..", "..", "tests")
+	files, err := filepath.Glob(path.Join(testsDir, "*.yml"))
+	if err != nil {
+		t.Fatalf("cannot glob files in the test directory: %v", err)
+	}
+	var tests []goPlayerTest
+	for _, filename := range files {
+		basename := filepath.Base(filename)
+		testname := strings.TrimSuffix(basename, path.Ext(basename))
+		if strings.Contains(testname, "sample") {
+			continue // the players do not load gm.dls by themselves
+		}
+		tests = append(tests, goPlayerTest{name: testname, song: testname, rowSync: testname == "test_sync", transposePatterns: strings.Contains(testname, "transpose")})
+	}
+	tests = append(tests, goPlayerTest{name: "test_envelope_16bit", song: "test_envelope", output16Bit: true})
+	dir := t.TempDir()
+	var imports, renders strings.Builder
+	for i, test := range tests {
+		songBytes, err := os.ReadFile(path.Join(testsDir, test.song+".yml"))
+		if err != nil {
+			t.Fatalf("cannot read the .yml file: %v", err)
+		}
+		var song sointu.Song
+		if err := yaml.Unmarshal(songBytes, &song); err != nil {
+			t.Fatalf("could not parse the .yml file: %v", err)
+		}
+		comp, err := compiler.New(runtime.GOOS, "go", test.output16Bit, test.rowSync)
+		if err != nil {
+			t.Fatalf("could not create compiler: %v", err)
+		}
+		comp.GoPackage = fmt.Sprintf("song%d", i)
+		comp.TransposePatterns = test.transposePatterns
+		code, err := comp.Song(&song)
+		if err != nil {
+			t.Fatalf("could not compile %v: %v", test.name, err)
+		}
+		if err := os.Mkdir(path.Join(dir, comp.GoPackage), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path.Join(dir, comp.GoPackage, "player.go"), []byte(code[".go"]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&imports, "\t%q\n", "players/"+comp.GoPackage)
+		fmt.Fprintf(&renders, "\tbuf%d := make([]%s.Sample, %s.BufferLength)\n", i, comp.GoPackage, comp.GoPackage)
+		if test.rowSync {
+			fmt.Fprintf(&renders, "\tsyncs%d := make([]float32, %s.SyncBufferLength)\n", i, comp.GoPackage)
+			fmt.Fprintf(&renders, "\t%s.RenderWithSyncs(buf%d, syncs%d)\n", comp.GoPackage, i, i)
+			fmt.Fprintf(&renders, "\twrite(%q, syncs%d)\n", test.name+"_syncbuf", i)
+		} else {
+			fmt.Fprintf(&renders, "\t%s.Render(buf%d)\n", comp.GoPackage, i)
+		}
+		fmt.Fprintf(&renders, "\twrite(%q, buf%d)\n", test.name, i)
+	}
+	main := fmt.Sprintf(`package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+
+%s)
+
+func write(name string, data any) {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, data)
+	if err := os.WriteFile(filepath.Join(os.Args[1], name+".raw"), b.Bytes(), 0644); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+%s}
+`, imports.String(), renders.String())
+	if err := os.WriteFile(path.Join(dir, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(dir, "go.mod"), []byte("module players\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	cmd := exec.Command(goCmd, "run", ".", outDir)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOTOOLCHAIN=local")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("could not build and run the Go players: %v\n%s", err, out)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := readRawFloats(t, path.Join(outDir, test.name+".raw"), test.output16Bit)
+			expected := readRawFloats(t, path.Join(testsDir, "expected_output", test.name+".raw"), test.output16Bit)
+			compareRenders(t, actual, expected)
+			if test.rowSync {
+				actual := readRawFloats(t, path.Join(outDir, test.name+"_syncbuf.raw"), false)
+				expected := readRawFloats(t, path.Join(testsDir, "expected_output", test.name+"_syncbuf.raw"), false)
+				compareRenders(t, actual, expected)
+			}
+		})
+	}
+}
+
+func readRawFloats(t *testing.T, filename string, output16Bit bool) []float32 {
+	t.Helper()
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("cannot read %v: %v", filename, err)
+	}
+	if output16Bit {
+		ints := make([]int16, len(b)/2)
+		binary.Read(bytes.NewReader(b), binary.LittleEndian, ints)
+		ret := make([]float32, len(ints))
+		for i, v := range ints {
+			ret[i] = float32(v) / 32767
+		}
+		return ret
+	}
+	ret := make([]float32, len(b)/4)
+	binary.Read(bytes.NewReader(b), binary.LittleEndian, ret)
+	return ret
+}
+
+// compareRenders compares the interleaved stereo renders as liberally as the
+// Go VM tests: the Go players do their math like the Go VM, which rounds
+// slightly differently than the x86 VM that rendered the expected outputs.
+func compareRenders(t *testing.T, actual, expected []float32) {
+	t.Helper()
+	const errorThreshold = 1e-2
+	if len(actual) != len(expected) {
+		t.Fatalf("buffer length mismatch, got %v, expected %v", len(actual), len(expected))
+	}
+	errs := 0
+	for i, s := range expected {
+		d := math.Abs(float64(s - actual[i]))
+		if i >= 2 {
+			d = min(d, math.Abs(float64(s-actual[i-2])))
+		}
+		if i+2 < len(actual) {
+			d = min(d, math.Abs(float64(s-actual[i+2])))
+		}
+		if math.IsNaN(float64(actual[i])) || d > errorThreshold {
+			errs++
+			if errs > 200 {
+				t.Fatalf("more than 200 errors bigger than %v detected, last at sample position %v", errorThreshold, i)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package compiler
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/vsariola/sointu"
 )
@@ -196,3 +197,72 @@ func ConstructPatterns(song *sointu.Song) ([][]byte, [][]byte, error) {
 	}
 	return bytePatterns, sequences, nil
 }
+
+// TransposePatterns is an optional optimization pass after ConstructPatterns:
+// the patterns that are transpositions of an earlier pattern, i.e. have the
+// holds and releases on the same rows and all the notes shifted by the same
+// amount, are replaced by the earlier pattern and the transposition is stored
+// for each sequence entry. The players add the transposition to the notes, but
+// not to the holds or releases. Returns the new patterns, sequences and
+// transpositions, and the number of bytes saved. If nothing would be saved, the
+// patterns and sequences are returned as they were, with nil transpositions.
+func TransposePatterns(patterns [][]byte, sequences [][]byte) ([][]byte, [][]byte, [][]int8, int) {
+	if len(patterns) == 0 || len(sequences) == 0 {
+		return patterns, sequences, nil, 0
+	}
+	var newPatterns [][]byte
+	newIndices := make([]int, len(patterns))
+	shifts := make([]int8, len(patterns))
+	for i, pat := range patterns {
+		newIndices[i] = -1
+		for j, p := range newPatterns {
+			if shift, ok := transposition(p, pat); ok {
+				newIndices[i], shifts[i] = j, shift
+				break
+			}
+		}
+		if newIndices[i] == -1 {
+			newIndices[i] = len(newPatterns)
+			newPatterns = append(newPatterns, pat)
+		}
+	}
+	saved := (len(patterns) - len(newPatterns)) * len(patterns[0])
+	saved -= len(sequences) * len(sequences[0]) // the transposition table
+	if saved <= 0 {
+		return patterns, sequences, nil, 0
+	}
+	newSequences := make([][]byte, len(sequences))
+	transpositions := make([][]int8, len(sequences))
+	for i, s := range sequences {
+		newSequences[i] = make([]byte, len(s))
+		transpositions[i] = make([]int8, len(s))
+		for j, p := range s {
+			newSequences[i][j] = byte(newIndices[p])
+			transpositions[i][j] = shifts[p]
+		}
+	}
+	return newPatterns, newSequences, transpositions, saved
+}
+
+// transposition checks if the pattern b is the pattern a with all the notes
+// shifted by the same amount, and returns the amount.
+func transposition(a, b []byte) (int8, bool) {
+	shift, first := 0, true
+	for k := range a {
+		if a[k] <= 1 || b[k] <= 1 { // holds and releases should be on the same rows
+			if a[k] != b[k] {
+				return 0, false
+			}
+			continue
+		}
+		if d := int(b[k]) - int(a[k]); first {
+			shift, first = d, false
+		} else if d != shift {
+			return 0, false
+		}
+	}
+	if shift < math.MinInt8 || shift > math.MaxInt8 {
+		return 0, false
+	}
+	return int8(shift), true
+}
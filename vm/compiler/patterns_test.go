@@ -90,3 +90,36 @@ func TestDontCares(t *testing.T) {
 		t.Fatalf("got different patterns than expected. got: %v expected: %v", patterns, expectedPatterns)
 	}
 }
+
+func TestTransposePatterns(t *testing.T) {
+	patterns := [][]byte{{36, 1, 1, 0, 0, 0, 0, 0}, {43, 1, 1, 0, 0, 0, 0, 0}, {48, 1, 1, 0, 0, 0, 0, 0}, {36, 0, 0, 0, 0, 0, 0, 0}}
+	sequences := [][]byte{{0, 1, 2, 3}}
+	newPatterns, newSequences, transpositions, saved := compiler.TransposePatterns(patterns, sequences)
+	expectedPatterns := [][]byte{{36, 1, 1, 0, 0, 0, 0, 0}, {36, 0, 0, 0, 0, 0, 0, 0}}
+	expectedSequences := [][]byte{{0, 0, 0, 1}}
+	expectedTranspositions := [][]int8{{0, 7, 12, 0}}
+	if !reflect.DeepEqual(newPatterns, expectedPatterns) {
+		t.Fatalf("got different patterns than expected. got: %v expected: %v", newPatterns, expectedPatterns)
+	}
+	if !reflect.DeepEqual(newSequences, expectedSequences) {
+		t.Fatalf("got different sequences than expected. got: %v expected: %v", newSequences, expectedSequences)
+	}
+	if !reflect.DeepEqual(transpositions, expectedTranspositions) {
+		t.Fatalf("got different transpositions than expected. got: %v expected: %v", transpositions, expectedTranspositions)
+	}
+	if saved != 12 {
+		t.Fatalf("expected 12 bytes saved, got %v", saved)
+	}
+}
+
+func TestTransposePatternsNoSavings(t *testing.T) {
+	patterns := [][]byte{{36, 1, 0, 0}, {43, 1, 0, 0}}
+	sequences := [][]byte{{0, 1}, {0, 0}, {0, 0}, {1, 1}, {1, 0}}
+	newPatterns, newSequences, transpositions, saved := compiler.TransposePatterns(patterns, sequences)
+	if !reflect.DeepEqual(newPatterns, patterns) || !reflect.DeepEqual(newSequences, sequences) {
+		t.Fatalf("the patterns should not change when the transposition table is larger than the savings")
+	}
+	if transpositions != nil || saved != 0 {
+		t.Fatalf("expected no transpositions and no savings, got %v and %v", transpositions, saved)
+	}
+}
@@ -0,0 +1,155 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm"
+)
+
+type (
+	// DataSection is one data section of a compiled player, e.g. the patterns
+	DataSection struct {
+		Name string
+		Data []byte
+	}
+
+	// SectionSize is the size of one data section of a compiled player
+	SectionSize struct {
+		Name   string
+		Raw    int     // size in the compiled player, in bytes
+		Packed float64 // estimated size after packing, in bytes
+	}
+)
+
+// DataSections returns the data of the player compiled from the song, in
+// sections: the patterns (with the tracks), the bytecode (opcodes and
+// operands), the delay table and the user samples, if the song has any. The
+// code of the player is not included, as it is only known after assembling.
+func DataSections(song *sointu.Song, transposePatterns bool) ([]DataSection, error) {
+	patterns, sequences, err := ConstructPatterns(song)
+	if err != nil {
+		return nil, fmt.Errorf(`could not encode song: %v`, err)
+	}
+	var transpositions [][]int8
+	if transposePatterns {
+		patterns, sequences, transpositions, _ = TransposePatterns(patterns, sequences)
+	}
+	bytecode, err := vm.NewBytecode(song.Patch, vm.NecessaryFeaturesFor(song.Patch), song.BPM)
+	if err != nil {
+		return nil, fmt.Errorf(`could not encode patch: %v`, err)
+	}
+	var patternData bytes.Buffer
+	for _, p := range patterns {
+		patternData.Write(p)
+	}
+	for _, s := range sequences {
+		patternData.Write(s)
+	}
+	for _, t := range transpositions {
+		binary.Write(&patternData, binary.LittleEndian, t)
+	}
+	var delayData bytes.Buffer
+	binary.Write(&delayData, binary.LittleEndian, bytecode.DelayTimes)
+	ret := []DataSection{
+		{"patterns", patternData.Bytes()},
+		{"bytecode", append(append([]byte{}, bytecode.Opcodes...), bytecode.Operands...)},
+		{"delay table", delayData.Bytes()},
+	}
+	if len(bytecode.SampleBank) > 0 {
+		var sampleData bytes.Buffer
+		binary.Write(&sampleData, binary.LittleEndian, bytecode.SampleBank)
+		ret = append(ret, DataSection{"samples", sampleData.Bytes()})
+	}
+	return ret, nil
+}
+
+// EstimateSizes estimates the packed sizes of the data sections of the player
+// compiled from the song, see DataSections. The last section, "total", is the
+// sum of the other sections.
+func EstimateSizes(song *sointu.Song, transposePatterns bool) ([]SectionSize, error) {
+	sections, err := DataSections(song, transposePatterns)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]SectionSize, 0, len(sections)+1)
+	total := SectionSize{Name: "total"}
+	for _, s := range sections {
+		size := SectionSize{Name: s.Name, Raw: len(s.Data), Packed: EstimatePackedSize(s.Data)}
+		total.Raw += size.Raw
+		total.Packed += size.Packed
+		ret = append(ret, size)
+	}
+	return append(ret, total), nil
+}
+
+// EstimatePackedSize estimates the size of the data, in bytes, after packing it
+// with a context mixing packer, like Crinkler or Squishy. The estimate is the
+// entropy of the data under a small model of the same kind: the bit
+// predictions of order 0-4 and order 6 context models are mixed in the
+// logistic domain, and each bit costs -log2 of its predicted probability. The
+// real packers have more models and tuned parameters, so the estimate is best
+// used for comparing songs and sections, not as an exact budget.
+func EstimatePackedSize(data []byte) float64 {
+	orders := [...]int{0, 1, 2, 3, 4, 6}
+	const tableBits = 16 // the contexts are hashed into tables of this size, like in the packers
+	type counter struct{ n0, n1 uint8 }
+	var tables [len(orders)][]counter
+	var weights, stretched [len(orders)]float64
+	var contexts, keys [len(orders)]uint64
+	for i := range tables {
+		tables[i] = make([]counter, 1<<tableBits)
+		weights[i] = 0.3
+	}
+	const learningRate = 0.02
+	bits := 0.0
+	for pos, b := range data {
+		for i, order := range orders { // hash of the previous order bytes
+			h := uint64(order+1) * 0x9E3779B97F4A7C15
+			for k := 1; k <= order && pos-k >= 0; k++ {
+				h = (h ^ uint64(data[pos-k])) * 0x100000001B3
+			}
+			contexts[i] = h
+		}
+		partial := uint64(1) // the bits of the current byte seen so far, with a leading 1
+		for j := 7; j >= 0; j-- {
+			bit := int(b>>j) & 1
+			dot := 0.0
+			for i := range orders {
+				keys[i] = ((contexts[i] ^ partial) * 0xFF51AFD7ED558CCD) >> (64 - tableBits)
+				c := tables[i][keys[i]]
+				p := (float64(c.n1) + 0.4) / (float64(c.n0) + float64(c.n1) + 0.8)
+				stretched[i] = math.Log(p / (1 - p))
+				dot += weights[i] * stretched[i]
+			}
+			p := 1 / (1 + math.Exp(-dot))
+			p = math.Min(math.Max(p, 1.0/4096), 1-1.0/4096)
+			if bit == 1 {
+				bits -= math.Log2(p)
+			} else {
+				bits -= math.Log2(1 - p)
+			}
+			err := float64(bit) - p
+			for i := range orders {
+				weights[i] += learningRate * err * stretched[i]
+				c := &tables[i][keys[i]]
+				if bit == 1 { // nonstationary counters: the opposite count is halved
+					c.n1 = min(c.n1, 254) + 1
+					if c.n0 > 2 {
+						c.n0 = c.n0/2 + 1
+					}
+				} else {
+					c.n0 = min(c.n0, 254) + 1
+					if c.n1 > 2 {
+						c.n1 = c.n1/2 + 1
+					}
+				}
+			}
+			partial = partial<<1 | uint64(bit)
+		}
+	}
+	return bits / 8
+}
@@ -0,0 +1,70 @@
+package compiler_test
+
+import (
+	"math/rand"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/vm/compiler"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEstimatePackedSize(t *testing.T) {
+	zeros := make([]byte, 4096)
+	if size := compiler.EstimatePackedSize(zeros); size > 16 {
+		t.Fatalf("4096 zeros should pack to almost nothing, got %v bytes", size)
+	}
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(0)).Read(random)
+	if size := compiler.EstimatePackedSize(random); size < 4000 || size > 4200 {
+		t.Fatalf("4096 random bytes should not pack, got %v bytes", size)
+	}
+	if size := compiler.EstimatePackedSize(nil); size != 0 {
+		t.Fatalf("empty data should take no space, got %v bytes", size)
+	}
+}
+
+func TestEstimateSizes(t *testing.T) {
+	_, myname, _, _ := runtime.Caller(0)
+	songBytes, err := os.ReadFile(path.Join(path.Dir(myname), "..", "..", "tests", "test_pattern_transpose.yml"))
+	if err != nil {
+		t.Fatalf("cannot read song: %v", err)
+	}
+	var song sointu.Song
+	if err := yaml.Unmarshal(songBytes, &song); err != nil {
+		t.Fatalf("cannot unmarshal song: %v", err)
+	}
+	sizes, err := compiler.EstimateSizes(&song, false)
+	if err != nil {
+		t.Fatalf("EstimateSizes failed: %v", err)
+	}
+	transposedSizes, err := compiler.EstimateSizes(&song, true)
+	if err != nil {
+		t.Fatalf("EstimateSizes failed: %v", err)
+	}
+	names := []string{"patterns", "bytecode", "delay table", "total"}
+	if len(sizes) != len(names) {
+		t.Fatalf("expected sections %v, got %v", names, sizes)
+	}
+	var raw int
+	var packed float64
+	for i, s := range sizes[:len(sizes)-1] {
+		if s.Name != names[i] {
+			t.Fatalf("expected section %v, got %v", names[i], s.Name)
+		}
+		if s.Packed > float64(s.Raw)+4 {
+			t.Fatalf("section %v should not grow much when packed: %v bytes, packed %v bytes", s.Name, s.Raw, s.Packed)
+		}
+		raw += s.Raw
+		packed += s.Packed
+	}
+	if total := sizes[len(sizes)-1]; total.Raw != raw || total.Packed != packed {
+		t.Fatalf("the total should be the sum of the sections, got %v", total)
+	}
+	if transposedSizes[0].Raw != sizes[0].Raw-8 {
+		t.Fatalf("transposing the patterns should save 8 bytes, got %v and %v", sizes[0].Raw, transposedSizes[0].Raw)
+	}
+}
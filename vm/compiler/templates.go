@@ -0,0 +1,165 @@
+package compiler
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateSubdir returns the subdirectory of the embedded templates for the
+// architecture.
+func templateSubdir(arch string) (string, error) {
+	switch arch {
+	case "386", "amd64":
+		return "amd64-386", nil
+	case "wasm", "c", "go":
+		return arch, nil
+	}
+	return "", fmt.Errorf("only amd64, 386, wasm, c and go archs are supported (targeted architecture was %v)", arch)
+}
+
+// songTemplates returns the templates executed when compiling a song player.
+func songTemplates(arch string) []string {
+	switch arch {
+	case "386", "amd64":
+		return []string{"player.asm", "player.h", "player.inc"}
+	case "wasm":
+		return []string{"player.wat"}
+	case "c":
+		return []string{"player.c", "player.h"}
+	case "go":
+		return []string{"player.go.tmpl"}
+	}
+	return nil
+}
+
+// libraryTemplates returns the templates executed when compiling a library.
+func libraryTemplates(arch string) []string {
+	if arch == "wasm" {
+		return []string{"library.wat", "library.js"}
+	}
+	return []string{"library.asm", "library.h"}
+}
+
+// EmbeddedTemplates returns the names of the standard templates for the
+// architecture, i.e. the templates used when no template directory is given.
+func EmbeddedTemplates(arch string) ([]string, error) {
+	subdir, err := templateSubdir(arch)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := templateFS.ReadDir("templates/" + subdir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the templates: %v", err)
+	}
+	ret := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, e.Name())
+	}
+	return ret, nil
+}
+
+// ExportTemplates writes the standard templates for the architecture to the
+// directory, creating it if needed. The directory can be modified and given
+// to NewFromTemplates.
+func ExportTemplates(arch string, directory string) error {
+	names, err := EmbeddedTemplates(arch)
+	if err != nil {
+		return err
+	}
+	subdir, _ := templateSubdir(arch)
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return fmt.Errorf("could not create directory %v: %v", directory, err)
+	}
+	for _, name := range names {
+		contents, err := fs.ReadFile(templateFS, "templates/"+subdir+"/"+name)
+		if err != nil {
+			return fmt.Errorf("could not read template %v: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(directory, name), contents, 0644); err != nil {
+			return fmt.Errorf("could not write template %v: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// MissingTemplates returns the templates that compiling a song (or a library,
+// if library is true) needs, but the templates of the compiler do not have.
+// The templates included by other templates are checked too, so that a custom
+// template directory can be validated before compiling anything.
+func (com *Compiler) MissingTemplates(library bool) []string {
+	needed := songTemplates(com.Arch)
+	if library {
+		needed = libraryTemplates(com.Arch)
+	}
+	missing := map[string]bool{}
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		t := com.Template.Lookup(name)
+		if t == nil || t.Tree == nil {
+			missing[name] = true
+			return
+		}
+		for _, included := range includedTemplates(t) {
+			visit(included)
+		}
+	}
+	for _, name := range needed {
+		visit(name)
+	}
+	ret := make([]string, 0, len(missing))
+	for name := range missing {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// includedTemplates returns the names of the templates that the template
+// includes with {{template "name"}}.
+func includedTemplates(t *template.Template) []string {
+	var ret []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			ret = append(ret, n.Name)
+		}
+	}
+	walk(t.Tree.Root)
+	return ret
+}
+
+// checkTemplates returns an error if the compiler lacks any of the templates
+// needed for compiling a song or a library.
+func (com *Compiler) checkTemplates(library bool) error {
+	if missing := com.MissingTemplates(library); len(missing) > 0 {
+		return fmt.Errorf("the templates are missing %v", missing)
+	}
+	return nil
+}
@@ -99,7 +99,16 @@ su_op_addp_mono:
     call    su_op_loadnote_mono
     su_op_loadnote_mono:
 {{- end}}
+{{- if .NoteTable}}
+    push    {{.AX}}
+    mov     eax, dword [{{.INP}}-su_voice.inputs+su_voice.note]
+    and     eax, 127 ; the note table has 128 entries
+{{- .Prepare "su_note_table" | indent 4}}
+    fld     dword [{{.Use "su_note_table"}} + {{.AX}}*4] ; tuned note
+    pop     {{.AX}}
+{{- else}}
     fild    dword [{{.INP}}-su_voice.inputs+su_voice.note]
+{{- end}}
     {{.Prepare (.Float 0.0078125)}}
     fmul    dword [{{.Use (.Float 0.0078125)}}]  ; s=n/128.0
     {{.Prepare (.Float 0.5)}}
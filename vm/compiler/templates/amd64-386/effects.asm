@@ -307,6 +307,32 @@ su_op_pan_do:
 {{end}}
 
 
+{{- if .HasOp "width"}}
+;-------------------------------------------------------------------------------
+;   WIDTH opcode: change the stereo width of the signal
+;-------------------------------------------------------------------------------
+;   Stereo: l r ->  m+s m-s
+;
+;   where m = (l+r)/2 is the mid signal, s = w*(l-r) is the side signal and w
+;   is the width in [0,1] range; 0.5 keeps the signal unchanged, 0 makes it
+;   mono and 1 doubles the side signal. There is no mono version of this unit.
+;-------------------------------------------------------------------------------
+{{.Func "su_op_width" "Opcode"}}
+    fld     st1                                 ; r l r
+    fsubr   st0, st1                            ; l-r l r
+    fmul    dword [{{.Input "width" "width"}}]  ; s l r
+    fxch    st2                                 ; r l s
+    faddp   st1, st0                            ; l+r s
+{{- .Float 0.5 | .Prepare | indent 4}}
+    fmul    dword [{{.Float 0.5 | .Use}}]       ; m s
+    fld     st0                                 ; m m s
+    fsub    st0, st2                            ; m-s m s
+    fxch    st2                                 ; s m m-s
+    faddp   st1, st0                            ; m+s m-s
+    ret
+{{end}}
+
+
 {{- if .HasOp "delay"}}
 ;-------------------------------------------------------------------------------
 ;   DELAY opcode: adds delay effect to the signal
@@ -316,8 +342,15 @@ su_op_pan_do:
 ;   Stereo: perform delay on ST1, using delaycount delaylines starting
 ;           at delayindex + delaycount from the delaytable (so the right delays
 ;           can be different)
+;   Interpolation: when the delay time is modulated or note tracked, the
+;           delay lines are read at fractional positions, interpolating
+;           linearly between the two nearest samples
 ;-------------------------------------------------------------------------------
 {{.Func "su_op_delay" "Opcode"}}
+{{- if .SupportsParamValue "delay" "interpolation" 1}}
+    lodsb                           ; al = interpolation flag
+    bswap   eax                     ; move the flag to the highest byte of eax; bswap does not touch the carry flag
+{{- end}}
     lodsw                           ; al = delay index, ah = delay count
     {{- .PushRegs .VAL "DelayVal" .COM "DelayCom" | indent 4}}
     movzx   ebx, al
@@ -380,12 +413,40 @@ su_op_delay_loop:
         {{- if .SupportsParamValue "delay" "notetracking" 1}}
         test    ah, 1 ; note syncing is the least significant bit of ah, 0 = ON, 1 = OFF
         jne     su_op_delay_skipnotesync
+        {{- if .NoteTable}}
+        push    {{.AX}}
+        mov     eax, dword [{{.INP}}-su_voice.inputs+su_voice.note]
+        and     eax, 127 ; the note table has 128 entries
+        {{- .Prepare "su_note_table" | indent 8}}
+        fld     dword [{{.Use "su_note_table"}} + {{.AX}}*4] ; tuned note
+        pop     {{.AX}}
+        {{- else}}
         fild    dword [{{.INP}}-su_voice.inputs+su_voice.note]
+        {{- end}}
         {{.Int 0x3DAAAAAA | .Prepare | indent 8}}
         fmul    dword [{{.Int 0x3DAAAAAA | .Use}}]
         {{.Call "su_power"}}
         fdivp   st1, st0                 ; use 10787 for delaytime to have neutral transpose
         su_op_delay_skipnotesync:
+        {{- end}}
+        {{- if .SupportsParamValue "delay" "interpolation" 1}}
+        test    eax, 0x1000000                          ; interpolation flag is in the highest byte of eax
+        jz      su_op_delay_nointerpolation
+        fld     st0                                     ; k k dr*y p*p*x
+        {{- .Float 0.5 | .Prepare | indent 8}}
+        fsub    dword [{{.Float 0.5 | .Use}}]           ; k-.5 k dr*y p*p*x
+        fistp   dword [{{.SP}}-4]                       ; k dr*y p*p*x, dword [{{.SP}}-4] = i = floor(k)
+        fisub   dword [{{.SP}}-4]                       ; a=k-i dr*y p*p*x, where a is the interpolation coefficient
+        mov     edi, esi
+        sub     di, word [{{.SP}}-4]                    ; we perform the math in 16-bit to wrap around
+        fld     dword [{{.CX}}+su_delayline_wrk.buffer+{{.DI}}*4]; s0 a dr*y p*p*x, where s0 = b[t-i]
+        dec     di
+        fld     dword [{{.CX}}+su_delayline_wrk.buffer+{{.DI}}*4]; s1 s0 a dr*y p*p*x, where s1 = b[t-i-1]
+        fsub    st0, st1                                ; s1-s0 s0 a dr*y p*p*x
+        fmulp   st2, st0                                ; s0 a*(s1-s0) dr*y p*p*x
+        faddp   st1, st0                                ; s dr*y p*p*x, where s = s0+a*(s1-s0)
+        jmp     short su_op_delay_interpolated
+su_op_delay_nointerpolation:
         {{- end}}
         fistp   dword [{{.SP}}-4]                       ; dr*y p*p*x, dword [{{.SP}}-4] = integer amount of delay (samples)
         mov     edi, esi                            ; edi = esi = current time
@@ -395,6 +456,9 @@ su_op_delay_loop:
         sub     di, word [{{.BX}}]                      ; we perform the math in 16-bit to wrap around
         {{- end}}
         fld     dword [{{.CX}}+su_delayline_wrk.buffer+{{.DI}}*4]; s dr*y p*p*x, where s is the sample from delay buffer
+        {{- if and (.SupportsParamValue "delay" "interpolation" 1) (or (.SupportsModulation "delay" "delaytime") (.SupportsParamValue "delay" "notetracking" 1))}}
+su_op_delay_interpolated:
+        {{- end}}
         fadd    st1, st0                                ; s dr*y+s p*p*x (add comb output to current output)
         fld1                                            ; 1 s dr*y+s p*p*x
         fsub    dword [{{.Input "delay" "damp"}}]         ; 1-da s dr*y+s p*p*x
@@ -438,8 +502,15 @@ su_op_delay_loop:
 ;           you can either MULP to compress the signal or SEND it to a GAIN
 ;           somewhere else for compressor side-chaining.
 ;   Stereo: push g g on stack, where g is calculated using l^2 + r^2
+;   Sidechain: the level is detected from the topmost signal s, which is
+;           replaced with g, i.e. x s -> x g
 ;-------------------------------------------------------------------------------
 {{.Func "su_op_compressor" "Opcode"}}
+{{- if .SupportsParamValue "compressor" "sidechain" 1}}
+    lodsb                                       ; al = sidechain flag
+    dec     al                                  ; dec does not touch the carry flag, so the stereo bit survives
+    jz      su_op_compressor_sidechain
+{{- end}}
     fld     st0                                 ; x x
     fmul    st0, st0                            ; x^2 x
 {{- if .StereoAndMono "compressor"}}
@@ -450,12 +521,18 @@ su_op_delay_loop:
     fst     st3                                 ; y x^2 l r
     fmul    st0, st0                            ; y^2 x^2 l r
     faddp   st1, st0                            ; y^2+x^2 l r
+{{- if .SupportsParamValue "compressor" "sidechain" 1}}
+su_op_compressor_stereo:
+{{- end}}
 {{- if .StereoAndMono "compressor"}}
     call    su_op_compressor_mono               ; So, for stereo, we square both left & right and add them up
     fld     st0                                 ; and return the computed gain two times, ready for MULP STEREO
     ret
 su_op_compressor_mono:
 {{- end}}
+{{- end}}
+{{- if .SupportsParamValue "compressor" "sidechain" 1}}
+su_op_compressor_level:
 {{- end}}
     fld     dword [{{.WRK}}]    ; l x^2 x
     fucomi  st0, st1
@@ -490,4 +567,139 @@ su_op_compressor_mono:
     fld     st0                                 ; and return the computed gain two times, ready for MULP STEREO
 {{- end}}
     ret
+{{- if .SupportsParamValue "compressor" "sidechain" 1}}
+su_op_compressor_sidechain:                     ; the level is detected from the sidechain signal s on top of the stack
+    fmul    st0, st0                            ; s^2 x, the sidechain signal is consumed
+{{- if .StereoAndMono "compressor"}}
+    jnc     su_op_compressor_level
+{{- end}}
+{{- if .Stereo "compressor"}}
+    fxch                                        ; t s^2 l r
+    fmul    st0, st0                            ; t^2 s^2 l r
+    faddp   st1, st0                            ; t^2+s^2 l r
+    jmp     su_op_compressor_stereo
+{{- else}}
+    jmp     su_op_compressor_level
 {{- end}}
+{{- end}}
+{{- end}}
+
+
+{{- if .HasOp "limiter"}}
+;-------------------------------------------------------------------------------
+;   LIMITER opcode: lookahead brickwall limiter
+;-------------------------------------------------------------------------------
+;   Mono:   x   ->  limited(x)
+;   Stereo: l r ->  limited(l) limited(r), both channels using the same gain
+;
+;   The signal is delayed by the lookahead. Each sample stores in the lookahead
+;   buffer the gain reduction it needs, and the gain reduction approaches it
+;   linearly during the lookahead, reaching it fully when the sample comes out:
+;   the gain reduction is the maximum of t*(a+1)/(n+1) over the buffer, where
+;   t is the gain reduction needed by a sample, a its age and n the lookahead.
+;   So the peak is held until it has left the buffer and the output never
+;   exceeds the ceiling; the final clipping only catches the rounding errors.
+;-------------------------------------------------------------------------------
+{{.Func "su_op_limiter" "Opcode"}}
+    lodsb                                       ; al = lookahead L, in steps of 4 samples
+    setc    ah                                  ; ah = stereo bit
+    {{- .PushRegs .VAL "LimiterVal" .COM "LimiterCom" | indent 4}}
+    fld     st0                                 ; l l r
+    fabs                                        ; |l| l r
+{{- if .Stereo "limiter"}}
+{{- if .Mono "limiter"}}
+    test    ah, ah
+    jz      su_op_limiter_peak
+{{- end}}
+    fld     st2                                 ; r |l| l r
+    fabs                                        ; |r| |l| l r
+    fucomi  st0, st1
+    fcmovb  st0, st1                            ; max(|r|,|l|) |l| l r
+    fstp    st1                                 ; p l r, where p is the peak
+su_op_limiter_peak:
+{{- end}}
+    fld     dword [{{.Input "limiter" "ceiling"}}] ; c p l r
+    fucomi  st0, st1
+    fcmovb  st0, st1                            ; max(p,c) p l r
+    fstp    st1                                 ; max(p,c) l r
+    fdivr   dword [{{.Input "limiter" "ceiling"}}] ; c/max(p,c) l r
+    fld1                                        ; 1 c/max(p,c) l r
+    fsubrp  st1, st0                            ; t l r, where t is the gain reduction needed by this sample
+    movzx   esi, word [{{.Stack "GlobalTick"}}]
+    shl     si, 2                               ; each sample takes 4 slots of the buffer: the channels and t. We wrap at 65536
+    mov     {{.CX}}, {{.PTRWORD}} [{{.Stack "DelayWorkSpace"}}] ; the lookahead buffer is a delay line
+    fstp    dword [{{.CX}}+su_delayline_wrk.buffer+{{.SI}}*4+8] ; l r
+    movzx   edi, al
+    shl     edi, 2                              ; edi = n, the lookahead in samples
+    inc     edi                                 ; edi = a+1, starting from the oldest sample
+    fldz                                        ; m l r, where m is the maximum of t*(a+1)
+su_op_limiter_window:
+    lea     ebx, [edi*4-4]
+    neg     bx
+    add     bx, si                              ; bx = 4*(tick-a), in 16-bit to wrap around
+    fld     dword [{{.CX}}+su_delayline_wrk.buffer+{{.BX}}*4+8] ; t m l r
+    mov     dword [{{.SP}}-4], edi
+    fimul   dword [{{.SP}}-4]                   ; t*(a+1) m l r
+    fucomi  st0, st1
+    fcmovb  st0, st1                            ; max(t*(a+1),m) m l r
+    fstp    st1                                 ; m' l r
+    dec     edi
+    jnz     su_op_limiter_window
+    movzx   edi, al
+    shl     edi, 2
+    inc     edi
+    mov     dword [{{.SP}}-4], edi
+    fidiv   dword [{{.SP}}-4]                   ; m/(n+1) l r, the target gain reduction
+    fld     dword [{{.Input "limiter" "release"}}] ; r m/(n+1) l r
+{{- .Int 24 | .Prepare | indent 4}}
+    fimul   dword [{{.Int 24 | .Use}}]          ; 24*r m/(n+1) l r
+    fchs                                        ; -24*r m/(n+1) l r
+    {{.Call "su_power"}}                        ; k m/(n+1) l r, where k = 2^(-24*r)
+    fld1                                        ; 1 k m/(n+1) l r
+    fsubrp  st1, st0                            ; 1-k m/(n+1) l r
+    fmul    dword [{{.WRK}}]                    ; u*(1-k) m/(n+1) l r, where u is the gain reduction
+    fucomi  st0, st1
+    fcmovb  st0, st1                            ; u' m/(n+1) l r, where u' = max(u*(1-k),m/(n+1)): exponential release
+    fstp    st1                                 ; u' l r
+    fst     dword [{{.WRK}}]                    ; u' l r
+    fld1                                        ; 1 u' l r
+    fsubrp  st1, st0                            ; g l r, where g = 1-u' is the gain
+    fstp    dword [{{.WRK}}+4]                  ; l r
+    sub     edi, 1
+    shl     edi, 2                              ; edi = 4*n, the lookahead in slots
+{{- if .Stereo "limiter"}}
+{{- if .Mono "limiter"}}
+    test    ah, ah
+    jz      su_op_limiter_mono
+{{- end}}
+    inc     si
+    fxch                                        ; r l
+    call    su_op_limiter_do                    ; r' l
+    dec     si
+    fxch                                        ; l r'
+su_op_limiter_mono:
+{{- end}}
+    call    su_op_limiter_do                    ; l' r'
+    add     {{.CX}}, su_delayline_wrk.size
+    mov     {{.PTRWORD}} [{{.Stack "DelayWorkSpace"}}], {{.CX}} ; move delay workspace pointer back to stack
+    {{- .PopRegs .VAL .COM | indent 4}}
+    ret
+
+{{.Func "su_op_limiter_do"}}                    ; x
+    mov     ebx, esi
+    sub     bx, di                              ; we perform the math in 16-bit to wrap around
+    fstp    dword [{{.CX}}+su_delayline_wrk.buffer+{{.SI}}*4] ; save x to the lookahead buffer
+    fld     dword [{{.CX}}+su_delayline_wrk.buffer+{{.BX}}*4] ; d, where d is the delayed signal
+    fmul    dword [{{.WRK}}+4]                  ; y=g*d
+    fld     dword [{{.Input "limiter" "ceiling"}}] ; c y
+    fucomi  st0, st1
+    fcmovnb st0, st1                            ; min(c,y) y
+    fstp    st1                                 ; y
+    fchs                                        ; -y
+    fld     dword [{{.Input "limiter" "ceiling"}}] ; c -y
+    fucomi  st0, st1
+    fcmovnb st0, st1                            ; min(c,-y) -y
+    fstp    st1                                 ; -y
+    fchs                                        ; y, which is now clipped to [-c,c]
+    ret
+{{end}}
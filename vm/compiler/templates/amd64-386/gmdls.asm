@@ -1,4 +1,5 @@
 {{- if .SupportsParamValue "oscillator" "type" .Sample}}
+{{- if .UsesGmDls}}
 
 {{- if eq .OS "windows"}}
 {{.ExportFunc "su_load_gmdls"}}
@@ -49,4 +50,20 @@ extern __imp__ReadFile@20
 {{.SectBss "susamtable"}}
 su_sample_table:
     resb    3440660    ; size of gmdls.
+{{- if gt (len .SampleBank) 0}}
+    resw    {{len .SampleBank}} ; user samples, copied here from su_sample_bank when the song starts
+
+{{.Data "su_sample_bank"}}
+{{- template "samplebank" .SampleBank}}
+{{- end}}
+{{- else}}
+
+{{.Data "su_sample_table"}} ; no gm.dls samples used, so the user samples are the whole sample table
+{{- template "samplebank" .SampleBank}}
+{{- end}}
 {{end}}
+
+{{- define "samplebank"}}
+{{- range $i, $v := .}}{{if eq (mod $i 16) 0}}
+    dw {{else}},{{end}}{{$v}}{{end}}
+{{- end}}
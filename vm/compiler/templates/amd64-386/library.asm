@@ -1,14 +1,16 @@
 {{template "structs.asm" .}}
 
+; the arrays are allocated by the caller, sized for the patch, so only pointers
+; to them are stored here
 struc su_synth
     .synth_wrk  resb    su_synthworkspace.size
-    .delay_wrks resb    su_delayline_wrk.size * 128
-    .delaytimes resw    768
-    .sampleoffs resb    su_sample_offset.size * 256
+    .delay_wrks resb    {{.PTRSIZE}}
+    .delaytimes resb    {{.PTRSIZE}}
+    .sampleoffs resb    {{.PTRSIZE}}
     .randseed   resd    1
     .globaltime resd    1
-    .opcodes    resb    32 * 64
-    .operands   resb    32 * 64 * 8
+    .opcodes    resb    {{.PTRSIZE}}
+    .operands   resb    {{.PTRSIZE}}
     .polyphony  resd    1
     .numvoices  resd    1
 endstruc
@@ -41,9 +43,9 @@ endstruc
     {{.Push .SI "BufSize"}}
     {{.Push .DX "BufPtr"}}
     {{.Push .CX "SynthState"}}
-    lea     {{.AX}}, [{{.CX}} + su_synth.sampleoffs]
+    mov     {{.AX}}, [{{.CX}} + su_synth.sampleoffs]
     {{.Push .AX "SampleTable"}}
-    lea     {{.AX}}, [{{.CX}} + su_synth.delaytimes]
+    mov     {{.AX}}, [{{.CX}} + su_synth.delaytimes]
     {{.Push .AX "DelayTable"}}
     mov     eax, [{{.CX}} + su_synth.randseed]
     {{.Push .AX "RandSeed"}}
@@ -73,10 +75,11 @@ su_render_samples_loop:
         mov     eax, [{{.CX}} + su_synth.numvoices]
         {{.Push .AX "VoicesRemain"}}
         lea     {{.DX}}, [{{.CX}}+ su_synth.synth_wrk]
-        lea     {{.COM}}, [{{.CX}}+ su_synth.opcodes]
-        lea     {{.VAL}}, [{{.CX}}+ su_synth.operands]
+        mov     {{.COM}}, [{{.CX}}+ su_synth.opcodes]
+        mov     {{.VAL}}, [{{.CX}}+ su_synth.operands]
         lea     {{.WRK}}, [{{.DX}} + su_synthworkspace.voices]
-        lea     {{.CX}}, [{{.CX}}+ su_synth.delay_wrks - su_delayline_wrk.filtstate]
+        mov     {{.CX}}, [{{.CX}}+ su_synth.delay_wrks]
+        sub     {{.CX}}, su_delayline_wrk.filtstate
         {{.Call "su_run_vm"}}
         {{.Pop .AX}}
         {{.Pop .AX}}
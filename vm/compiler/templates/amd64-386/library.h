@@ -38,13 +38,14 @@ typedef struct SampleOffset {
 
 typedef struct Synth {
     struct SynthWorkspace SynthWrk;
-    struct DelayWorkspace DelayWrks[128]; // let's keep this as 64 for now, so the delays take 16 meg. If that's too little or too much, we can change this in future.
-    unsigned short DelayTimes[768];
-    struct SampleOffset SampleOffsets[256];
+    // The arrays are allocated by the caller, as large as the patch needs
+    struct DelayWorkspace* DelayWrks; // one per delay line
+    unsigned short* DelayTimes;
+    struct SampleOffset* SampleOffsets;
     unsigned int RandSeed;
     unsigned int GlobalTick;
-    unsigned char Opcodes[32 * 64];
-    unsigned char Operands[32 * 64 * 8];
+    unsigned char* Opcodes;
+    unsigned char* Operands;
     unsigned int Polyphony;
     unsigned int NumVoices;
 } Synth;
@@ -70,6 +71,10 @@ void CALLCONV su_load_gmdls(void);
 //
 // Parameters:
 //      synth       pointer to the synthesizer used. RandSeed should be > 0 e.g. 1
+//                  The arrays Opcodes, Operands, DelayTimes, SampleOffsets and
+//                  DelayWrks should be allocated large enough for the patch,
+//                  with DelayWrks zeroed initially. The arrays that the patch
+//                  does not use can be NULL.
 //      buffer      audio sample buffer, L R L R ...
 //      samples     pointer to the maximum number of samples to be rendered.
 //                  buffer should have a length of 2 * maxsamples as the audio
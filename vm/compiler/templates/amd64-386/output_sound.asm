@@ -1,7 +1,12 @@
-{{- if not .Output16Bit }}
+{{- if or .Threads (not .Output16Bit) }}
     {{- if not .Clip }}
             mov     {{.DI}}, [{{.Stack "OutputBufPtr"}}] ; edi containts ptr
+            {{- if .Threads}}
+            mov     {{.SI}}, [{{.Stack "SynthObj"}}] ; the threads output floats, su_render_song mixes them
+            add     {{.SI}}, su_synthworkspace.left
+            {{- else}}
             mov     {{.SI}}, {{.PTRWORD}} su_synth_obj + su_synthworkspace.left
+            {{- end}}
             movsd   ; copy left channel to output buffer
             movsd   ; copy right channel to output buffer
             mov     [{{.Stack "OutputBufPtr"}}], {{.DI}} ; save back the updated ptr
@@ -2,10 +2,31 @@
 ;-------------------------------------------------------------------------------
 ;   Uninitialized data: The synth object
 ;-------------------------------------------------------------------------------
+{{- if .Threads}}
+{{- range $i, $t := .Threads}}
+{{$.SectBss (printf "synth_object_%v" $i)}}
+su_synth_obj_{{$i}}:
+    resb    su_synthworkspace.size
+    resb    {{$t.NumDelayLines}}*su_delayline_wrk.size
+{{- end}}
+
+;-------------------------------------------------------------------------------
+;   Uninitialized data: The output buffers and the handles of the threads
+;-------------------------------------------------------------------------------
+{{- range $i, $t := .Threads}}
+{{$.SectBss (printf "thread_buffer_%v" $i)}}
+su_thread_buffer_{{$i}}:
+    resd    {{$.MaxSamples}}*2
+{{- end}}
+{{.SectBss "thread_handles"}}
+su_thread_handles:
+    resb    {{len .Threads}}*{{.PTRSIZE}}
+{{- else}}
 {{.SectBss "synth_object"}}
 su_synth_obj:
     resb    su_synthworkspace.size
     resb    {{.Song.Patch.NumDelayLines}}*su_delayline_wrk.size
+{{- end}}
 
 {{- if or .RowSync (.HasOp "sync")}}
 {{- if or (and (eq .OS "windows") (not .Amd64)) (eq .OS "darwin")}}
@@ -34,6 +55,70 @@ extern syncBuf
     {{- .PushRegs | indent 4}}
     {{- end}}
     {{- $prologsize := len .Stacklocs}}
+    {{- if and .UsesGmDls (gt (len .SampleBank) 0)}}
+    {{- .Prepare "su_sample_bank" | indent 4}}
+    lea     {{.SI}}, [{{.Use "su_sample_bank"}}]
+    {{- .Prepare "su_sample_table" | indent 4}}
+    lea     {{.DI}}, [{{.Use "su_sample_table"}} + 3440660]
+    mov     ecx, {{len .SampleBank | mul 2}}
+    rep     movsb                       ; copy the user samples after the gm.dls data
+    {{- end}}
+    {{- if .Threads}}
+    {{- template "threads.asm" .}}
+    mov     {{.DI}}, [{{.Stack "OutputBufPtr"}}] ; edi points to the output buffer
+    xor     ecx, ecx
+su_render_mixloop:                      ; sum the outputs of the threads
+    fldz
+    {{- range $i, $t := .Threads}}
+    {{- $buf := printf "su_thread_buffer_%v" $i}}
+    {{- $.Prepare $buf | indent 4}}
+    fadd    dword [{{$.Use $buf}}+{{$.CX}}*4]
+    {{- end}}
+    {{- if .Output16Bit}}
+    {{.Call "su_clip"}}
+    {{- .Float 32767.0 | .Prepare | indent 4}}
+    fmul    dword [{{.Float 32767.0 | .Use}}]
+    push    {{.AX}}
+    fistp   dword [{{.SP}}]
+    pop     {{.AX}}
+    stosw                               ; store integer converted sample
+    {{- else}}
+    fstp    dword [{{.DI}}]
+    add     {{.DI}}, 4
+    {{- end}}
+    inc     ecx
+    cmp     ecx, {{.MaxSamples}}*2
+    jl      su_render_mixloop
+    {{- template "render_epilogue" .}}
+    {{- if not .Amd64}}
+    ret     4
+    {{- end}}
+
+;-------------------------------------------------------------------------------
+;   su_render_thread function: the entry point of the threads
+;-------------------------------------------------------------------------------
+;   Has the signature su_render_thread(void *ptr), where ptr is a pointer to
+;   the thread descriptor in su_threads. Renders the instruments of the thread
+;   to the output buffer of the thread, as floats.
+;   Stack:  thread_desc_ptr
+;-------------------------------------------------------------------------------
+{{.ThreadFunc "su_render_thread" "ThreadDesc"}}
+    {{-  if .Amd64}}
+    {{- if eq .OS "windows"}}
+    {{- .PushRegs "rcx" "ThreadDesc" "rdi" "NonVolatileRsi" "rsi" "NonVolatile" "rbx" "NonVolatileRbx" "rbp" "NonVolatileRbp" | indent 4}}
+    {{- else}}
+    {{- .PushRegs "rdi" "ThreadDesc" "rbx" "NonVolatileRbx" "rbp" "NonVolatileRbp" | indent 4}}
+    {{- end}}
+    {{- else}}
+    {{- .PushRegs | indent 4}}
+    {{- end}}
+    {{- $prologsize = len .Stacklocs}}
+    mov     {{.SI}}, [{{.Stack "ThreadDesc"}}]
+    {{.Push (printf "%v [%v+%v]" .PTRWORD .SI (mul 3 .PTRSIZE)) "Operands"}}
+    {{.Push (printf "%v [%v+%v]" .PTRWORD .SI (mul 2 .PTRSIZE)) "Opcodes"}}
+    {{.Push (printf "%v [%v+%v]" .PTRWORD .SI .PTRSIZE) "SynthObj"}}
+    {{.Push (printf "%v [%v]" .PTRWORD .SI) "OutputBufPtr"}}
+    {{- end}}
     {{- if or .RowSync (.HasOp "sync")}}
     {{- if or (and (eq .OS "windows") (not .Amd64)) (eq .OS "darwin")}}
     {{- .Prepare "_syncBuf"}}
@@ -59,12 +144,21 @@ su_render_sampleloop:                   ; loop through every sample in the row
             {{.Push (.PolyphonyBitmask | printf "%v") "PolyphonyBitmask"}} ; does the next voice reuse the current opcodes?
             {{- end}}
             {{.Push (.Song.Patch.NumVoices | printf "%v") "VoicesRemain"}}
+            {{- if .Threads}}
+            mov     {{.DX}}, [{{.Stack "SynthObj"}}]             ; {{.DX}} points to the synth object of the thread
+            mov     {{.COM}}, [{{.Stack "Opcodes"}}]             ; COM points to vm code of the thread
+            mov     {{.VAL}}, [{{.Stack "Operands"}}]            ; VAL points to unit params of the thread
+            {{- if or (.HasOp "delay") (.HasOp "limiter")}}
+            lea     {{.CX}}, [{{.DX}} + su_synthworkspace.size - su_delayline_wrk.filtstate]
+            {{- end}}
+            {{- else}}
             mov     {{.DX}}, {{.PTRWORD}} su_synth_obj                       ; {{.DX}} points to the synth object
             mov     {{.COM}}, {{.PTRWORD}} su_patch_opcodes           ; COM points to vm code
             mov     {{.VAL}}, {{.PTRWORD}} su_patch_operands             ; VAL points to unit params
-            {{- if .HasOp "delay"}}
+            {{- if or (.HasOp "delay") (.HasOp "limiter")}}
             mov     {{.CX}}, {{.PTRWORD}} su_synth_obj + su_synthworkspace.size - su_delayline_wrk.filtstate
             {{- end}}
+            {{- end}}
             lea     {{.WRK}}, [{{.DX}} + su_synthworkspace.voices]            ; WRK points to the first voice
             {{.Call "su_run_vm"}} ; run through the VM code
             {{.Pop .AX}}
@@ -85,6 +179,14 @@ su_render_sampleloop:                   ; loop through every sample in the row
     {{- range slice .Stacklocs $prologsize}}
     {{$.Pop $.AX}}
     {{- end}}
+    {{- template "render_epilogue" .}}
+    {{- if and (not .Amd64) (or (not .Threads) (eq .OS "windows"))}}
+    ret     4
+    {{- else if not .Amd64}}
+    ret
+    {{- end}}
+
+{{- define "render_epilogue"}}
     {{-  if .Amd64}}
     {{- if eq .OS "windows"}}
     ; Windows64 ABI, rdi rsi rbx rbp non-volatile
@@ -96,8 +198,8 @@ su_render_sampleloop:                   ; loop through every sample in the row
     ret
     {{- else}}
     {{- .PopRegs | indent 4}}
-    ret     4
     {{- end}}
+{{- end}}
 
 ;-------------------------------------------------------------------------------
 ;   su_update_voices function: polyphonic & chord implementation
@@ -115,12 +217,22 @@ su_render_sampleloop:                   ; loop through every sample in the row
     lea     {{.SI}}, [{{.Use "su_tracks"}}+{{.AX}}]  ; esi points to the pattern data for current track
     xor     eax, eax                            ; eax is the first voice of next track
     xor     ebx, ebx                            ; ebx is the first voice of current track
+    {{- if .Threads}}
+    mov     {{.BP}}, [{{.Stack "SynthObj"}}]         ; ebp points to the current_voiceno array of the thread
+    {{- else}}
     mov     {{.BP}}, {{.PTRWORD}} su_synth_obj           ; ebp points to the current_voiceno array
+    {{- end}}
 su_update_voices_trackloop:
         movzx   eax, byte [{{.SI}}]                     ; eax = current pattern
         imul    eax, {{.PatternLength}}                   ; eax = offset to current pattern data
 {{- .Prepare "su_patterns" .AX | indent 4}}
         movzx   eax,byte [{{.Use "su_patterns" .AX}} + {{.DX}}]  ; eax = note
+{{- if .Transpositions}}
+        cmp     al, {{.Hold}}
+        jbe     short su_update_voices_nottransposed ; holds and releases are not transposed
+        add     al, byte [{{.SI}} + su_transpositions - su_tracks] ; note += transposition of the current pattern
+su_update_voices_nottransposed:
+{{- end}}
         push    {{.DX}}                                 ; Stack: ptrnrow
         xor     edx, edx                            ; edx=0
         mov     ecx, ebx                            ; ecx=first voice of the track to be done
@@ -136,8 +248,13 @@ su_calculate_voices_loop:                           ; do {
         mov     edi, ecx
         add     edi, ebx
         shl     edi, 12           ; each unit = 64 bytes and there are 1<<MAX_UNITS_SHIFT units + small header
+{{- if .Threads}}
+        add     {{.DI}}, [{{.Stack "SynthObj"}} + {{mul 2 .PTRSIZE}}] ; Stack: next_instr ptrnrow
+        and     dword [{{.DI}} + su_synthworkspace.voices + su_voice.sustain], 0 ; set the voice currently active to release; notice that it could increment any number of times
+{{- else}}
 {{- .Prepare "su_synth_obj" | indent 4}}
         and     dword [{{.Use "su_synth_obj"}} + su_synthworkspace.voices + su_voice.sustain + {{.DI}}], 0 ; set the voice currently active to release; notice that it could increment any number of times
+{{- end}}
         cmp     al, {{.Hold}}                    ; if cl < HLD (no new note triggered)
         jl      su_update_voices_nexttrack          ;   goto nexttrack
         inc     ecx                                 ; curvoice++
@@ -148,7 +265,12 @@ su_update_voices_skipreset:
         mov     byte [{{.BP}}],cl
         add     ecx, ebx
         shl     ecx, 12                           ; each unit = 64 bytes and there are 1<<6 units + small header
+{{- if .Threads}}
+        add     {{.CX}}, [{{.Stack "SynthObj"}} + {{mul 2 .PTRSIZE}}] ; Stack: next_instr ptrnrow
+        lea     {{.DI}},[{{.CX}} + su_synthworkspace.voices]
+{{- else}}
         lea     {{.DI}},[{{.Use "su_synth_obj"}} + su_synthworkspace.voices + {{.CX}}]
+{{- end}}
         stosd                                       ; save note
         stosd                                       ; save release
         mov     ecx, (su_voice.size - su_voice.inputs)/4
@@ -159,9 +281,14 @@ su_update_voices_nexttrack:
         pop     {{.DX}}                                 ; edx=patrnrow
         add     {{.SI}}, {{.SequenceLength}}
         inc     {{.BP}}
+{{- if .Threads}}
+        lea     {{.AX}}, [{{.BP}} - {{len .Song.Score.Tracks}}]
+        cmp     {{.AX}}, [{{.Stack "SynthObj"}}]
+{{- else}}
 {{- $addrname := len .Song.Score.Tracks | printf "su_synth_obj + %v"}}
 {{- .Prepare $addrname | indent 8}}
         cmp     {{.BP}},{{.Use $addrname}}
+{{- end}}
         jl      su_update_voices_trackloop
     ret
 {{- else}}
@@ -172,13 +299,24 @@ su_update_voices_nexttrack:
     div     ebx                                 ; eax = current pattern, edx = current row in pattern
 {{- .Prepare "su_tracks" | indent 4}}
     lea     {{.SI}}, [{{.Use "su_tracks"}}+{{.AX}}]; esi points to the pattern data for current track
+    {{- if .Threads}}
+    mov     {{.DI}}, [{{.Stack "SynthObj"}}]
+    add     {{.DI}}, su_synthworkspace.voices
+    {{- else}}
     mov     {{.DI}}, {{.PTRWORD}} su_synth_obj+su_synthworkspace.voices
+    {{- end}}
     mov     bl, {{len .Song.Score.Tracks}}                      ; MAX_TRACKS is always <= 32 so this is ok
 su_update_voices_trackloop:
         movzx   eax, byte [{{.SI}}]                     ; eax = current pattern
         imul    eax, {{.PatternLength}}           ; multiply by rows per pattern, eax = offset to current pattern data
 {{- .Prepare "su_patterns" .AX | indent 8}}
         movzx   eax, byte [{{.Use "su_patterns" .AX}} + {{.DX}}]  ; ecx = note
+{{- if .Transpositions}}
+        cmp     al, {{.Hold}}
+        jbe     short su_update_voices_nottransposed ; holds and releases are not transposed
+        add     al, byte [{{.SI}} + su_transpositions - su_tracks] ; note += transposition of the current pattern
+su_update_voices_nottransposed:
+{{- end}}
         cmp     al, {{.Hold}}                   ; anything but hold causes action
         je      short su_update_voices_nexttrack
         mov     dword [{{.DI}}+su_voice.sustain], eax     ; set the voice currently active to release
@@ -217,6 +355,13 @@ su_update_voices_skipadd:
     db {{. | toStrings | join ","}}
 {{- end}}
 
+{{- if .Transpositions}}
+su_transpositions:                                  ; in the same section as su_tracks, as they are addressed relative to each other
+{{- range .Transpositions}}
+    db {{. | toStrings | join ","}}
+{{- end}}
+{{- end}}
+
 {{- if gt (.SampleOffsets | len) 0}}
 ;-------------------------------------------------------------------------------
 ;    Sample offsets
@@ -229,6 +374,32 @@ su_update_voices_skipadd:
 {{- end}}
 {{end}}
 
+{{- if .WavetableOffsets}}
+;-------------------------------------------------------------------------------
+;    Wavetable offsets: first frame and number of frames of each wavetable
+;-------------------------------------------------------------------------------
+{{.Data "su_wavetable_offsets"}}
+{{- range .WavetableOffsets}}
+    dw {{.Start}},{{.NumFrames}}
+{{- end}}
+
+;-------------------------------------------------------------------------------
+;    Wavetables: the frames of all wavetables used by the song
+;-------------------------------------------------------------------------------
+{{.Data "su_wavetables"}}
+{{- template "samplebank" .WavetableBank}}
+{{end}}
+
+{{- if .NoteTable}}
+;-------------------------------------------------------------------------------
+;    Note table: the tuned pitch of each note, in semitones
+;-------------------------------------------------------------------------------
+{{.Data "su_note_table"}}
+{{- range .NoteTable}}
+    dd {{printf "%.8e" .}}
+{{- end}}
+{{end}}
+
 {{- if gt (.DelayTimes | len ) 0}}
 ;-------------------------------------------------------------------------------
 ;    Delay times
@@ -240,14 +411,37 @@ su_update_voices_skipadd:
 ;-------------------------------------------------------------------------------
 ;    The code for this patch, basically indices to vm jump table
 ;-------------------------------------------------------------------------------
+{{- if .Threads}}
+{{- range $i, $t := .Threads}}
+{{$.Data (printf "su_patch_opcodes_%v" $i)}}
+    db {{$t.Opcodes | toStrings | join ","}}
+{{- end}}
+{{- else}}
 {{.Data "su_patch_opcodes"}}
     db {{.Opcodes | toStrings | join ","}}
+{{- end}}
 
 ;-------------------------------------------------------------------------------
 ;    The parameters / inputs to each opcode
 ;-------------------------------------------------------------------------------
+{{- if .Threads}}
+{{- range $i, $t := .Threads}}
+{{$.Data (printf "su_patch_operands_%v" $i)}}
+    db {{$t.Operands | toStrings | join ","}}
+{{- end}}
+
+;-------------------------------------------------------------------------------
+;    Threads: the output buffer, synth object, opcodes and operands of each
+;    thread
+;-------------------------------------------------------------------------------
+{{.Data "su_threads"}}
+{{- range $i, $t := .Threads}}
+    {{$.DPTR}} su_thread_buffer_{{$i}}, su_synth_obj_{{$i}}, su_patch_opcodes_{{$i}}, su_patch_operands_{{$i}}
+{{- end}}
+{{- else}}
 {{.Data "su_patch_operands"}}
     db {{.Operands | toStrings | join ","}}
+{{- end}}
 
 ;-------------------------------------------------------------------------------
 ;    Constants
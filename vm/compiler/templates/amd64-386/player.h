@@ -56,7 +56,7 @@ extern "C" {
 {{- end}}
 void SU_CALLCONV su_render_song(SUsample *buffer);
 
-{{- if gt (.SampleOffsets | len) 0}}
+{{- if and (gt (.SampleOffsets | len) 0) .UsesGmDls}}
 void SU_CALLCONV su_load_gmdls();
 #define SU_LOAD_GMDLS
 {{- end}}
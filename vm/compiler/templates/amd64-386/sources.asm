@@ -105,6 +105,17 @@ su_op_noise_mono:
     xchg    ecx, dword [{{.Modulation "oscillator" "frequency"}}]
 {{- end}}
     lodsb                                   ; load the flags
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+    fld     dword [{{.VAL}}]                ; w, frequency of a tempo synced lfo (0 = not synced, negative = phase locked to song time)
+    fabs                                    ; |w|
+    fimul   dword [{{.Stack "GlobalTick"}}] ; |w|*t
+    fld1                                    ; 1 |w|*t
+    fxch                                    ; |w|*t 1
+    fprem                                   ; mod(|w|*t,1) 1
+    fstp    st1                             ; p, the phase if locked to song time
+    fstp    dword [{{.INP}}+28]             ; input 7 is unused by the oscillator, so stash p there
+    lea     {{.VAL}}, [{{.VAL}}+4]          ; skip the frequency operand; lea does not touch the carry flag, which tells if we are stereo
+{{- end}}
 {{- if .Library}}
     mov     {{.DI}}, [{{.Stack "SampleTable"}}]; we need to put this in a register, as the stereo & unisons screw the stack positions
                                  ; ain't we lucky that {{.DI}} was unused throughout
@@ -156,6 +167,19 @@ su_op_oscillat_unison_out:
     {{.PopRegs .AX .WRK .AX}}
     ret
 su_op_oscillat_single:
+{{- end}}
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+    cmp     dword [{{.VAL}}-4], 0           ; is this a tempo synced lfo?
+    je      short su_op_oscillat_not_synced
+    fstp    st0                             ; synced lfos ignore transpose and detune
+    jg      short su_op_oscillat_note_reset ; positive w: phase is reset on note trigger as usual
+    fld     dword [{{.INP}}+28]             ; p
+    fstp    dword [{{.WRK}}]                ; phase is locked to the song time, overwrite the phase
+su_op_oscillat_note_reset:
+    fld     dword [{{.VAL}}-4]              ; w
+    fabs                                    ; |w|
+    jmp     su_op_oscillat_normalized
+su_op_oscillat_not_synced:
 {{- end}}
     fld     dword [{{.Input "oscillator" "transpose"}}]
 {{- .Float 0.5 | .Prepare}}
@@ -167,7 +191,16 @@ su_op_oscillat_single:
     test    al, byte 0x08
     jnz     su_op_oscillat_skipnote
 {{- end}}
+{{- if .NoteTable}}
+    push    {{.AX}}
+    mov     eax, dword [{{.INP}}-su_voice.inputs+su_voice.note]
+    and     eax, 127 ; the note table has 128 entries
+{{- .Prepare "su_note_table" | indent 4}}
+    fadd    dword [{{.Use "su_note_table"}} + {{.AX}}*4] ; // st0 is tuned note, st1 is t+d offset
+    pop     {{.AX}}
+{{- else}}
     fiadd   dword [{{.INP}}-su_voice.inputs+su_voice.note]   ; // st0 is note, st1 is t+d offset
+{{- end}}
 {{- if .SupportsParamValue "oscillator" "lfo" 1}}
 su_op_oscillat_skipnote:
 {{- end}}
@@ -218,6 +251,13 @@ su_op_oscillat_not_sample:
 {{- end}}
     fld     dword [{{.Input "oscillator" "color"}}]               ; // c      p
     ; every oscillator test included if needed
+{{- if .WavetableOffsets}}
+    test    al, byte 0x74            ; wavetable is the only type without a type bit
+    jnz     short su_op_oscillat_not_wavetable
+    {{.Call "su_oscillat_wavetable"}}
+    jmp     su_op_oscillat_gain ; skip waveshaping as the shape parameter is reused as the position in the wavetable
+su_op_oscillat_not_wavetable:
+{{- end}}
 {{- if .SupportsParamValue "oscillator" "type" .Sine}}
     test    al, byte 0x40
     jz      short su_op_oscillat_notsine
@@ -314,7 +354,7 @@ su_oscillat_sine_do:
     fld1                                    ; 1
     pop     {{.AX}}
     and     al, 0xf                         ; ax=int(16*p) & 15, stack: 1
-    bt      word [{{.VAL}}-4],ax                 ; if bit ax of the gate word is set
+    bt      word [{{.VAL}}-{{if .SupportsParamValueOtherThan "oscillator" "rate" 0}}8{{else}}4{{end}}],ax                 ; if bit ax of the gate word is set
     jc      su_oscillat_gate_bit                ;   goto gate_bit
     fsub    st0, st0                        ; stack: 0
 su_oscillat_gate_bit:                           ; stack: 0/1, let's call it x
@@ -334,7 +374,7 @@ su_oscillat_gate_bit:                           ; stack: 0/1, let's call it x
 {{.Func "su_oscillat_sample"}}
     {{- .PushRegs .AX "SampleAx" .DX "SampleDx" .CX "SampleCx" .BX "SampleBx" .DI "SampleDi" | indent 4}}                              ; edx must be saved, eax & ecx if this is stereo osc
     push    {{.AX}}
-    mov     al, byte [{{.VAL}}-4]                                ; reuse "color" as the sample number
+    mov     al, byte [{{.VAL}}-{{if .SupportsParamValueOtherThan "oscillator" "rate" 0}}8{{else}}4{{end}}]                                ; reuse "color" as the sample number
 {{- if .Library}}
     lea     {{.DI}}, [{{.DI}} + {{.AX}}*8]                           ; edi points now to the sample table entry
 {{- else}}
@@ -364,6 +404,73 @@ su_oscillat_sample_not_looping:
 {{end}}
 
 
+{{- if .HasCall "su_oscillat_wavetable"}}
+{{.Func "su_oscillat_wavetable"}}
+    fstp    st0                                     ; p, color is reused as the wavetable number
+    {{- .PushRegs .AX "WavetableAx" .DX "WavetableDx" .CX "WavetableCx" .BX "WavetableBx" .DI "WavetableDi" | indent 4}}
+    movzx   eax, byte [{{.VAL}}-{{if .SupportsParamValueOtherThan "oscillator" "rate" 0}}8{{else}}4{{end}}] ; reuse "color" as the wavetable number
+{{- .Prepare "su_wavetable_offsets" | indent 4}}
+    movzx   ecx, word [{{.Use "su_wavetable_offsets"}} + {{.AX}}*4]     ; ecx = first frame of the wavetable
+    movzx   ebx, word [{{.Use "su_wavetable_offsets"}} + {{.AX}}*4 + 2] ; ebx = N, the number of frames
+    shl     ecx, 8                                  ; ecx = offset of the first frame, in words
+{{- .Float 256.0 | .Prepare | indent 4}}
+    fmul    dword [{{.Float 256.0 | .Use}}]         ; x=256*p
+    fld     st0                                     ; x x
+{{- .Float 0.5 | .Prepare | indent 4}}
+    fsub    dword [{{.Float 0.5 | .Use}}]           ; x-.5 x
+    push    {{.AX}}
+    fistp   dword [{{.SP}}]                         ; x, dword [{{.SP}}] = j = floor(x)
+    fisub   dword [{{.SP}}]                         ; b=x-j, where b is the interpolation coefficient within a frame
+    pop     {{.DI}}                                 ; edi = j
+    fld     dword [{{.Input "oscillator" "shape"}}] ; s b, shape is reused as the position in the wavetable
+    dec     ebx                                     ; ebx = N-1
+    push    {{.BX}}
+    fimul   dword [{{.SP}}]                         ; f=s*(N-1) b
+    fld     st0                                     ; f f b
+{{- .Float 0.5 | .Prepare | indent 4}}
+    fsub    dword [{{.Float 0.5 | .Use}}]           ; f-.5 f b
+    fistp   dword [{{.SP}}]                         ; f b, dword [{{.SP}}] = i = floor(f)
+    fisub   dword [{{.SP}}]                         ; a=f-i b, where a is the interpolation coefficient between frames
+    pop     {{.AX}}                                 ; eax = i
+    inc     ebx                                     ; ebx = N
+    xor     edx, edx                                ; div wants edx to be empty
+    div     ebx                                     ; edx = i mod N, so out of range positions wrap around
+    mov     eax, edx                                ; eax = i0
+    inc     edx
+    cmp     edx, ebx
+    jb      su_oscillat_wavetable_nowrap
+    xor     edx, edx
+su_oscillat_wavetable_nowrap:                       ; edx = i1 = (i0+1) mod N
+    fxch                                            ; b a
+    call    su_oscillat_wavetable_frame             ; s0 b a
+    mov     eax, edx
+    fxch                                            ; b s0 a
+    call    su_oscillat_wavetable_frame             ; s1 b s0 a
+    fstp    st1                                     ; s1 s0 a
+    fsub    st0, st1                                ; s1-s0 s0 a
+    fmulp   st2, st0                                ; s0 a*(s1-s0)
+    faddp   st1, st0                                ; s0+a*(s1-s0)
+{{- .Float 32767.0 | .Prepare | indent 4}}
+    fdiv    dword [{{.Float 32767.0 | .Use}}]
+    {{- .PopRegs .AX .DX .CX .BX .DI | indent 4}}
+    ret
+su_oscillat_wavetable_frame:                        ; b, eax = frame number, ecx = offset of the first frame, edi = j
+    shl     eax, 8
+    add     eax, ecx                                ; eax = offset of the frame, in words
+    lea     ebx, [{{.AX}}+{{.DI}}]
+{{- .Prepare "su_wavetables" | indent 4}}
+    fild    word [{{.Use "su_wavetables"}} + {{.BX}}*2] ; s0 b, where s0 = w[j]
+    mov     ebx, edi
+    inc     bl                                      ; ebx = (j+1) & 255
+    add     ebx, eax
+    fild    word [{{.Use "su_wavetables"}} + {{.BX}}*2] ; s1 s0 b, where s1 = w[j+1]
+    fsub    st0, st1                                ; s1-s0 s0 b
+    fmul    st0, st2                                ; b*(s1-s0) s0 b
+    faddp   st1, st0                                ; s0+b*(s1-s0) b
+    ret
+{{end}}
+
+
 {{- if .HasOp "loadval"}}
 ;-------------------------------------------------------------------------------
 ;   LOADVAL opcode
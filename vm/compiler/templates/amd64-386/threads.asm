@@ -0,0 +1,141 @@
+
+;-------------------------------------------------------------------------------
+;   Starts one su_render_thread per thread and waits until all of them have
+;   finished. Each thread renders its instruments to its own buffer; the buffers
+;   are mixed afterwards by su_render_song.
+;-------------------------------------------------------------------------------
+{{- if .Amd64}}
+{{- if eq .OS "windows"}}
+    extern CreateThread ; requires windows
+    extern WaitForSingleObject ; requires windows
+    mov     rbp, rsp
+    and     rsp, -16                    ; Win64 ABI requires 16-byte aligned stack
+    sub     rsp, 48                     ; shadow space + space for two parameters
+    xor     ebx, ebx
+su_threads_startloop:
+    xor     ecx, ecx                    ; default security attributes
+    xor     edx, edx                    ; default stack size
+    mov     r8, qword su_render_thread
+    imul    r9d, ebx, {{mul 4 .PTRSIZE}}
+    mov     rax, qword su_threads
+    add     r9, rax                     ; r9 = &su_threads[ebx]
+    mov     qword [rsp+32], rdx         ; start the thread immediately
+    mov     qword [rsp+40], rdx         ; thread id is not needed
+    call    CreateThread                ; rax = CreateThread(NULL,0,su_render_thread,&su_threads[ebx],0,NULL)
+    mov     rcx, qword su_thread_handles
+    mov     [rcx+rbx*8], rax
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_startloop
+    xor     ebx, ebx
+su_threads_joinloop:
+    mov     rcx, qword su_thread_handles
+    mov     rcx, [rcx+rbx*8]
+    or      edx, -1                     ; INFINITE
+    call    WaitForSingleObject         ; WaitForSingleObject(su_thread_handles[ebx],INFINITE)
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_joinloop
+    mov     rsp, rbp
+{{- else}}
+{{- $create := "pthread_create wrt ..plt"}}
+{{- $join := "pthread_join wrt ..plt"}}
+{{- if eq .OS "darwin"}}
+{{- $create = "_pthread_create"}}
+{{- $join = "_pthread_join"}}
+    extern _pthread_create
+    extern _pthread_join
+{{- else}}
+    extern pthread_create
+    extern pthread_join
+{{- end}}
+    mov     rbp, rsp
+    and     rsp, -16                    ; SystemV ABI requires 16-byte aligned stack
+    xor     ebx, ebx
+su_threads_startloop:
+    mov     rdi, qword su_thread_handles
+    lea     rdi, [rdi+rbx*8]
+    xor     esi, esi                    ; default attributes
+    mov     rdx, qword su_render_thread
+    imul    ecx, ebx, {{mul 4 .PTRSIZE}}
+    mov     rax, qword su_threads
+    add     rcx, rax                    ; rcx = &su_threads[ebx]
+    call    {{$create}}  ; pthread_create(&su_thread_handles[ebx],NULL,su_render_thread,&su_threads[ebx])
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_startloop
+    xor     ebx, ebx
+su_threads_joinloop:
+    mov     rdi, qword su_thread_handles
+    mov     rdi, [rdi+rbx*8]
+    xor     esi, esi                    ; the return value is not needed
+    call    {{$join}}  ; pthread_join(su_thread_handles[ebx],NULL)
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_joinloop
+    mov     rsp, rbp
+{{- end}}
+{{- else}}
+{{- if eq .OS "windows"}}
+    xor     ebx, ebx
+su_threads_startloop:
+    push    0                           ; thread id is not needed
+    push    0                           ; start the thread immediately
+    imul    eax, ebx, {{mul 4 .PTRSIZE}}
+    add     eax, su_threads
+    push    eax                         ; &su_threads[ebx]
+    push    su_render_thread
+    push    0                           ; default stack size
+    push    0                           ; default security attributes
+    call    dword [__imp__CreateThread@24] ; eax = CreateThread(NULL,0,su_render_thread,&su_threads[ebx],0,NULL)
+    mov     [su_thread_handles+ebx*4], eax
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_startloop
+    xor     ebx, ebx
+su_threads_joinloop:
+    push    -1                          ; INFINITE
+    push    dword [su_thread_handles+ebx*4]
+    call    dword [__imp__WaitForSingleObject@8] ; WaitForSingleObject(su_thread_handles[ebx],INFINITE)
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_joinloop
+extern __imp__CreateThread@24 ; requires windows
+extern __imp__WaitForSingleObject@8
+{{- else}}
+{{- $create := "pthread_create"}}
+{{- $join := "pthread_join"}}
+{{- if eq .OS "darwin"}}
+{{- $create = "_pthread_create"}}
+{{- $join = "_pthread_join"}}
+{{- end}}
+    extern {{$create}}
+    extern {{$join}}
+    mov     ebp, esp
+    and     esp, -16                    ; keep the stack aligned for the calls
+    sub     esp, 16                     ; space for four parameters
+    xor     ebx, ebx
+su_threads_startloop:
+    lea     eax, [su_thread_handles+ebx*4]
+    mov     [esp], eax
+    mov     dword [esp+4], 0            ; default attributes
+    mov     dword [esp+8], su_render_thread
+    imul    eax, ebx, {{mul 4 .PTRSIZE}}
+    add     eax, su_threads
+    mov     [esp+12], eax               ; &su_threads[ebx]
+    call    {{$create}}
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_startloop
+    xor     ebx, ebx
+su_threads_joinloop:
+    mov     eax, [su_thread_handles+ebx*4]
+    mov     [esp], eax
+    mov     dword [esp+4], 0            ; the return value is not needed
+    call    {{$join}}
+    inc     ebx
+    cmp     ebx, {{len .Threads}}
+    jl      su_threads_joinloop
+    mov     esp, ebp
+{{- end}}
+{{- end}}
@@ -0,0 +1,153 @@
+{{- if .HasOp "pop"}}
+//------------------------------------------------------------------------------
+//   POP opcode: remove (discard) the topmost signal from the stack
+//------------------------------------------------------------------------------
+//   Mono:   a -> (empty)
+//   Stereo: a b -> (empty)
+//------------------------------------------------------------------------------
+static void su_op_pop(int stereo) {
+    su_sp -= 1 + stereo;
+}
+{{end}}
+
+{{- if .HasOp "add"}}
+//------------------------------------------------------------------------------
+//   ADD opcode: add the two top most signals on the stack
+//------------------------------------------------------------------------------
+//   Mono:   a b -> a+b b
+//   Stereo: a b c d -> a+c b+d c d
+//------------------------------------------------------------------------------
+static void su_op_add(int stereo) {
+{{- if .Stereo "add"}}
+    if (stereo) {
+        su_sp[-1] += su_sp[-3];
+        su_sp[-2] += su_sp[-4];
+        return;
+    }
+{{- end}}
+    su_sp[-1] += su_sp[-2];
+}
+{{end}}
+
+{{- if .HasOp "addp"}}
+//------------------------------------------------------------------------------
+//   ADDP opcode: add the two top most signals on the stack and pop
+//------------------------------------------------------------------------------
+//   Mono:   a b -> a+b
+//   Stereo: a b c d -> a+c b+d
+//------------------------------------------------------------------------------
+static void su_op_addp(int stereo) {
+{{- if .Stereo "addp"}}
+    if (stereo) {
+        su_sp[-3] += su_sp[-1];
+        su_sp[-4] += su_sp[-2];
+        su_sp -= 2;
+        return;
+    }
+{{- end}}
+    su_sp[-2] += su_sp[-1];
+    su_sp--;
+}
+{{end}}
+
+{{- if .HasOp "loadnote"}}
+//------------------------------------------------------------------------------
+//   LOADNOTE opcode: load the current note, scaled to [-1,1]
+//------------------------------------------------------------------------------
+//   Mono:   push the note on stack
+//   Stereo: push the note on stack twice
+//------------------------------------------------------------------------------
+static void su_op_loadnote(int stereo) {
+    float note = su_tuned_note() / 64.0f - 1.0f;
+{{- if .Stereo "loadnote"}}
+    if (stereo)
+        *su_sp++ = note;
+{{- end}}
+    *su_sp++ = note;
+}
+{{end}}
+
+{{- if .HasOp "mul"}}
+//------------------------------------------------------------------------------
+//   MUL opcode: multiply the two top most signals on the stack
+//------------------------------------------------------------------------------
+//   Mono:   a b -> a*b a
+//   Stereo: a b c d -> a*c b*d c d
+//------------------------------------------------------------------------------
+static void su_op_mul(int stereo) {
+{{- if .Stereo "mul"}}
+    if (stereo) {
+        su_sp[-1] *= su_sp[-3];
+        su_sp[-2] *= su_sp[-4];
+        return;
+    }
+{{- end}}
+    su_sp[-1] *= su_sp[-2];
+}
+{{end}}
+
+{{- if .HasOp "mulp"}}
+//------------------------------------------------------------------------------
+//   MULP opcode: multiply the two top most signals on the stack and pop
+//------------------------------------------------------------------------------
+//   Mono:   a b -> a*b
+//   Stereo: a b c d -> a*c b*d
+//------------------------------------------------------------------------------
+static void su_op_mulp(int stereo) {
+{{- if .Stereo "mulp"}}
+    if (stereo) {
+        su_sp[-3] *= su_sp[-1];
+        su_sp[-4] *= su_sp[-2];
+        su_sp -= 2;
+        return;
+    }
+{{- end}}
+    su_sp[-2] *= su_sp[-1];
+    su_sp--;
+}
+{{end}}
+
+{{- if .HasOp "push"}}
+//------------------------------------------------------------------------------
+//   PUSH opcode: push the topmost signal on the stack
+//------------------------------------------------------------------------------
+//   Mono:   a -> a a
+//   Stereo: a b -> a b a b
+//------------------------------------------------------------------------------
+static void su_op_push(int stereo) {
+{{- if .Stereo "push"}}
+    if (stereo) {
+        su_sp[0] = su_sp[-2];
+        su_sp[1] = su_sp[-1];
+        su_sp += 2;
+        return;
+    }
+{{- end}}
+    su_sp[0] = su_sp[-1];
+    su_sp++;
+}
+{{end}}
+
+{{- if .HasOp "xch"}}
+//------------------------------------------------------------------------------
+//   XCH opcode: exchange the signals on the stack
+//------------------------------------------------------------------------------
+//   Mono:   a b -> b a
+//   Stereo: a b c d -> c d a b
+//------------------------------------------------------------------------------
+static void su_op_xch(int stereo) {
+    float t = su_sp[-1];
+{{- if .Stereo "xch"}}
+    if (stereo) {
+        su_sp[-1] = su_sp[-3];
+        su_sp[-3] = t;
+        t = su_sp[-2];
+        su_sp[-2] = su_sp[-4];
+        su_sp[-4] = t;
+        return;
+    }
+{{- end}}
+    su_sp[-1] = su_sp[-2];
+    su_sp[-2] = t;
+}
+{{end}}
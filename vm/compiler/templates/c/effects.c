@@ -0,0 +1,347 @@
+{{- if .HasOp "distort"}}
+//------------------------------------------------------------------------------
+//   DISTORT opcode: apply distortion on the signal
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  x*k/(1-k+(2*k-1)*abs(x))            where k is drive
+//   Stereo: l r ->  l*k/(1-k+(2*k-1)*abs(l)) r*k/(1-k+(2*k-1)*abs(r))
+//------------------------------------------------------------------------------
+static void su_op_distort(int stereo) {
+    int i;
+    for (i = 1; i <= 1 + stereo; i++)
+        su_sp[-i] = su_waveshaper(su_sp[-i], su_transformed[{{.InputNumber "distort" "drive"}}]);
+}
+{{end}}
+
+{{- if .HasOp "hold"}}
+//------------------------------------------------------------------------------
+//   HOLD opcode: sample and hold the signal, reducing sample rate
+//------------------------------------------------------------------------------
+//   Mono version:   holds the signal at a rate defined by the freq parameter
+//   Stereo version: holds both channels
+//------------------------------------------------------------------------------
+static void su_op_hold(int stereo) {
+    float freq2 = su_transformed[{{.InputNumber "hold" "holdfreq"}}] * su_transformed[{{.InputNumber "hold" "holdfreq"}}];
+    int i;
+    for (i = 0; i <= stereo; i++) {
+        float phase = su_wrk->state[i] - freq2;
+        if (phase <= 0) {
+            su_wrk->state[2 + i] = su_sp[-1 - i];
+            phase += 1;
+        }
+        su_sp[-1 - i] = su_wrk->state[2 + i];
+        su_wrk->state[i] = phase;
+    }
+}
+{{end}}
+
+{{- if .HasOp "crush"}}
+//------------------------------------------------------------------------------
+//   CRUSH opcode: quantize the signal to finite number of levels
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  e*round(x/e)            where e=2**(-24*resolution)
+//   Stereo: l r ->  e*round(l/e) e*round(r/e)
+//------------------------------------------------------------------------------
+static void su_op_crush(int stereo) {
+    float e = su_nonlinear_map(su_transformed[{{.InputNumber "crush" "resolution"}}]);
+    int i;
+    for (i = 1; i <= 1 + stereo; i++)
+        su_sp[-i] = (float)(round(su_sp[-i] / e) * e);
+}
+{{end}}
+
+{{- if .HasOp "gain"}}
+//------------------------------------------------------------------------------
+//   GAIN opcode: apply gain on the signal
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  x*g
+//   Stereo: l r ->  l*g r*g
+//------------------------------------------------------------------------------
+static void su_op_gain(int stereo) {
+{{- if .Stereo "gain"}}
+    if (stereo)
+        su_sp[-2] *= su_transformed[{{.InputNumber "gain" "gain"}}];
+{{- end}}
+    su_sp[-1] *= su_transformed[{{.InputNumber "gain" "gain"}}];
+}
+{{end}}
+
+{{- if .HasOp "invgain"}}
+//------------------------------------------------------------------------------
+//   INVGAIN opcode: apply inverse gain on the signal
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  x/g
+//   Stereo: l r ->  l/g r/g
+//------------------------------------------------------------------------------
+static void su_op_invgain(int stereo) {
+{{- if .Stereo "invgain"}}
+    if (stereo)
+        su_sp[-2] /= su_transformed[{{.InputNumber "invgain" "invgain"}}];
+{{- end}}
+    su_sp[-1] /= su_transformed[{{.InputNumber "invgain" "invgain"}}];
+}
+{{end}}
+
+{{- if .HasOp "dbgain"}}
+//------------------------------------------------------------------------------
+//   DBGAIN opcode: apply gain on the signal, with gain given in decibels
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  x*g, where g = 2**((2*d-1)*6.643856189774724) i.e. -40dB to 40dB, d=[0..1]
+//   Stereo: l r ->  l*g r*g
+//------------------------------------------------------------------------------
+static void su_op_dbgain(int stereo) {
+    float g = (float)pow(2, (su_transformed[{{.InputNumber "dbgain" "decibels"}}] * 2 - 1) * 6.643856189774724);
+{{- if .Stereo "dbgain"}}
+    if (stereo)
+        su_sp[-2] *= g;
+{{- end}}
+    su_sp[-1] *= g;
+}
+{{end}}
+
+{{- if .HasOp "filter"}}
+//------------------------------------------------------------------------------
+//   FILTER opcode: perform low/high/band-pass/notch etc. filtering on the signal
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  filtered(x)
+//   Stereo: l r ->  filtered(l) filtered(r)
+//------------------------------------------------------------------------------
+static void su_op_filter(int stereo) {
+    float freq2 = su_transformed[{{.InputNumber "filter" "frequency"}}] * su_transformed[{{.InputNumber "filter" "frequency"}}];
+    float res = su_transformed[{{.InputNumber "filter" "resonance"}}];
+    int flags = *su_val++;
+    int i;
+    for (i = 0; i <= stereo; i++) {
+        float low = su_wrk->state[i], band = su_wrk->state[2 + i], high, output = 0;
+        low += freq2 * band;
+        high = su_sp[-1 - i] - low - res * band;
+        band += freq2 * high;
+        su_wrk->state[i] = low;
+        su_wrk->state[2 + i] = band;
+        if (flags & 0x40)
+            output += low;
+        if (flags & 0x20)
+            output += band;
+        if (flags & 0x10)
+            output += high;
+        if (flags & 0x08)
+            output -= band;
+        if (flags & 0x04)
+            output -= high;
+        su_sp[-1 - i] = output;
+    }
+}
+{{end}}
+
+{{- if .HasOp "belleq"}}
+//------------------------------------------------------------------------------
+//   BELLEQ opcode: peaking bell filter, implemented as a biquad
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  filtered(x)
+//   Stereo: l r ->  filtered(l) filtered(r)
+//------------------------------------------------------------------------------
+static void su_op_belleq(int stereo) {
+    float omega0 = 2 * su_transformed[{{.InputNumber "belleq" "frequency"}}] * su_transformed[{{.InputNumber "belleq" "frequency"}}];
+    float alpha = (float)sin(omega0) * 2 * su_transformed[{{.InputNumber "belleq" "bandwidth"}}];
+    float A = (float)pow(2, (su_transformed[{{.InputNumber "belleq" "gain"}}] - .5f) * 6.643856189774724);
+    float u = alpha * A, v = alpha / A;
+    float b0 = 1 + u, b1 = -2 * (float)cos(omega0), b2 = 1 - u;
+    float a0 = 1 + v, a1 = b1, a2 = 1 - v;
+    int i;
+    for (i = 0; i <= stereo; i++) { // transposed direct form II
+        float x = su_sp[-1 - i];
+        float y = (b0 * x + su_wrk->state[i]) / a0; // the biquad is not in normalized form, so divide by a0
+        su_wrk->state[i] = b1 * x - a1 * y + su_wrk->state[2 + i];
+        su_wrk->state[2 + i] = b2 * x - a2 * y;
+        su_sp[-1 - i] = y;
+    }
+}
+{{end}}
+
+{{- if .HasOp "clip"}}
+//------------------------------------------------------------------------------
+//   CLIP opcode: clips the signal into [-1,1] range
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  min(max(x,-1),1)
+//   Stereo: l r ->  min(max(l,-1),1) min(max(r,-1),1)
+//------------------------------------------------------------------------------
+static void su_op_clip(int stereo) {
+{{- if .Stereo "clip"}}
+    if (stereo)
+        su_sp[-2] = su_clip(su_sp[-2]);
+{{- end}}
+    su_sp[-1] = su_clip(su_sp[-1]);
+}
+{{end}}
+
+{{- if .HasOp "pan"}}
+//------------------------------------------------------------------------------
+//   PAN opcode: pan the signal
+//------------------------------------------------------------------------------
+//   Mono:   s   ->  s*(1-p) s*p
+//   Stereo: l r ->  l*(1-p) r*p
+//
+//   where p is the panning in [0,1] range
+//------------------------------------------------------------------------------
+static void su_op_pan(int stereo) {
+{{- if .Mono "pan"}}
+    if (!stereo) {
+        su_sp[0] = su_sp[-1];
+        su_sp++;
+    }
+{{- end}}
+    su_sp[-2] *= su_transformed[{{.InputNumber "pan" "panning"}}];
+    su_sp[-1] *= 1 - su_transformed[{{.InputNumber "pan" "panning"}}];
+}
+{{end}}
+
+{{- if .HasOp "width"}}
+//------------------------------------------------------------------------------
+//   WIDTH opcode: scale the side signal of a stereo signal
+//------------------------------------------------------------------------------
+//   Stereo: l r ->  m+s*w m-s*w, where m = (l+r)/2, s = (l-r)/2
+//   There is no mono version.
+//------------------------------------------------------------------------------
+static void su_op_width(int stereo) {
+    float side = (su_sp[-1] - su_sp[-2]) * su_transformed[{{.InputNumber "width" "width"}}];
+    float mid = (su_sp[-1] + su_sp[-2]) * 0.5f;
+    su_sp[-1] = mid + side;
+    su_sp[-2] = mid - side;
+}
+{{end}}
+
+{{- if .HasOp "delay"}}
+//------------------------------------------------------------------------------
+//   DELAY opcode: adds delay effect to the signal
+//------------------------------------------------------------------------------
+//   Mono:   perform delay on ST0, using delaycount delaylines starting
+//           at delayindex from the delaytable
+//   Stereo: perform delay on ST1, using delaycount delaylines starting
+//           at delayindex + delaycount from the delaytable (so the right delays
+//           can be different)
+//------------------------------------------------------------------------------
+static void su_op_delay(int stereo) {
+    float pregain2 = su_transformed[{{.InputNumber "delay" "pregain"}}] * su_transformed[{{.InputNumber "delay" "pregain"}}];
+    float damp = su_transformed[{{.InputNumber "delay" "damp"}}];
+    float feedback = su_transformed[{{.InputNumber "delay" "feedback"}}];
+{{- if .SupportsParamValue "delay" "interpolation" 1}}
+    int interpolation = *su_val++;
+{{- end}}
+    int index = *su_val++;
+    int count = *su_val++;
+    unsigned short t = (unsigned short)su_globaltick;
+    float *signal = su_sp - 1 - stereo;
+    int i, j;
+    for (i = 0; i <= stereo; i++, signal++) {
+        su_delayline *d = su_delaywrk;
+        float output = su_transformed[{{.InputNumber "delay" "dry"}}] * *signal;
+        for (j = 0; j < count; j += 2, index++) {
+            float delay = su_delay_times[index]{{if .SupportsModulation "delay" "delaytime"}} + su_wrk->ports[{{.InputNumber "delay" "delaytime"}}] * 32767{{end}};
+            float s;
+            d = su_delaywrk++;
+{{- if .SupportsParamValue "delay" "notetracking" 1}}
+            if (!(count & 1)) // note tracking delays are scaled by the frequency of the note
+                delay /= (float)exp2(su_tuned_note() * 0.083333333333);
+{{- end}}
+{{- if .SupportsParamValue "delay" "interpolation" 1}}
+            if (interpolation == 1) { // linear interpolation between the two nearest samples, for smooth modulation
+                float k = (float)floor(delay);
+                float s0 = d->buffer[(unsigned short)(t - (int)k)], s1 = d->buffer[(unsigned short)(t - (int)k - 1)];
+                s = s0 + (delay - k) * (s1 - s0);
+            } else
+{{- end}}
+            s = d->buffer[(unsigned short)(t - (int)(delay + 0.5f))];
+            output += s;
+            d->dampstate = damp * d->dampstate + (1 - damp) * s;
+            d->buffer[t] = feedback * d->dampstate + pregain2 * *signal;
+        }
+        d->dcfiltstate = output + (0.99609375f * d->dcfiltstate - d->dcin); // the dc filter state is stored in the last delay line
+        d->dcin = output;
+        *signal = d->dcfiltstate;
+    }
+{{- if .SupportsModulation "delay" "delaytime"}}
+    su_wrk->ports[{{.InputNumber "delay" "delaytime"}}] = 0;
+{{- end}}
+}
+{{end}}
+
+{{- if .HasOp "compressor"}}
+//------------------------------------------------------------------------------
+//   COMPRESSOR opcode: push compressor gain to stack
+//------------------------------------------------------------------------------
+//   Mono:   push g on stack, where g is a suitable gain for the signal
+//           you can either MULP to compress the signal or SEND it to a GAIN
+//           somewhere else for compressor side-chaining.
+//   Stereo: push g g on stack, where g is calculated using l^2 + r^2
+//------------------------------------------------------------------------------
+static void su_op_compressor(int stereo) {
+    float level = su_sp[-1] * su_sp[-1]; // square the signal to get power
+    float current = su_wrk->state[0], t2, gain = 1;
+{{- if .Stereo "compressor"}}
+    if (stereo)
+        level += su_sp[-2] * su_sp[-2];
+{{- end}}
+{{- if .SupportsParamValue "compressor" "sidechain" 1}}
+    if (*su_val++ == 1) // the sidechain signal is only used for detecting the level
+        su_sp -= 1 + stereo;
+{{- end}}
+    if (level < current) // releasing
+        current += (level - current) * su_nonlinear_map(su_transformed[{{.InputNumber "compressor" "release"}}]);
+    else // attacking
+        current += (level - current) * su_nonlinear_map(su_transformed[{{.InputNumber "compressor" "attack"}}]);
+    su_wrk->state[0] = current;
+    t2 = su_transformed[{{.InputNumber "compressor" "threshold"}}] * su_transformed[{{.InputNumber "compressor" "threshold"}}];
+    if (current > t2)
+        gain = (float)pow(t2 / current, su_transformed[{{.InputNumber "compressor" "ratio"}}] / 2);
+    gain /= su_transformed[{{.InputNumber "compressor" "invgain"}}]; // apply inverse gain
+{{- if .Stereo "compressor"}}
+    if (stereo)
+        *su_sp++ = gain;
+{{- end}}
+    *su_sp++ = gain;
+}
+{{end}}
+
+{{- if .HasOp "limiter"}}
+//------------------------------------------------------------------------------
+//   LIMITER opcode: lookahead brickwall limiter
+//------------------------------------------------------------------------------
+//   Mono:   x   ->  limited(x)
+//   Stereo: l r ->  limited(l) limited(r), with the gain computed from max(|l|,|r|)
+//
+//   The signal is delayed by the lookahead in a delay line, next to the gain
+//   reduction each sample needs. The gain reduction approaches it linearly,
+//   reaching it fully when the sample comes out, so the peak is held until it
+//   has left the delay line.
+//------------------------------------------------------------------------------
+static void su_op_limiter(int stereo) {
+    float ceiling = su_transformed[{{.InputNumber "limiter" "ceiling"}}];
+    int lookahead = *su_val++;
+    su_delayline *d = su_delaywrk++;
+    float peak = (float)fabs(su_sp[-1]), target = 0, gain;
+    unsigned short t = (unsigned short)(su_globaltick * 4); // each sample takes 4 slots: the channels and the gain reduction
+    unsigned short n = (unsigned short)(lookahead * 4); // lookahead in samples
+    unsigned short a;
+    int i;
+{{- if .Stereo "limiter"}}
+    if (stereo && (float)fabs(su_sp[-2]) > peak)
+        peak = (float)fabs(su_sp[-2]);
+{{- end}}
+    d->buffer[(unsigned short)(t + 2)] = 1 - ceiling / (peak > ceiling ? peak : ceiling);
+    for (a = 0; a <= n; a++) {
+        float r = d->buffer[(unsigned short)(t - a * 4 + 2)] * (float)(a + 1);
+        if (r > target)
+            target = r;
+    }
+    target /= (float)(n + 1);
+    su_wrk->state[0] *= 1 - su_nonlinear_map(su_transformed[{{.InputNumber "limiter" "release"}}]); // exponential release
+    if (target > su_wrk->state[0])
+        su_wrk->state[0] = target;
+    gain = 1 - su_wrk->state[0];
+    for (i = 0; i <= stereo; i++) {
+        float x;
+        d->buffer[(unsigned short)(t + i)] = su_sp[-1 - i];
+        x = gain * d->buffer[(unsigned short)(t + i - n * 4)];
+        su_sp[-1 - i] = x > ceiling ? ceiling : x < -ceiling ? -ceiling : x; // clipping only catches the rounding errors
+    }
+}
+{{end}}
@@ -0,0 +1,25 @@
+{{- if .HasOp "speed"}}
+//------------------------------------------------------------------------------
+//   SPEED opcode: modulate the speed (bpm) of the song based on ST0
+//------------------------------------------------------------------------------
+//   Mono: adds or subtracts the ticks, a value of 0.5 is neutral & will
+//   result in no speed change.
+//   There is no STEREO version.
+//------------------------------------------------------------------------------
+static void su_op_speed(int stereo) {
+    float r = su_wrk->state[0] + (float)(exp2(*--su_sp * 2.206896551724138f) - 1); // (2*s-1)*64/24, the player is advancing 1 tick by its own
+    int w = (int)(r + 1.5f) - 1; // round to whole ticks
+    su_wrk->state[0] = r - w; // save the remainder for future
+    su_sample += w; // add the whole ticks to row tick count
+}
+{{end}}
+
+{{- if or .RowSync (.HasOp "sync")}}
+//------------------------------------------------------------------------------
+//   SYNC opcode: save the stack top to sync buffer
+//------------------------------------------------------------------------------
+static void su_op_sync(int stereo) {
+    if (!(su_globaltick & 255))
+        *su_syncbufptr++ = su_sp[-1];
+}
+{{end}}
@@ -0,0 +1,117 @@
+//------------------------------------------------------------------------------
+//   Helper functions
+//------------------------------------------------------------------------------
+// su_nonlinear_map returns 2^(-24*x), where x is a parameter in the range 0-1
+static inline float su_nonlinear_map(float x) {
+    return (float)exp2(-24 * x);
+}
+
+// su_waveshaper returns x*a/(1-a+(2*a-1)*abs(x))
+static inline float su_waveshaper(float x, float a) {
+    return x * a / (1 - a + (2 * a - 1) * (float)fabs(x));
+}
+
+// su_clip clamps x into the range [-1,1]
+static inline float su_clip(float x) {
+    return x < -1 ? -1 : x > 1 ? 1 : x;
+}
+
+// su_rand returns a pseudorandom value in the range [-1,1]
+static inline float su_rand(void) {
+    su_randseed *= 16007;
+    return (float)(int32_t)su_randseed / -2147483648.0f;
+}
+
+// su_tuned_note returns the note of the current voice, in semitones
+static inline float su_tuned_note(void) {
+{{- if .NoteTable}}
+    return su_note_table[su_curvoice->note & 127];
+{{- else}}
+    return (float)su_curvoice->note;
+{{- end}}
+}
+
+// su_float_operand reads a little endian float from the operand stream
+static inline float su_float_operand(void) {
+    uint32_t bits = su_val[0] + ((uint32_t)su_val[1] << 8) + ((uint32_t)su_val[2] << 16) + ((uint32_t)su_val[3] << 24);
+    float f;
+    memcpy(&f, &bits, sizeof f);
+    su_val += 4;
+    return f;
+}
+
+{{- template "arithmetic.c" .}}
+{{- template "effects.c" .}}
+{{- template "flowcontrol.c" .}}
+{{- template "sinks.c" .}}
+{{- template "sources.c" .}}
+
+//------------------------------------------------------------------------------
+// The opcode jump table. This is constructed to only include the opcodes that
+// are used so that the jump table is as small as possible.
+//------------------------------------------------------------------------------
+static void (*const su_vm_jumptable[])(int stereo) = {
+{{- range .Instructions}}
+    su_op_{{.}},
+{{- end}}
+};
+
+//------------------------------------------------------------------------------
+// The number of transformed parameters each opcode takes
+//------------------------------------------------------------------------------
+static const unsigned char su_vm_transformcounts[] = {
+{{- range .Instructions}}
+    {{$.TransformCount .}},
+{{- end}}
+};
+
+//------------------------------------------------------------------------------
+//   su_run_vm function: runs the entire virtual machine once, creating 1 sample
+//------------------------------------------------------------------------------
+//   Input:      su_outputs  :   left and right set to 0 before calling
+//   Output:     su_outputs  :   left and right sample
+//------------------------------------------------------------------------------
+static void su_run_vm(void) {
+    unsigned int voicesremain = {{.Song.Patch.NumVoices}};
+{{- if .SupportsPolyphony}}
+    const unsigned char *com_instr_start = su_patch_opcodes, *val_instr_start = su_patch_operands;
+{{- end}}
+    su_com = su_patch_opcodes;
+    su_val = su_patch_operands;
+    su_curvoice = su_voices;
+    su_wrk = su_curvoice->units;
+{{- if or (.HasOp "delay") (.HasOp "limiter")}}
+    su_delaywrk = su_delaylines;
+{{- end}}
+{{- if .RowSync}}
+    *su_sp++ = (float)((double)su_sample / {{.Song.SamplesPerRow}} + su_row); // the current fractional row is written as sync #0
+    su_op_sync(0);
+    su_sp--;
+{{- end}}
+    for (;;) {
+        int opcode = *su_com++;
+        if (opcode >> 1) {
+            int i, count = su_vm_transformcounts[(opcode >> 1) - 1];
+            for (i = 0; i < count; i++) {
+                su_transformed[i] = *su_val++ / 128.0f + su_wrk->ports[i]; // scale from 0-128 to 0.0-1.0 and add the modulations
+                su_wrk->ports[i] = 0;
+            }
+            su_vm_jumptable[(opcode >> 1) - 1](opcode & 1); // the LSB of the opcode is the stereo bit
+            su_wrk++;
+            continue;
+        }
+        // the opcode is zero, advance to next voice
+        if (--voicesremain == 0)
+            return;
+        su_curvoice++;
+        su_wrk = su_curvoice->units;
+{{- if .SupportsPolyphony}}
+        if (({{.PolyphonyBitmask}}u >> voicesremain) & 1) { // the next voice reuses the opcodes of the current voice
+            su_com = com_instr_start;
+            su_val = val_instr_start;
+        }
+        com_instr_start = su_com;
+        val_instr_start = su_val;
+{{- end}}
+    }
+}
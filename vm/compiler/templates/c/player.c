@@ -0,0 +1,298 @@
+// auto-generated by Sointu, editing not recommended
+#include <math.h>
+#include <stdint.h>
+#include <string.h>
+{{- if and (gt (len .SampleOffsets) 0) .UsesGmDls}}
+#include <stdio.h>
+{{- end}}
+
+{{- if .Output16Bit}}
+typedef short SUsample;
+{{- else}}
+typedef float SUsample;
+{{- end}}
+
+#define SU_PI 3.14159265358979323846
+
+//------------------------------------------------------------------------------
+//   Types
+//------------------------------------------------------------------------------
+typedef struct {
+    float state[8];
+    float ports[8];
+} su_unit;
+
+typedef struct {
+    int note;
+    int sustain;
+    su_unit units[63];
+} su_voice;
+
+typedef struct {
+    float dcin;
+    float dcfiltstate;
+    float dampstate;
+    float buffer[65536];
+} su_delayline;
+
+typedef struct {
+    unsigned int start;
+    unsigned short loopstart;
+    unsigned short looplength;
+} su_sample_offset;
+
+typedef struct {
+    unsigned short start; // first frame of the wavetable
+    unsigned short numframes;
+} su_wavetable_offset;
+
+enum { SU_ENV_ATTACK, SU_ENV_DECAY, SU_ENV_SUSTAIN, SU_ENV_RELEASE };
+
+//------------------------------------------------------------------------------
+//    Patterns
+//------------------------------------------------------------------------------
+static const unsigned char su_patterns[] = {
+{{- range .Patterns}}
+    {{. | toStrings | join ","}},
+{{- end}}
+};
+
+//------------------------------------------------------------------------------
+//    Tracks
+//------------------------------------------------------------------------------
+static const unsigned char su_tracks[] = {
+{{- range .Sequences}}
+    {{. | toStrings | join ","}},
+{{- end}}
+};
+
+{{- if .Transpositions}}
+
+//------------------------------------------------------------------------------
+//    Transpositions: added to the notes of the patterns in the tracks
+//------------------------------------------------------------------------------
+static const signed char su_transpositions[] = {
+{{- range .Transpositions}}
+    {{. | toStrings | join ","}},
+{{- end}}
+};
+{{- end}}
+
+{{- if gt (.SampleOffsets | len) 0}}
+
+//------------------------------------------------------------------------------
+//    Sample offsets
+//------------------------------------------------------------------------------
+static const su_sample_offset su_sample_offsets[] = {
+{{- range .SampleOffsets}}
+    { {{.Start}}, {{.LoopStart}}, {{.LoopLength}} },
+{{- end}}
+};
+
+{{- if .UsesGmDls}}
+
+#ifndef SU_GMDLS_PATH
+#define SU_GMDLS_PATH "C:\\Windows\\System32\\drivers\\gm.dls"
+#endif
+
+static short su_sample_table[1720330{{if gt (len .SampleBank) 0}} + {{len .SampleBank}}{{end}}]; // gm.dls{{if gt (len .SampleBank) 0}}, followed by the user samples, copied here from su_sample_bank when the song starts{{end}}
+
+{{- if gt (len .SampleBank) 0}}
+
+static const short su_sample_bank[] = {
+{{- template "samplebank" .SampleBank}}
+};
+{{- end}}
+
+//------------------------------------------------------------------------------
+//   su_load_gmdls function: loads the gm.dls samples from SU_GMDLS_PATH
+//------------------------------------------------------------------------------
+void su_load_gmdls(void) {
+    FILE *f = fopen(SU_GMDLS_PATH, "rb");
+    if (f == NULL)
+        return; // the samples are just silent without gm.dls
+    if (fread(su_sample_table, 1, 3440660, f) == 0)
+        memset(su_sample_table, 0, 3440660);
+    fclose(f);
+}
+{{- else}}
+
+static const short su_sample_table[] = { // no gm.dls samples used, so the user samples are the whole sample table
+{{- template "samplebank" .SampleBank}}
+};
+{{- end}}
+{{- end}}
+
+{{- if .WavetableOffsets}}
+
+//------------------------------------------------------------------------------
+//    Wavetables: the offsets of each wavetable, followed by the frames of all
+//    wavetables used by the song
+//------------------------------------------------------------------------------
+static const su_wavetable_offset su_wavetable_offsets[] = {
+{{- range .WavetableOffsets}}
+    { {{.Start}}, {{.NumFrames}} },
+{{- end}}
+};
+
+static const short su_wavetables[] = {
+{{- template "samplebank" .WavetableBank}}
+};
+{{- end}}
+
+{{- if .NoteTable}}
+
+//------------------------------------------------------------------------------
+//    Note table: the tuned pitch of each note, in semitones
+//------------------------------------------------------------------------------
+static const float su_note_table[] = {
+{{- range .NoteTable}}
+    {{printf "%.8e" .}}f,
+{{- end}}
+};
+{{- end}}
+
+{{- if .HasOp "delay"}}
+
+//------------------------------------------------------------------------------
+//    Delay times
+//------------------------------------------------------------------------------
+static const unsigned short su_delay_times[] = {
+    {{if .DelayTimes}}{{.DelayTimes | toStrings | join ","}}{{else}}0{{end}}
+};
+{{- end}}
+
+//------------------------------------------------------------------------------
+//    The code for this patch, basically indices to vm jump table
+//------------------------------------------------------------------------------
+static const unsigned char su_patch_opcodes[] = {
+    {{.Opcodes | toStrings | join ","}}
+};
+
+//------------------------------------------------------------------------------
+//    The parameters / inputs to each opcode
+//------------------------------------------------------------------------------
+static const unsigned char su_patch_operands[] = {
+    {{if .Operands}}{{.Operands | toStrings | join ","}}{{else}}0{{end}}
+};
+
+//------------------------------------------------------------------------------
+//   Uninitialized data: the synth object
+//------------------------------------------------------------------------------
+static float su_outputs[8];             // left, right and the three aux channels
+static su_voice su_voices[32];
+{{- if ne .VoiceTrackBitmask 0}}
+static unsigned char su_trackcurrentvoice[32]; // which voice is playing on which track
+{{- end}}
+{{- if or (.HasOp "delay") (.HasOp "limiter")}}
+static su_delayline su_delaylines[{{max 1 .Song.Patch.NumDelayLines}}];
+static su_delayline *su_delaywrk;       // the next delay line to be used
+{{- end}}
+static float su_stack[64];              // the signal stack, grows upwards
+static float *su_sp;                    // points just above the topmost signal
+static float su_transformed[8];         // the transformed operands of the current unit
+static const unsigned char *su_com;     // the opcode stream
+static const unsigned char *su_val;     // the operand stream
+static su_voice *su_curvoice;
+static su_unit *su_wrk;                 // the unit being processed
+static uint32_t su_randseed;
+static uint32_t su_globaltick;
+static int su_row;
+static int su_sample;
+{{- if or .RowSync (.HasOp "sync")}}
+extern float syncBuf[];
+static float *su_syncbufptr;
+{{- end}}
+
+{{template "patch.c" .}}
+
+//------------------------------------------------------------------------------
+//   su_update_voices function: polyphonic & chord implementation
+//------------------------------------------------------------------------------
+static void su_update_voices(void) {
+    int pattern = su_row / {{.PatternLength}}, row = su_row % {{.PatternLength}};
+    int track, note;
+{{- if ne .VoiceTrackBitmask 0}}
+    // The more complicated implementation: one track can trigger multiple voices
+    int firstvoice = 0;
+    for (track = 0; track < {{len .Sequences}}; track++) {
+        int numvoices = 1, voiceno;
+        while (({{.VoiceTrackBitmask}}u >> (firstvoice + numvoices - 1)) & 1)
+            numvoices++;
+        note = su_patterns[su_tracks[track * {{.SequenceLength}} + pattern] * {{.PatternLength}} + row];
+{{- if .Transpositions}}
+        if (note > {{.Hold}}) // holds and releases are not transposed
+            note += su_transpositions[track * {{.SequenceLength}} + pattern];
+{{- end}}
+        if (note != {{.Hold}}) { // anything but hold causes action
+            voiceno = su_trackcurrentvoice[track];
+            su_voices[firstvoice + voiceno].sustain = 0; // release the voice currently active
+            if (note > {{.Hold}}) { // a new note is triggered on the next voice of the track
+                if (++voiceno >= numvoices)
+                    voiceno = 0;
+                su_trackcurrentvoice[track] = (unsigned char)voiceno;
+                memset(&su_voices[firstvoice + voiceno], 0, sizeof(su_voice)); // clear the workspace of the new voice, retriggering oscillators
+                su_voices[firstvoice + voiceno].note = note;
+                su_voices[firstvoice + voiceno].sustain = 1;
+            }
+        }
+        firstvoice += numvoices;
+    }
+{{- else}}
+    // The simple implementation: each track triggers always the same voice
+    for (track = 0; track < {{len .Sequences}}; track++) {
+        note = su_patterns[su_tracks[track * {{.SequenceLength}} + pattern] * {{.PatternLength}} + row];
+{{- if .Transpositions}}
+        if (note > {{.Hold}}) // holds and releases are not transposed
+            note += su_transpositions[track * {{.SequenceLength}} + pattern];
+{{- end}}
+        if (note == {{.Hold}}) // anything but hold causes action
+            continue;
+        su_voices[track].sustain = 0; // release the voice
+        if (note > {{.Hold}}) {
+            memset(&su_voices[track], 0, sizeof(su_voice)); // clear the workspace of the new voice, retriggering oscillators
+            su_voices[track].note = note;
+            su_voices[track].sustain = 1;
+        }
+    }
+{{- end}}
+}
+
+//------------------------------------------------------------------------------
+//   su_render_song function: the entry point for the synth
+//------------------------------------------------------------------------------
+//   Renders the compile time hard-coded song to the buffer.
+//------------------------------------------------------------------------------
+void su_render_song(SUsample *buffer) {
+{{- if and .UsesGmDls (gt (len .SampleBank) 0)}}
+    memcpy(su_sample_table + 1720330, su_sample_bank, sizeof su_sample_bank); // copy the user samples after the gm.dls data
+{{- end}}
+{{- if or .RowSync (.HasOp "sync")}}
+    su_syncbufptr = syncBuf;
+{{- end}}
+    su_randseed = 1;
+    su_globaltick = 0;
+    su_sp = su_stack;
+    for (su_row = 0; su_row < {{mul .PatternLength .SequenceLength}}; su_row++) { // loop through every row in the song
+        su_update_voices(); // update instruments for the new row
+        for (su_sample = 0; su_sample < {{.Song.SamplesPerRow}}; su_sample++) { // loop through every sample in the row
+            su_run_vm();
+{{- if .Output16Bit}}
+            *buffer++ = (short)lrintf(su_clip(su_outputs[0]) * 32767.0f);
+            *buffer++ = (short)lrintf(su_clip(su_outputs[1]) * 32767.0f);
+{{- else}}
+            *buffer++ = su_outputs[0];
+            *buffer++ = su_outputs[1];
+{{- end}}
+            su_outputs[0] = 0; // clear the left and right channels so the VM is ready to write them again
+            su_outputs[1] = 0;
+            su_globaltick++; // increment global time, used by delays
+        }
+    }
+}
+
+{{- define "samplebank"}}
+{{- range $i, $v := .}}{{if eq (mod $i 16) 0}}{{if ne $i 0}}
+{{- end}}
+   {{end}} {{$v}},{{end}}
+{{- end}}
@@ -0,0 +1,86 @@
+{{- if .HasOp "out"}}
+//------------------------------------------------------------------------------
+//   OUT opcode: outputs and pops the signal
+//------------------------------------------------------------------------------
+{{- if .Mono "out"}}
+//   Mono: add ST0 to main left port, then pop
+{{- end}}
+{{- if .Stereo "out"}}
+//   Stereo: add ST0 to left out and ST1 to right out, then pop
+{{- end}}
+//------------------------------------------------------------------------------
+static void su_op_out(int stereo) {
+{{- if .Stereo "out"}}
+    if (stereo)
+        su_outputs[1] += su_transformed[{{.InputNumber "out" "gain"}}] * su_sp[-2];
+{{- end}}
+    su_outputs[0] += su_transformed[{{.InputNumber "out" "gain"}}] * su_sp[-1];
+    su_sp -= 1 + stereo;
+}
+{{end}}
+
+{{- if .HasOp "outaux"}}
+//------------------------------------------------------------------------------
+//   OUTAUX opcode: outputs to main and aux1 outputs and pops the signal
+//------------------------------------------------------------------------------
+//   Mono: add outgain*ST0 to main left port and auxgain*ST0 to aux1 left
+//   Stereo: also add outgain*ST1 to main right port and auxgain*ST1 to aux1 right
+//------------------------------------------------------------------------------
+static void su_op_outaux(int stereo) {
+{{- if .Stereo "outaux"}}
+    if (stereo) {
+        su_outputs[1] += su_transformed[{{.InputNumber "outaux" "outgain"}}] * su_sp[-2];
+        su_outputs[3] += su_transformed[{{.InputNumber "outaux" "auxgain"}}] * su_sp[-2];
+    }
+{{- end}}
+    su_outputs[0] += su_transformed[{{.InputNumber "outaux" "outgain"}}] * su_sp[-1];
+    su_outputs[2] += su_transformed[{{.InputNumber "outaux" "auxgain"}}] * su_sp[-1];
+    su_sp -= 1 + stereo;
+}
+{{end}}
+
+{{- if .HasOp "aux"}}
+//------------------------------------------------------------------------------
+//   AUX opcode: outputs the signal to aux (or main) port and pops the signal
+//------------------------------------------------------------------------------
+//   Mono: add gain*ST0 to left port
+//   Stereo: also add gain*ST1 to right port
+//------------------------------------------------------------------------------
+static void su_op_aux(int stereo) {
+    int channel = *su_val++;
+{{- if .Stereo "aux"}}
+    if (stereo)
+        su_outputs[channel + 1] += su_transformed[{{.InputNumber "aux" "gain"}}] * su_sp[-2];
+{{- end}}
+    su_outputs[channel] += su_transformed[{{.InputNumber "aux" "gain"}}] * su_sp[-1];
+    su_sp -= 1 + stereo;
+}
+{{end}}
+
+{{- if .HasOp "send"}}
+//------------------------------------------------------------------------------
+//   SEND opcode: adds the signal to a port
+//------------------------------------------------------------------------------
+//   Mono: adds signal to a port, defined by a word in VAL stream
+//   Stereo: also add right signal to the following port
+//------------------------------------------------------------------------------
+static void su_op_send(int stereo) {
+    unsigned int addr = su_val[0] + (su_val[1] << 8);
+    float amount = su_transformed[{{.InputNumber "send" "amount"}}] * 2 - 1;
+    su_voice *voice = su_curvoice;
+    su_unit *unit;
+    int i;
+    su_val += 2;
+{{- if .SupportsGlobalSend}}
+    if (addr & 0x8000) { // global send: the address contains also the voice
+        addr -= 0x8010;
+        voice = &su_voices[addr >> 10];
+    }
+{{- end}}
+    unit = &voice->units[((addr & 0x3F0) >> 4) - 1];
+    for (i = 0; i <= stereo; i++)
+        unit->ports[(addr & 7) + i] += su_sp[-1 - i] * amount;
+    if (addr & 0x8) // send and pop
+        su_sp -= 1 + stereo;
+}
+{{end}}
@@ -0,0 +1,301 @@
+{{- if .HasOp "envelope"}}
+//------------------------------------------------------------------------------
+//   ENVELOPE opcode: pushes an ADSR envelope value on stack [0,1]
+//------------------------------------------------------------------------------
+//   Mono:   push the envelope value on stack
+//   Stereo: push the envelope value on stack twice
+//------------------------------------------------------------------------------
+static void su_op_envelope(int stereo) {
+    float level = su_wrk->state[1];
+    int state;
+    if (!su_curvoice->sustain)
+        su_wrk->state[0] = SU_ENV_RELEASE;
+    state = (int)su_wrk->state[0];
+    if (state == SU_ENV_ATTACK) {
+        level += su_nonlinear_map(su_transformed[{{.InputNumber "envelope" "attack"}}]);
+        if (level >= 1) {
+            level = 1;
+            state = SU_ENV_DECAY;
+        }
+    } else if (state == SU_ENV_DECAY) {
+        level -= su_nonlinear_map(su_transformed[{{.InputNumber "envelope" "decay"}}]);
+        if (level <= su_transformed[{{.InputNumber "envelope" "sustain"}}])
+            level = su_transformed[{{.InputNumber "envelope" "sustain"}}];
+    } else if (state == SU_ENV_RELEASE) {
+        level -= su_nonlinear_map(su_transformed[{{.InputNumber "envelope" "release"}}]);
+        if (level <= 0)
+            level = 0;
+    }
+    su_wrk->state[0] = (float)state;
+    su_wrk->state[1] = level;
+    level *= su_transformed[{{.InputNumber "envelope" "gain"}}];
+{{- if .Stereo "envelope"}}
+    if (stereo)
+        *su_sp++ = level;
+{{- end}}
+    *su_sp++ = level;
+}
+{{end}}
+
+{{- if .HasOp "noise"}}
+//------------------------------------------------------------------------------
+//   NOISE opcode: creates noise
+//------------------------------------------------------------------------------
+//   Mono:   push a random value [-1,1] value on stack
+//   Stereo: push two (different) random values on stack
+//------------------------------------------------------------------------------
+static void su_op_noise(int stereo) {
+{{- if .Stereo "noise"}}
+    if (stereo)
+        su_op_noise(0);
+{{- end}}
+    *su_sp++ = su_waveshaper(su_rand(), su_transformed[{{.InputNumber "noise" "shape"}}]) * su_transformed[{{.InputNumber "noise" "gain"}}];
+}
+{{end}}
+
+{{- if .HasOp "oscillator"}}
+{{- if .SupportsParamValue "oscillator" "type" .Sine}}
+static float su_oscillator_sine(double phase, double color) {
+    if (phase >= color)
+        return 0;
+    return (float)sin(2 * SU_PI * phase / color);
+}
+
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Trisaw}}
+static float su_oscillator_trisaw(double phase, double color) {
+    if (phase >= color) { // since phase cannot be 1, if color = 1, then this condition never fires
+        phase = 1 - phase;
+        color = 1 - color;
+    }
+    return (float)(phase / color * 2 - 1);
+}
+
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Pulse}}
+static float su_oscillator_pulse(double phase, double color) {
+    return phase >= color ? -1.0f : 1.0f;
+}
+
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Gate}}
+// su_oscillator_gate reuses color and shape as the 16 bit gate pattern; the
+// output is smoothed with a one pole filter, whose state is in *g
+static float su_oscillator_gate(double phase, const unsigned char *operands, float *g) {
+    int gatebits = (operands[{{.InputNumber "oscillator" "shape"}}] << 8) + operands[{{.InputNumber "oscillator" "color"}}];
+    float amplitude = (float)((gatebits >> ((int)(phase * 16 + .5) & 15)) & 1);
+    amplitude += 0.99609375f * (*g - amplitude);
+    *g = amplitude;
+    return amplitude;
+}
+
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Sample}}
+static float su_oscillator_sample(double phase, int sampleno) {
+    const su_sample_offset *o = &su_sample_offsets[sampleno];
+    long sampleindex = (long)(phase * 84.28074964676522 + 0.5);
+    if (sampleindex >= o->loopstart) {
+        sampleindex -= o->loopstart;
+        sampleindex %= o->looplength;
+        sampleindex += o->loopstart;
+    }
+    sampleindex += o->start;
+    if (sampleindex >= (long)(sizeof su_sample_table / sizeof su_sample_table[0]))
+        return 0;
+    return su_sample_table[sampleindex] / 32767.0f;
+}
+
+{{- end}}
+{{- if .WavetableOffsets}}
+// su_oscillator_wavetable returns the sample of a wavetable at the given
+// phase, interpolating linearly both within a frame and between the two frames
+// nearest to the position, which is given by the shape parameter
+static float su_oscillator_wavetable(double phase, int wavetable) {
+    const su_wavetable_offset *w = &su_wavetable_offsets[wavetable];
+    const short *frame0, *frame1;
+    double x = phase * 256;
+    int j = (int)floor(x), k = (j + 1) & 255;
+    float b = (float)x - (float)j;
+    float f = su_transformed[{{.InputNumber "oscillator" "shape"}}] * (w->numframes - 1);
+    float i = (float)floor(f);
+    float a = f - i;
+    unsigned int i0 = (unsigned int)(int)i % w->numframes; // out of range positions wrap around
+    float s0, s1;
+    frame0 = su_wavetables + (w->start + i0) * 256;
+    frame1 = su_wavetables + (w->start + (i0 + 1) % w->numframes) * 256;
+    s0 = frame0[j] + b * (frame0[k] - frame0[j]);
+    s1 = frame1[j] + b * (frame1[k] - frame1[j]);
+    return (s0 + a * (s1 - s0)) / 32767.0f;
+}
+
+{{- end}}
+
+//------------------------------------------------------------------------------
+//   OSCILLAT opcode: oscillator, the heart of the synth
+//------------------------------------------------------------------------------
+//   Mono:   push oscillator value on stack
+//   Stereo: push l r on stack, where l has opposite detune compared to r
+//------------------------------------------------------------------------------
+static void su_op_oscillator(int stereo) {
+{{- if or (.SupportsParamValue "oscillator" "type" .Sample) (.SupportsParamValue "oscillator" "type" .Gate) .WavetableOffsets}}
+    const unsigned char *operands = su_val - {{.TransformCount "oscillator"}}; // color and shape are reused by samples, gates and wavetables
+{{- end}}
+    int flags = *su_val++;
+    int unison = flags & 3;
+    float detunestereo = su_transformed[{{.InputNumber "oscillator" "detune"}}] * 2 - 1;
+    int i, j;
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+    // tempo synced lfos have their frequency as a float operand; 0 = not
+    // synced, negative = phase locked to song time
+    float syncomega = su_float_operand();
+    double songphase = fabs(syncomega) * su_globaltick;
+    songphase -= floor(songphase);
+{{- end}}
+    for (i = 0; i <= stereo; i++) {
+        float detune = detunestereo;
+        float output = 0;
+        for (j = 0; j <= unison; j++) {
+            float *statevar = &su_wrk->state[i + j * 2];
+            float amplitude = 0;
+            double omega, phase;
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+            if (syncomega != 0) {
+                omega = fabs(syncomega); // synced lfos ignore transpose and detune
+                if (syncomega < 0)
+                    *statevar = (float)songphase;
+            } else
+{{- end}}
+            {
+                double pitch = 64 * (su_transformed[{{.InputNumber "oscillator" "transpose"}}] * 2 - 1) + detune;
+                if (!(flags & 0x08)) // if lfo is disabled, add note to oscillator transpose
+                    pitch += su_tuned_note();
+                omega = exp2(pitch * 0.083333333333); // from semitones to octaves
+                if (!(flags & 0x08))
+                    omega *= 0.000092696138; // scaling coefficient to get middle-C where it should be
+                else
+                    omega *= 0.000038; // pretty random scaling constant to get LFOs into reasonable range
+            }
+{{- if .SupportsModulation "oscillator" "frequency"}}
+            omega += su_wrk->ports[{{.InputNumber "oscillator" "frequency"}}]; // add frequency modulation
+{{- end}}
+            phase = *statevar + omega;
+{{- if .SupportsParamValue "oscillator" "type" .Sample}}
+            if (flags & 0x80) {
+                *statevar = (float)phase;
+                phase += su_transformed[{{.InputNumber "oscillator" "phase"}}];
+                amplitude = su_oscillator_sample(phase, operands[{{.InputNumber "oscillator" "color"}}]); // reuse color as the sample number
+            } else
+{{- end}}
+            {
+                phase += 1;
+                phase -= (int)phase;
+                *statevar = (float)phase;
+                phase += su_transformed[{{.InputNumber "oscillator" "phase"}}];
+                phase += 1;
+                phase -= (int)phase; // phase mod 1.0, so that trisaw does not nan even if color = 1
+{{- if .SupportsParamValue "oscillator" "type" .Sine}}
+                if (flags & 0x40)
+                    amplitude = su_oscillator_sine(phase, su_transformed[{{.InputNumber "oscillator" "color"}}]);
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Trisaw}}
+                if (flags & 0x20)
+                    amplitude = su_oscillator_trisaw(phase, su_transformed[{{.InputNumber "oscillator" "color"}}]);
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Pulse}}
+                if (flags & 0x10)
+                    amplitude = su_oscillator_pulse(phase, su_transformed[{{.InputNumber "oscillator" "color"}}]);
+{{- end}}
+{{- if .SupportsParamValue "oscillator" "type" .Gate}}
+                if (flags & 0x04)
+                    amplitude = su_oscillator_gate(phase, operands, &su_wrk->state[4 + i]);
+{{- end}}
+{{- if .WavetableOffsets}}
+                if (!(flags & 0xF4)) // wavetable is the only type without a type bit
+                    amplitude = su_oscillator_wavetable(phase, operands[{{.InputNumber "oscillator" "color"}}]); // reuse color as the wavetable number
+{{- end}}
+            }
+{{- if or (.SupportsParamValue "oscillator" "type" .Gate) .WavetableOffsets}}
+            if (flags & 0xF0) // wave shaping is skipped with gate and wavetable, as they reuse the shape parameter
+                amplitude = su_waveshaper(amplitude, su_transformed[{{.InputNumber "oscillator" "shape"}}]);
+{{- else}}
+            amplitude = su_waveshaper(amplitude, su_transformed[{{.InputNumber "oscillator" "shape"}}]);
+{{- end}}
+            output += amplitude * su_transformed[{{.InputNumber "oscillator" "gain"}}];
+{{- if .SupportsParamValueOtherThan "oscillator" "unison" 0}}
+            if (j < unison)
+                su_transformed[{{.InputNumber "oscillator" "phase"}}] += 0.08333333f; // 1/12, add small phase shift so all oscillators don't start in phase
+{{- end}}
+            detune = -detune * 0.5f;
+        }
+        *su_sp++ = output;
+        detunestereo = -detunestereo;
+    }
+{{- if .SupportsModulation "oscillator" "frequency"}}
+    su_wrk->ports[{{.InputNumber "oscillator" "frequency"}}] = 0;
+{{- end}}
+}
+{{end}}
+
+{{- if .HasOp "loadval"}}
+//------------------------------------------------------------------------------
+//   LOADVAL opcode
+//------------------------------------------------------------------------------
+{{- if .Mono "loadval"}}
+//   Mono: push 2*v-1 on stack, where v is the input to port "value"
+{{- end}}
+{{- if .Stereo "loadval"}}
+//   Stereo: push 2*v-1 twice on stack
+{{- end}}
+//------------------------------------------------------------------------------
+static void su_op_loadval(int stereo) {
+    float v = su_transformed[{{.InputNumber "loadval" "value"}}] * 2 - 1;
+{{- if .Stereo "loadval"}}
+    if (stereo)
+        *su_sp++ = v;
+{{- end}}
+    *su_sp++ = v;
+}
+{{end}}
+
+{{- if .HasOp "receive"}}
+//------------------------------------------------------------------------------
+//   RECEIVE opcode
+//------------------------------------------------------------------------------
+{{- if .Mono "receive"}}
+//   Mono:   push l on stack, where l is the left channel received
+{{- end}}
+{{- if .Stereo "receive"}}
+//   Stereo: push l r on stack
+{{- end}}
+//------------------------------------------------------------------------------
+static void su_op_receive(int stereo) {
+{{- if .Stereo "receive"}}
+    if (stereo) {
+        *su_sp++ = su_wrk->ports[1];
+        su_wrk->ports[1] = 0;
+    }
+{{- end}}
+    *su_sp++ = su_wrk->ports[0];
+    su_wrk->ports[0] = 0;
+}
+{{end}}
+
+{{- if .HasOp "in"}}
+//------------------------------------------------------------------------------
+//   IN opcode: inputs and clears a global port
+//------------------------------------------------------------------------------
+//   Mono: push the left channel of a global port (out or aux)
+//   Stereo: also push the right channel (stack in l r order)
+//------------------------------------------------------------------------------
+static void su_op_in(int stereo) {
+    int channel = *su_val++;
+{{- if .Stereo "in"}}
+    if (stereo) {
+        *su_sp++ = su_outputs[channel + 1];
+        su_outputs[channel + 1] = 0;
+    }
+{{- end}}
+    *su_sp++ = su_outputs[channel];
+    su_outputs[channel] = 0;
+}
+{{end}}
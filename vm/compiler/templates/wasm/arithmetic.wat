@@ -104,7 +104,11 @@
         (call $su_op_loadnote (i32.const 0))
     ))
 {{- end}}
+{{- if .NoteTable}}
+    (f32.load offset={{index .Labels "su_note_table"}} (i32.shl (i32.and (i32.load (global.get $voice)) (i32.const 127)) (i32.const 2))) ;; tuned note
+{{- else}}
     (f32.convert_i32_u (i32.load (global.get $voice)))
+{{- end}}
     (f32.mul (f32.const 0.015625))
     (f32.sub (f32.const 1))
     (call $push)
@@ -210,7 +214,7 @@
     call $pop
     call $pop
 {{- if .StereoAndMono "xch"}}
-    (if (local.get $stereo) (then
+    (if (param f32 f32) (result f32 f32) (local.get $stereo) (then ;; the popped values are passed into the branches
 {{- end}}
 {{- if .Stereo "xch"}}
         call $pop  ;; F: d       P: c b a
@@ -315,6 +315,31 @@
 {{end}}
 
 
+{{- if .HasOp "width"}}
+;;-------------------------------------------------------------------------------
+;;   WIDTH opcode: change the stereo width of the signal
+;;-------------------------------------------------------------------------------
+;;   Stereo: l r ->  m+s m-s
+;;
+;;   where m = (l+r)/2 is the mid signal, s = w*(l-r) is the side signal and w
+;;   is the width in [0,1] range; 0.5 keeps the signal unchanged, 0 makes it
+;;   mono and 1 doubles the side signal. There is no mono version of this unit.
+;;-------------------------------------------------------------------------------
+(func $su_op_width (param $stereo i32) (local $m f32) (local $s f32)
+    (local.set $s (f32.mul
+        (f32.sub (call $peek) (call $peek2))
+        (call $input (i32.const {{.InputNumber "width" "width"}}))
+    ))
+    (local.set $m (f32.mul
+        (f32.add (call $pop) (call $pop))
+        (f32.const 0.5)
+    ))
+    (call $push (f32.sub (local.get $m) (local.get $s)))
+    (call $push (f32.add (local.get $m) (local.get $s)))
+)
+{{end}}
+
+
 {{- if .HasOp "delay"}}
 ;;-------------------------------------------------------------------------------
 ;;   DELAY opcode: adds delay effect to the signal
@@ -324,10 +349,16 @@
 ;;   Stereo: perform delay on ST1, using delaycount delaylines starting
 ;;           at delayindex + delaycount from the delaytable (so the right delays
 ;;           can be different)
+;;   Interpolation: when the delay time is modulated or note tracked, the
+;;           delay lines are read at fractional positions, interpolating
+;;           linearly between the two nearest samples
 ;;-------------------------------------------------------------------------------
 (func $su_op_delay (param $stereo i32) (local $delayIndex i32) (local $delayCount i32) (local $output f32) (local $s f32) (local $filtstate f32)
 {{- if .Stereo "delay"}} (local $delayCountStash i32) {{- end}}
 {{- if or (.SupportsModulation "delay" "delaytime") (.SupportsParamValue "delay" "notetracking" 1)}} (local $delayTime f32) {{- end}}
+{{- if .SupportsParamValue "delay" "interpolation" 1}} (local $interpolation i32) (local $floor f32)
+    (local.set $interpolation (call $scanOperand))
+{{- end}}
     (local.set $delayIndex (i32.mul (call $scanOperand) (i32.const 2)))
 {{- if .Stereo "delay"}}
     (local.set $delayCountStash (call $scanOperand))
@@ -344,58 +375,70 @@
         (call $peek)
     ))
     loop $delayLoop
-        (local.tee $s (f32.load offset=12
-            (i32.add ;; delayWRK + ((globalTick-delaytimes[delayIndex])&65535)*4
-                (i32.mul ;; ((globalTick-delaytimes[delayIndex])&65535)*4
-                    (i32.and ;; (globalTick-delaytimes[delayIndex])&65535
-                        (i32.sub ;; globalTick-delaytimes[delayIndex]
-                            (global.get $globaltick)
 {{- if or (.SupportsModulation "delay" "delaytime") (.SupportsParamValue "delay" "notetracking" 1)}} ;; delaytime modulation or note syncing require computing the delay time in floats
 {{- if .SupportsModulation "delay" "delaytime"}}
-                            (local.set $delayTime (f32.add
-                                (f32.convert_i32_u (i32.load16_u
-                                    offset={{index .Labels "su_delay_times"}}
-                                    (local.get $delayIndex)
-                                ))
-                                (f32.mul
-                                    (f32.load offset={{.InputNumber "delay" "delaytime" | mul 4 | add 32}} (global.get $WRK))
-                                    (f32.const 32767)
-                                )
-                            ))
+        (local.set $delayTime (f32.add
+            (f32.convert_i32_u (i32.load16_u
+                offset={{index .Labels "su_delay_times"}}
+                (local.get $delayIndex)
+            ))
+            (f32.mul
+                (f32.load offset={{.InputNumber "delay" "delaytime" | mul 4 | add 32}} (global.get $WRK))
+                (f32.const 32767)
+            )
+        ))
 {{- else}}
-                            (local.set $delayTime (f32.convert_i32_u (i32.load16_u
-                                    offset={{index .Labels "su_delay_times"}}
-                                    (local.get $delayIndex)
-                            )))
+        (local.set $delayTime (f32.convert_i32_u (i32.load16_u
+                offset={{index .Labels "su_delay_times"}}
+                (local.get $delayIndex)
+        )))
 {{- end}}
 {{- if .SupportsParamValue "delay" "notetracking" 1}}
-                            (if (i32.eqz (i32.and (local.get $delayCount) (i32.const 1)))(then
-                                (local.set $delayTime (f32.div
-                                    (local.get $delayTime)
-                                    (call $pow2
-                                        (f32.mul
-                                            (f32.convert_i32_u (i32.load (global.get $voice)))
-                                            (f32.const 0.08333333)
-                                        )
-                                    )
-                                ))
-                            ))
-{{- end}}
-                            (i32.trunc_f32_s (f32.add (local.get $delayTime) (f32.const 0.5)))
+        (if (i32.eqz (i32.and (local.get $delayCount) (i32.const 1)))(then
+            (local.set $delayTime (f32.div
+                (local.get $delayTime)
+                (call $pow2
+                    (f32.mul
+{{- if .NoteTable}}
+                        (f32.load offset={{index .Labels "su_note_table"}} (i32.shl (i32.and (i32.load (global.get $voice)) (i32.const 127)) (i32.const 2))) ;; tuned note
 {{- else}}
-                            (i32.load16_u
-                                offset={{index .Labels "su_delay_times"}}
-                                (local.get $delayIndex)
-                            )
+                        (f32.convert_i32_u (i32.load (global.get $voice)))
 {{- end}}
+                        (f32.const 0.08333333)
+                    )
+                )
+            ))
+        ))
+{{- end}}
+{{- if .SupportsParamValue "delay" "interpolation" 1}}
+        (local.tee $s (if (result f32) (local.get $interpolation)
+            (then ;; s = s0 + (k-floor(k))*(s1-s0), where s0 = b[t-floor(k)] and s1 = b[t-floor(k)-1]
+                (f32.add
+                    (local.tee $s (call $su_delay_read (i32.trunc_f32_s (local.tee $floor (f32.floor (local.get $delayTime))))))
+                    (f32.mul
+                        (f32.sub (local.get $delayTime) (local.get $floor))
+                        (f32.sub
+                            (call $su_delay_read (i32.add (i32.trunc_f32_s (local.get $floor)) (i32.const 1)))
+                            (local.get $s)
                         )
-                        (i32.const 65535)
                     )
-                    (i32.const 4)
                 )
-                (global.get $delayWRK)
+            )
+            (else
+                (call $su_delay_read (i32.trunc_f32_s (f32.add (local.get $delayTime) (f32.const 0.5))))
             )
         ))
+{{- else}}
+        (local.tee $s (call $su_delay_read (i32.trunc_f32_s (f32.add (local.get $delayTime) (f32.const 0.5)))))
+{{- end}}
+{{- else}}
+        (local.tee $s (call $su_delay_read
+            (i32.load16_u
+                offset={{index .Labels "su_delay_times"}}
+                (local.get $delayIndex)
+            )
+        ))
+{{- end}}
         (local.set $output (f32.add (local.get $output)))
         (f32.store
             (global.get $delayWRK)
@@ -472,6 +515,28 @@
     (f32.store offset={{.InputNumber "delay" "delaytime" | mul 4 | add 32}} (global.get $WRK) (f32.const 0))
 {{- end}}
 )
+
+;;-------------------------------------------------------------------------------
+;;   su_delay_read: returns the sample from the current delay line that was
+;;   written $delay samples ago
+;;-------------------------------------------------------------------------------
+(func $su_delay_read (param $delay i32) (result f32)
+    (f32.load offset=12
+        (i32.add ;; delayWRK + ((globalTick-delay)&65535)*4
+            (i32.mul ;; ((globalTick-delay)&65535)*4
+                (i32.and ;; (globalTick-delay)&65535
+                    (i32.sub ;; globalTick-delay
+                        (global.get $globaltick)
+                        (local.get $delay)
+                    )
+                    (i32.const 65535)
+                )
+                (i32.const 4)
+            )
+            (global.get $delayWRK)
+        )
+    )
+)
 {{end}}
 
 
@@ -483,9 +548,48 @@
 ;;           you can either MULP to compress the signal or SEND it to a GAIN
 ;;           somewhere else for compressor side-chaining.
 ;;   Stereo: push g g on stack, where g is calculated using l^2 + r^2
+;;   Sidechain: the level is detected from the topmost signal s, which is
+;;           replaced with g, i.e. x s -> x g
 ;;-------------------------------------------------------------------------------
 (func $su_op_compressor (param $stereo i32) (local $x2 f32) (local $level f32) (local $t2 f32)
+{{- if .SupportsParamValue "compressor" "sidechain" 1}} (local $s f32)
+    (if (call $scanOperand) (then ;; sidechain: detect the level from the topmost signal and consume it
+        (local.set $x2 (f32.mul
+            (local.tee $s (call $pop))
+            (local.get $s)
+        ))
+{{- if .Stereo "compressor"}}
+        (if (local.get $stereo)(then
+            (local.set $x2 (f32.add
+                (local.get $x2)
+                (f32.mul
+                    (local.tee $s (call $pop))
+                    (local.get $s)
+                )
+            ))
+        ))
+{{- end}}
+    )(else
+        (local.set $x2 (f32.mul
+            (call $peek)
+            (call $peek)
+        ))
 {{- if .Stereo "compressor"}}
+        (if (local.get $stereo)(then
+            (call $pop)
+            (local.set $x2 (f32.add
+                (local.get $x2)
+                (f32.mul
+                    (call $peek)
+                    (call $peek)
+                )
+            ))
+            (call $push)
+        ))
+{{- end}}
+    ))
+    (local.get $x2)
+{{- else if .Stereo "compressor"}}
     (local.set $x2 (f32.mul
         (call $peek)
         (call $peek)
@@ -552,3 +656,120 @@
     (f32.store (global.get $WRK) (local.get $level)) ;; save the updated levels
 )
 {{- end}}
+
+
+{{- if .HasOp "limiter"}}
+;;-------------------------------------------------------------------------------
+;;   LIMITER opcode: lookahead brickwall limiter
+;;-------------------------------------------------------------------------------
+;;   Mono:   x   ->  limited(x)
+;;   Stereo: l r ->  limited(l) limited(r), both channels using the same gain
+;;
+;;   The signal is delayed by the lookahead. Each sample stores in the lookahead
+;;   buffer the gain reduction it needs, and the gain reduction approaches it
+;;   linearly during the lookahead, reaching it fully when the sample comes out:
+;;   the gain reduction is the maximum of t*(a+1)/(n+1) over the buffer, where
+;;   t is the gain reduction needed by a sample, a its age and n the lookahead.
+;;   So the peak is held until it has left the buffer and the output never
+;;   exceeds the ceiling; the final clipping only catches the rounding errors.
+;;-------------------------------------------------------------------------------
+(func $su_op_limiter (param $stereo i32) (local $lookahead i32) (local $p f32) (local $m f32) (local $a i32) (local $g f32) (local $t i32) (local $l f32)
+    (local.set $lookahead (i32.shl (call $scanOperand) (i32.const 2))) ;; lookahead in samples
+    (local.set $p (f32.abs (call $peek)))
+{{- if .Stereo "limiter"}}
+    (if (local.get $stereo)(then
+        (local.set $p (f32.max (local.get $p) (f32.abs (call $peek2))))
+    ))
+{{- end}}
+    ;; each sample takes 4 slots of the buffer: the channels and the gain reduction it needs
+    (local.set $t (i32.shl (global.get $globaltick) (i32.const 2)))
+    (f32.store offset=20 ;; delayWRK + (t&65535)*4 + 12, the slot after the channels
+        (i32.add
+            (i32.mul
+                (i32.and (local.get $t) (i32.const 65535))
+                (i32.const 4)
+            )
+            (global.get $delayWRK)
+        )
+        (f32.sub
+            (f32.const 1)
+            (f32.div
+                (call $input (i32.const {{.InputNumber "limiter" "ceiling"}}))
+                (f32.max (local.get $p) (call $input (i32.const {{.InputNumber "limiter" "ceiling"}})))
+            )
+        )
+    )
+    (local.set $a (local.get $lookahead))
+    (loop $window ;; m = maximum of t*(a+1) over the ages a of the samples in the buffer
+        (local.set $m (f32.max
+            (local.get $m)
+            (f32.mul
+                (f32.load offset=20
+                    (i32.add ;; delayWRK + ((t-4*a)&65535)*4 + 12
+                        (i32.mul
+                            (i32.and (i32.sub (local.get $t) (i32.shl (local.get $a) (i32.const 2))) (i32.const 65535))
+                            (i32.const 4)
+                        )
+                        (global.get $delayWRK)
+                    )
+                )
+                (f32.convert_i32_u (i32.add (local.get $a) (i32.const 1)))
+            )
+        ))
+        (br_if $window (i32.ge_s (local.tee $a (i32.sub (local.get $a) (i32.const 1))) (i32.const 0)))
+    )
+    (f32.store (global.get $WRK) (local.tee $m (f32.max ;; u'=max(u*(1-k),m/(n+1)): exponential release
+        (f32.div (local.get $m) (f32.convert_i32_u (i32.add (local.get $lookahead) (i32.const 1))))
+        (f32.mul
+            (f32.load (global.get $WRK))
+            (f32.sub (f32.const 1) (call $nonLinearMap (i32.const {{.InputNumber "limiter" "release"}})))
+        )
+    )))
+    (local.set $g (f32.sub (f32.const 1) (local.get $m)))
+    (local.set $lookahead (i32.shl (local.get $lookahead) (i32.const 2))) ;; lookahead in slots
+{{- if .Stereo "limiter"}}
+    (if (local.get $stereo)(then
+        (local.set $l (call $pop))
+        (call $push (call $su_limiter_do (call $pop) (i32.add (local.get $t) (i32.const 1)) (local.get $lookahead) (local.get $g)))
+        (call $push (local.get $l))
+    ))
+{{- end}}
+    (call $push (call $su_limiter_do (call $pop) (local.get $t) (local.get $lookahead) (local.get $g)))
+    (global.set $delayWRK (i32.add (global.get $delayWRK) (i32.const 262156)))
+)
+
+;;-------------------------------------------------------------------------------
+;;   su_limiter_do: saves x to the lookahead buffer at index $i and returns the
+;;   sample written $n slots before, multiplied by $g and clipped to ceiling
+;;-------------------------------------------------------------------------------
+(func $su_limiter_do (param $x f32) (param $i i32) (param $n i32) (param $g f32) (result f32)
+    (f32.store offset=12
+        (i32.add ;; delayWRK + (i&65535)*4
+            (i32.mul
+                (i32.and (local.get $i) (i32.const 65535))
+                (i32.const 4)
+            )
+            (global.get $delayWRK)
+        )
+        (local.get $x)
+    )
+    (f32.max
+        (f32.neg (call $input (i32.const {{.InputNumber "limiter" "ceiling"}})))
+        (f32.min
+            (call $input (i32.const {{.InputNumber "limiter" "ceiling"}}))
+            (f32.mul
+                (local.get $g)
+                (f32.load offset=12
+                    (i32.add ;; delayWRK + ((i-n)&65535)*4
+                        (i32.mul
+                            (i32.and (i32.sub (local.get $i) (local.get $n)) (i32.const 65535))
+                            (i32.const 4)
+                        )
+                        (global.get $delayWRK)
+                    )
+                )
+            )
+        )
+    )
+)
+{{end}}
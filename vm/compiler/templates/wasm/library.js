@@ -0,0 +1,98 @@
+// auto-generated by Sointu, editing not recommended
+//
+// AudioWorklet glue for the Sointu wasm library. Assemble the accompanying .wat
+// into .wasm (e.g. with wat2wasm) and use it on the main thread like this:
+//
+//   const ctx = new AudioContext({ sampleRate: 44100 }); // the VM assumes 44100 Hz
+//   await ctx.audioWorklet.addModule("sointu.js");
+//   const module = await WebAssembly.compileStreaming(fetch("sointu.wasm"));
+//   const node = new AudioWorkletNode(ctx, "sointu", {
+//       numberOfInputs: 0,
+//       outputChannelCount: [2],
+//       processorOptions: { module },  // or { bytes } with the .wasm as an ArrayBuffer
+//   });
+//   node.connect(ctx.destination);
+//   node.port.postMessage({ type: "load", bytecode });  // from sointu-compile -b
+//   node.port.postMessage({ type: "trigger", voice: 0, note: 60 });
+//   node.port.postMessage({ type: "release", voice: 0 });
+//
+// The bytecode must be compiled for the library i.e. with all features
+// enabled, which is what sointu-compile -b outputs. Samples, wavetables and
+// tunings are not supported and the speed unit has no effect. Errors are
+// posted back to the main thread as { type: "error", message }.
+
+class SointuProcessor extends AudioWorkletProcessor {
+    constructor(options) {
+        super();
+        const opts = options.processorOptions || {};
+        const module = opts.module || new WebAssembly.Module(opts.bytes);
+        this.exports = new WebAssembly.Instance(module, { m: Math }).exports;
+        const buffer = this.exports.memory.buffer; // the memory never grows, so the views stay valid
+        this.output = new Float32Array(buffer, this.exports.output.value, this.exports.maxsamples.value * 2);
+        this.port.onmessage = (e) => {
+            try {
+                this.handle(e.data);
+            } catch (err) {
+                this.port.postMessage({ type: "error", message: String(err) });
+            }
+        };
+    }
+
+    handle(msg) {
+        switch (msg.type) {
+            case "load":
+                this.load(msg.bytecode);
+                break;
+            case "trigger":
+                this.exports.trigger(msg.voice, msg.note);
+                break;
+            case "release":
+                this.exports.release(msg.voice);
+                break;
+            case "reset":
+                this.exports.reset();
+                break;
+            default:
+                throw new Error(`unknown message type: ${msg.type}`);
+        }
+    }
+
+    load(bytecode) {
+        const e = this.exports;
+        const buffer = e.memory.buffer;
+        if (bytecode.numDelayLines > 128) {
+            throw new Error(`delay lines do not fit in the library: ${bytecode.numDelayLines} > 128`);
+        }
+        const copy = (array, address, length, name) => {
+            const src = bytecode[name] || [];
+            if (src.length > length) {
+                throw new Error(`${name} do not fit in the library: ${src.length} > ${length}`);
+            }
+            new array(buffer, address, src.length).set(src);
+        };
+        copy(Uint8Array, e.opcodes.value, e.operands.value - e.opcodes.value, "opcodes");
+        copy(Uint8Array, e.operands.value, e.delaytimes.value - e.operands.value, "operands");
+        copy(Uint16Array, e.delaytimes.value, 768, "delayTimes");
+        e.load(bytecode.numVoices, bytecode.polyphonyBitmask);
+        e.reset();
+    }
+
+    process(inputs, outputs) {
+        const channels = outputs[0];
+        const left = channels[0];
+        const right = channels[1];
+        let pos = 0;
+        while (pos < left.length) {
+            const n = this.exports.render(left.length - pos);
+            for (let i = 0; i < n; i++, pos++) {
+                left[pos] = this.output[2 * i];
+                if (right) {
+                    right[pos] = this.output[2 * i + 1];
+                }
+            }
+        }
+        return true;
+    }
+}
+
+registerProcessor("sointu", SointuProcessor);
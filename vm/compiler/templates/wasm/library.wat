@@ -0,0 +1,231 @@
+(module
+
+{{- /*
+;-------------------------------------------------------------------------------
+; The number of transformed parameters each opcode takes. The table is indexed
+; starting from 1, so the byte before it just keeps the label above address 0.
+;-------------------------------------------------------------------------------
+*/}}
+{{- .DataB 0}}
+{{- .SetDataLabel "su_vm_transformcounts"}}
+{{- range .Instructions}}
+{{- $.TransformCount . | $.ToByte | $.DataB}}
+{{- end}}
+
+{{- /*
+;-------------------------------------------------------------------------------
+; The patch: the host writes the bytecode here before calling load
+;-------------------------------------------------------------------------------
+*/}}
+{{- .Align}}
+{{- .SetBlockLabel "su_patch_opcodes"}}
+{{- .Block 2048}}
+{{- .SetBlockLabel "su_patch_operands"}}
+{{- .Block 16384}}
+{{- .SetBlockLabel "su_delay_times"}}
+{{- .Block 1536}}
+
+{{- /*
+;-------------------------------------------------------------------------------
+; Allocate memory for stack.
+; Stack of 64 float signals is enough for everybody... right?
+; Note: as the stack grows _downwards_ the label is _after_ stack
+;-------------------------------------------------------------------------------
+*/}}
+{{- .Align}}
+{{- .Block 256}}
+{{- .SetBlockLabel "su_stack"}}
+
+{{- /*
+;-------------------------------------------------------------------------------
+; Allocate memory for transformed operands.
+;-------------------------------------------------------------------------------
+*/}}
+{{- .Align}}
+{{- .SetBlockLabel "su_transformedoperands"}}
+{{- .Block 32}}
+
+{{- /*
+;-------------------------------------------------------------------------------
+; Uninitialized memory for synth, delaylines & outputbuffer
+;-------------------------------------------------------------------------------
+*/}}
+{{- .Align}}
+{{- .SetBlockLabel "su_synth"}}
+{{- .Block 32}}
+{{- .SetBlockLabel "su_globalports"}}
+{{- .Block 32}}
+{{- .SetBlockLabel "su_voices"}}
+{{- .Block 131072}}
+{{- .Align}}
+{{- .SetBlockLabel "su_delaylines"}}
+{{- .Block (int (mul 262156 128))}}
+{{- .Align}}
+{{- .SetBlockLabel "su_outputbuffer"}}
+{{- .Block (int (mul 4096 8))}}
+{{- .SetBlockLabel "su_outputend"}}
+
+
+;;------------------------------------------------------------------------------
+;; Import the difficult math functions from javascript
+;;------------------------------------------------------------------------------
+(func $pow (import "m" "pow") (param f32) (param f32) (result f32))
+(func $log2 (import "m" "log2") (param f32) (result f32))
+(func $sin (import "m" "sin") (param f32) (result f32))
+
+;;------------------------------------------------------------------------------
+;; Types. Only useful to define the jump table type, which is
+;; (int stereo) void
+;;------------------------------------------------------------------------------
+(type $opcode_func_signature (func (param i32)))
+
+;;------------------------------------------------------------------------------
+;; The one and only memory
+;;------------------------------------------------------------------------------
+(memory (export "memory") {{.MemoryPages}})
+
+;;------------------------------------------------------------------------------
+;; Globals
+;;------------------------------------------------------------------------------
+(global $WRK (mut i32) (i32.const 0))
+(global $COM (mut i32) (i32.const 0))
+(global $VAL (mut i32) (i32.const 0))
+(global $COM_instr_start (mut i32) (i32.const 0))
+(global $VAL_instr_start (mut i32) (i32.const 0))
+(global $delayWRK (mut i32) (i32.const 0))
+(global $globaltick (mut i32) (i32.const 0))
+(global $sample (mut i32) (i32.const 0)) ;; only written by the speed opcode, which has no effect in the library
+(global $voice (mut i32) (i32.const 0))
+(global $voicesRemain (mut i32) (i32.const 0))
+(global $randseed (mut i32) (i32.const 1))
+(global $sp (mut i32) (i32.const {{index .Labels "su_stack"}}))
+(global $outputBufPtr (mut i32) (i32.const 0))
+(global $numVoices (mut i32) (i32.const 0))
+(global $polyphony (mut i32) (i32.const 0))
+(global (export "opcodes") i32 (i32.const {{index .Labels "su_patch_opcodes"}}))
+(global (export "operands") i32 (i32.const {{index .Labels "su_patch_operands"}}))
+(global (export "delaytimes") i32 (i32.const {{index .Labels "su_delay_times"}}))
+(global (export "output") i32 (i32.const {{index .Labels "su_outputbuffer"}}))
+(global (export "maxsamples") i32 (i32.const 4096))
+
+
+;;------------------------------------------------------------------------------
+;; Functions to emulate FPU stack in software
+;;------------------------------------------------------------------------------
+(func $peek (result f32)
+    (f32.load (global.get $sp))
+)
+
+(func $peek2 (result f32)
+    (f32.load offset=4 (global.get $sp))
+)
+
+(func $pop (result f32)
+    (call $peek)
+    (global.set $sp (i32.add (global.get $sp) (i32.const 4)))
+)
+
+(func $push (param $value f32)
+    (global.set $sp (i32.sub (global.get $sp) (i32.const 4)))
+    (f32.store (global.get $sp) (local.get $value))
+)
+
+;;------------------------------------------------------------------------------
+;; Helper functions
+;;------------------------------------------------------------------------------
+(func $swap (param f32 f32) (result f32 f32) ;; x,y -> y,x
+    local.get 1
+    local.get 0
+)
+
+(func $scanOperand (result i32)        ;; scans positions $VAL for a byte, incrementing $VAL afterwards
+    (i32.load8_u (global.get $VAL))      ;; in other words: returns byte [$VAL++]
+    (global.set $VAL (i32.add (global.get $VAL) (i32.const 1))) ;; $VAL++
+)
+
+;;------------------------------------------------------------------------------
+;; load: takes the patch written at opcodes, operands and delaytimes into use.
+;; numVoices is the total number of voices of the patch and polyphony is the
+;; PolyphonyBitmask of the bytecode. Does not reset the synth; call reset for
+;; that.
+;;------------------------------------------------------------------------------
+(func (export "load") (param $numVoices i32) (param $polyphony i32)
+    (global.set $numVoices (select (local.get $numVoices) (i32.const 32) (i32.le_u (local.get $numVoices) (i32.const 32))))
+    (global.set $polyphony (local.get $polyphony))
+)
+
+;;------------------------------------------------------------------------------
+;; reset: silences all voices and clears the state of all units & delaylines
+;;------------------------------------------------------------------------------
+(func (export "reset")
+    (memory.fill
+        (i32.const {{index .Labels "su_synth"}})
+        (i32.const 0)
+        (i32.sub (i32.const {{index .Labels "su_outputbuffer"}}) (i32.const {{index .Labels "su_synth"}}))
+    )
+    (global.set $sp (i32.const {{index .Labels "su_stack"}}))
+    (global.set $randseed (i32.const 1))
+    (global.set $globaltick (i32.const 0))
+)
+
+;;------------------------------------------------------------------------------
+;; trigger: starts playing note on a voice, retriggering its oscillators
+;;------------------------------------------------------------------------------
+(func (export "trigger") (param $voice i32) (param $note i32) (local $di i32)
+    (if (i32.lt_u (local.get $voice) (i32.const 32))(then
+        (local.set $di (i32.add (i32.mul (local.get $voice) (i32.const 4096)) (i32.const {{index .Labels "su_voices"}})))
+        (memory.fill (local.get $di) (i32.const 0) (i32.const 4096))
+        (i32.store (local.get $di) (local.get $note))
+        (i32.store offset=4 (local.get $di) (i32.const 1))
+    ))
+)
+
+;;------------------------------------------------------------------------------
+;; release: releases the note playing on a voice
+;;------------------------------------------------------------------------------
+(func (export "release") (param $voice i32)
+    (if (i32.lt_u (local.get $voice) (i32.const 32))(then
+        (i32.store offset={{add (index .Labels "su_voices") 4}} (i32.mul (local.get $voice) (i32.const 4096)) (i32.const 0))
+    ))
+)
+
+;;------------------------------------------------------------------------------
+;; render: renders at most maxsamples stereo float samples, interleaved, to
+;; output. Returns the number of samples rendered.
+;;------------------------------------------------------------------------------
+(func (export "render") (param $length i32) (result i32) (local $remaining i32)
+    (local.set $length (select (local.get $length) (i32.const 4096) (i32.le_u (local.get $length) (i32.const 4096))))
+    (global.set $outputBufPtr (i32.const {{index .Labels "su_outputbuffer"}}))
+    (if (i32.eqz (global.get $numVoices))(then ;; no patch loaded, so there is nothing to run
+        (memory.fill (global.get $outputBufPtr) (i32.const 0) (i32.shl (local.get $length) (i32.const 3)))
+        (return (local.get $length))
+    ))
+    (local.set $remaining (local.get $length))
+    block $done
+        loop $sample_loop
+            (br_if $done (i32.eqz (local.get $remaining)))
+            (global.set $COM (i32.const {{index .Labels "su_patch_opcodes"}}))
+            (global.set $VAL (i32.const {{index .Labels "su_patch_operands"}}))
+            (global.set $COM_instr_start (global.get $COM))
+            (global.set $VAL_instr_start (global.get $VAL))
+            (global.set $WRK (i32.const {{index .Labels "su_voices"}}))
+            (global.set $voice (i32.const {{index .Labels "su_voices"}}))
+            (global.set $voicesRemain (global.get $numVoices))
+            (global.set $delayWRK (i32.const {{index .Labels "su_delaylines"}}))
+            (call $su_run_vm)
+            {{- template "output_sound.wat" .}}
+            (global.set $globaltick (i32.add (global.get $globaltick) (i32.const 1)))
+            (local.set $remaining (i32.sub (local.get $remaining) (i32.const 1)))
+            br $sample_loop
+        end
+    end
+    (local.get $length)
+)
+
+{{template "patch.wat" .}}
+
+
+;; All data is collected into a byte buffer and emitted at once
+(data (i32.const 0) "{{range .Data}}\{{. | printf "%02x"}}{{end}}")
+
+) ;; END MODULE
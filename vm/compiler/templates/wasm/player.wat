@@ -26,6 +26,20 @@
     {{- end}}
 {{- end}}
 
+{{- if .Transpositions}}
+{{- /*
+;------------------------------------------------------------------------------
+;    Transpositions: added to the notes of the patterns in the tracks
+;-------------------------------------------------------------------------------
+*/}}
+{{- .SetDataLabel "su_transpositions"}}
+{{- range .Transpositions}}
+    {{- range .}}
+        {{- $.DataSB .}}
+    {{- end}}
+{{- end}}
+{{- end}}
+
 {{- /*
 ;------------------------------------------------------------------------------
 ;    The code for this patch, basically indices to vm jump table
@@ -46,6 +60,36 @@
 {{- $.DataB .}}
 {{- end}}
 
+{{- /*
+;-------------------------------------------------------------------------------
+;    Note table: the tuned pitch of each note, in semitones
+;-------------------------------------------------------------------------------
+*/}}
+{{- if .NoteTable}}
+{{- .SetDataLabel "su_note_table"}}
+{{- range .NoteTable}}
+{{- $.DataF .}}
+{{- end}}
+{{- end}}
+
+{{- /*
+;-------------------------------------------------------------------------------
+;    Wavetables: first frame and number of frames of each wavetable, followed
+;    by the frames of all wavetables used by the song
+;-------------------------------------------------------------------------------
+*/}}
+{{- if .WavetableOffsets}}
+{{- .SetDataLabel "su_wavetable_offsets"}}
+{{- range .WavetableOffsets}}
+{{- $.DataW .Start}}
+{{- $.DataW .NumFrames}}
+{{- end}}
+{{- .SetDataLabel "su_wavetables"}}
+{{- range .WavetableBank}}
+{{- $.DataS .}}
+{{- end}}
+{{- end}}
+
 {{- /*
 ;-------------------------------------------------------------------------------
 ;    Delay times
@@ -145,7 +189,7 @@
 (global $COM_instr_start (mut i32) (i32.const 0))
 (global $VAL_instr_start (mut i32) (i32.const 0))
 {{- end}}
-{{- if .HasOp "delay"}}
+{{- if or (.HasOp "delay") (.HasOp "limiter")}}
 (global $delayWRK (mut i32) (i32.const 0))
 {{- end}}
 (global $globaltick (mut i32) (i32.const 0))
@@ -220,7 +264,7 @@
                 (global.set $WRK (i32.const {{index .Labels "su_voices"}}))
                 (global.set $voice (i32.const {{index .Labels "su_voices"}}))
                 (global.set $voicesRemain (i32.const {{.Song.Patch.NumVoices | printf "%v"}}))
-{{- if .HasOp "delay"}}
+{{- if or (.HasOp "delay") (.HasOp "limiter")}}
                 (global.set $delayWRK (i32.const {{index .Labels "su_delaylines"}}))
 {{- end}}
                 (call $su_run_vm)
@@ -262,7 +306,15 @@
         (i32.mul (i32.const {{.PatternLength}}))
         (i32.add (global.get $row))
         (i32.load8_u offset={{index .Labels "su_patterns"}})
+{{- if .Transpositions}}
+        (local.set $note)
+        (if (i32.gt_u (local.get $note) (i32.const {{.Hold}}))(then ;; holds and releases are not transposed
+            (local.set $note (i32.add (local.get $note) (i32.load8_s offset={{index .Labels "su_transpositions"}} (local.get $si))))
+        ))
+        (local.get $note)
+{{- else}}
         (local.tee $note)
+{{- end}}
         (if (i32.ne (i32.const {{.Hold}}))(then
             (i32.store offset={{add (index .Labels "su_voices") 4}}
                 (i32.mul
@@ -310,7 +362,15 @@
         (i32.mul (i32.const {{.PatternLength}}))
         (i32.add (global.get $row))
         (i32.load8_u offset={{index .Labels "su_patterns"}})
+{{- if .Transpositions}}
+        (local.set $note)
+        (if (i32.gt_u (local.get $note) (i32.const {{.Hold}}))(then ;; holds and releases are not transposed
+            (local.set $note (i32.add (local.get $note) (i32.load8_s offset={{index .Labels "su_transpositions"}} (local.get $si))))
+        ))
+        (local.get $note)
+{{- else}}
         (local.tee $note)
+{{- end}}
         (if (i32.ne (i32.const {{.Hold}}))(then
             (i32.store offset=4 (local.get $di) (i32.const 0)) ;; release the note
             (if (i32.gt_u (local.get $note) (i32.const {{.Hold}}))(then
@@ -204,3 +204,11 @@
     (global.set $sample (i32.add (global.get $sample) (local.get $w)))
 )
 {{end}}
+
+{{- if .HasOp "sync"}}
+;;-------------------------------------------------------------------------------
+;;   SYNC opcode: NOP, as the wasm VM has no sync buffer
+;;-------------------------------------------------------------------------------
+(func $su_op_sync (param $stereo i32)
+)
+{{end}}
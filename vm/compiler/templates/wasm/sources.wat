@@ -110,6 +110,9 @@
 {{- if .SupportsModulation "oscillator" "frequency"}}
     (local $freqMod f32)
 {{- end}}
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+    (local $syncFreq f32) (local $songPhase f64)
+{{- end}}
 {{- if .Stereo "oscillator"}}
     (local $WRK_stereostash i32)
     (local.set $WRK_stereostash (global.get $WRK))
@@ -119,6 +122,10 @@
     (f32.store offset={{.InputNumber "oscillator" "frequency" | mul 4 | add 32}} (global.get $WRK) (f32.const 0))
 {{- end}}
     (local.set $flags (call $scanOperand))
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+    (local.set $syncFreq (f32.load (global.get $VAL))) ;; frequency of a tempo synced lfo, 0 = not synced, negative = phase locked to song time
+    (global.set $VAL (i32.add (global.get $VAL) (i32.const 4)))
+{{- end}}
     (local.set $detune (call $inputSigned (i32.const {{.InputNumber "oscillator" "detune"}})))
 {{- if .Stereo "oscillator"}}
     loop $stereoLoop
@@ -135,6 +142,20 @@
         (local.tee $phase
             (f32.sub
                 (local.tee $phase
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+                    (if (result f32) (f32.ne (local.get $syncFreq) (f32.const 0)) (then
+                        (if (f32.lt (local.get $syncFreq) (f32.const 0)) (then
+                            (f32.store (global.get $WRK) (f32.demote_f64 (f64.sub ;; phase is locked to song time: p = mod(|w|*t,1)
+                                (local.tee $songPhase (f64.mul
+                                    (f64.promote_f32 (f32.abs (local.get $syncFreq)))
+                                    (f64.convert_i32_u (global.get $globaltick))
+                                ))
+                                (f64.floor (local.get $songPhase))
+                            )))
+                        ))
+                        (f32.abs (local.get $syncFreq)) ;; synced lfo ignores transpose and detune
+                    )(else
+{{- end}}
                     ;; Transpose calculation starts
                     (f32.div
                         (call $inputSigned (i32.const {{.InputNumber "oscillator" "transpose"}}))
@@ -143,7 +164,11 @@
                     (f32.add (local.get $detune)) ;; add detune. detune is -1 to 1 so can detune a full note up or down at max
                     (f32.add (select
                         (f32.const 0)
+{{- if .NoteTable}}
+                        (f32.load offset={{index .Labels "su_note_table"}} (i32.shl (i32.and (i32.load (global.get $voice)) (i32.const 127)) (i32.const 2))) ;; tuned note
+{{- else}}
                         (f32.convert_i32_u (i32.load (global.get $voice)))
+{{- end}}
                         (i32.and (local.get $flags) (i32.const 0x8))
                     ))  ;; if lfo is not enabled, add the note number to it
                     (f32.mul (f32.const 0.0833333)) ;; /12, in full octaves
@@ -153,6 +178,9 @@
                         (f32.const 0.000092696138) ;; scaling constant to get middle-C to where it should be
                         (i32.and (local.get $flags) (i32.const 0x8))
                     ))
+{{- if .SupportsParamValueOtherThan "oscillator" "rate" 0}}
+                    ))
+{{- end}}
 {{- if .SupportsModulation "oscillator" "frequency"}}
                     (f32.add (local.get $freqMod))
 {{- end}}
@@ -183,8 +211,15 @@
 {{- if .SupportsParamValue "oscillator" "type" .Gate}}
     (if (i32.and (local.get $flags) (i32.const 0x04)) (then
         (local.set $amplitude (call $oscillator_gate (local.get $phase)))
-        ;; wave shaping is skipped with gate
-    )(else
+    ))
+{{- end}}
+{{- if .WavetableOffsets}}
+    (if (i32.eqz (i32.and (local.get $flags) (i32.const 0xF4))) (then ;; wavetable is the only type without a type bit
+        (local.set $amplitude (call $oscillator_wavetable (local.get $phase)))
+    ))
+{{- end}}
+{{- if or (.SupportsParamValue "oscillator" "type" .Gate) .WavetableOffsets}}
+    (if (i32.and (local.get $flags) (i32.const 0xF0)) (then ;; wave shaping is skipped with gate and wavetable, as they reuse the shape parameter
         (local.set $amplitude (call $waveshaper (local.get $amplitude) (call $input (i32.const {{.InputNumber "oscillator" "shape"}}))))
     ))
     (local.get $amplitude)
@@ -275,7 +310,7 @@
                             (f32.convert_i32_u ;; 'x' gate bit = float((gatebits >> (int(p*16+.5)&15)) & 1)
                                 (i32.and ;; (int(p*16+.5)&15)&1
                                     (i32.shr_u ;; int(p*16+.5)&15
-                                        (i32.load16_u (i32.sub (global.get $VAL) (i32.const 4)))
+                                        (i32.load16_u (i32.sub (global.get $VAL) (i32.const {{if .SupportsParamValueOtherThan "oscillator" "rate" 0}}8{{else}}4{{end}})))
                                         (i32.and ;; int(p*16+.5) & 15
                                             (i32.trunc_f32_s (f32.add
                                                 (f32.mul
@@ -302,6 +337,55 @@
 )
 {{end}}
 
+{{- if .WavetableOffsets}}
+(func $oscillator_wavetable (param $phase f32) (result f32) (local $offset i32) (local $n i32) (local $j i32) (local $b f32) (local $f f32) (local $i0 i32)
+    (local.set $offset (i32.add ;; reuse "color" as the wavetable number
+        (i32.shl (i32.load8_u (i32.sub (global.get $VAL) (i32.const {{if .SupportsParamValueOtherThan "oscillator" "rate" 0}}8{{else}}4{{end}}))) (i32.const 2))
+        (i32.const {{index .Labels "su_wavetable_offsets"}})
+    ))
+    (local.set $n (i32.load16_u offset=2 (local.get $offset))) ;; number of frames
+    (local.set $offset (i32.add ;; address of the first frame
+        (i32.shl (i32.load16_u (local.get $offset)) (i32.const 9))
+        (i32.const {{index .Labels "su_wavetables"}})
+    ))
+    (local.set $b (f32.sub ;; b = x - j, where x = 256*p and j = floor(x)
+        (local.tee $phase (f32.mul (local.get $phase) (f32.const 256)))
+        (f32.convert_i32_u (local.tee $j (i32.trunc_f32_u (local.get $phase))))
+    ))
+    (local.set $f (f32.sub ;; a = f - i, where f = s*(N-1) and i = floor(f); shape is reused as the position in the wavetable
+        (local.tee $f (f32.mul
+            (call $input (i32.const {{.InputNumber "oscillator" "shape"}}))
+            (f32.convert_i32_u (i32.sub (local.get $n) (i32.const 1)))
+        ))
+        (local.tee $phase (f32.floor (local.get $f)))
+    ))
+    (local.set $i0 (i32.rem_u (i32.trunc_f32_s (local.get $phase)) (local.get $n))) ;; out of range positions wrap around
+    (call $wavetable_frame (local.get $offset) (local.get $i0) (local.get $j) (local.get $b))
+    (call $wavetable_frame (local.get $offset) (i32.rem_u (i32.add (local.get $i0) (i32.const 1)) (local.get $n)) (local.get $j) (local.get $b))
+    (call $wavetable_frame (local.get $offset) (local.get $i0) (local.get $j) (local.get $b))
+    f32.sub
+    (f32.mul (local.get $f))
+    f32.add
+    (f32.div (f32.const 32767))
+)
+
+;; $wavetable_frame returns w[j]+b*(w[j+1]-w[j]), where w is the frame $i of the
+;; wavetable starting at $offset
+(func $wavetable_frame (param $offset i32) (param $i i32) (param $j i32) (param $b f32) (result f32) (local $s0 f32)
+    (local.set $offset (i32.add (local.get $offset) (i32.shl (local.get $i) (i32.const 9))))
+    (f32.add
+        (local.tee $s0 (f32.convert_i32_s (i32.load16_s (i32.add (local.get $offset) (i32.shl (local.get $j) (i32.const 1))))))
+        (f32.mul
+            (f32.sub
+                (f32.convert_i32_s (i32.load16_s (i32.add (local.get $offset) (i32.shl (i32.and (i32.add (local.get $j) (i32.const 1)) (i32.const 255)) (i32.const 1)))))
+                (local.get $s0)
+            )
+            (local.get $b)
+        )
+    )
+)
+{{end}}
+
 {{end}}
 
 
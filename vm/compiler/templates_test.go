@@ -0,0 +1,54 @@
+package compiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/vsariola/sointu/vm/compiler"
+)
+
+func TestExportTemplates(t *testing.T) {
+	for _, arch := range []string{"amd64", "wasm", "c", "go"} {
+		t.Run(arch, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := compiler.ExportTemplates(arch, dir); err != nil {
+				t.Fatalf("ExportTemplates failed: %v", err)
+			}
+			comp, err := compiler.NewFromTemplates("linux", arch, false, false, dir)
+			if err != nil {
+				t.Fatalf("NewFromTemplates failed: %v", err)
+			}
+			if missing := comp.MissingTemplates(false); len(missing) > 0 {
+				t.Fatalf("the exported templates should be complete, missing %v", missing)
+			}
+		})
+	}
+}
+
+func TestMissingTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := compiler.ExportTemplates("amd64", dir); err != nil {
+		t.Fatalf("ExportTemplates failed: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "structs.asm")); err != nil { // included by both the player and the library
+		t.Fatalf("could not remove template: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "player.inc")); err != nil {
+		t.Fatalf("could not remove template: %v", err)
+	}
+	comp, err := compiler.NewFromTemplates("linux", "amd64", false, false, dir)
+	if err != nil {
+		t.Fatalf("NewFromTemplates failed: %v", err)
+	}
+	if missing := comp.MissingTemplates(false); !slices.Equal(missing, []string{"player.inc", "structs.asm"}) {
+		t.Fatalf("expected player.inc and structs.asm to be missing, got %v", missing)
+	}
+	if missing := comp.MissingTemplates(true); !slices.Equal(missing, []string{"structs.asm"}) {
+		t.Fatalf("expected structs.asm to be missing from the library, got %v", missing)
+	}
+	if _, err := comp.Library(); err == nil {
+		t.Fatalf("compiling the library should fail when templates are missing")
+	}
+}
@@ -9,7 +9,7 @@ import (
 //
 // NOTE! Due to the single pass nature of the compilation and the way memory is
 // organized, you should initialize all initialized data in the .wat files using
-// DataB, DataW and DataD macros _before_ any calls to Block. Block allocates
+// DataB, DataW, DataD and DataF macros _before_ any calls to Block. Block allocates
 // uninitialized data blocks from the memory.
 type WasmMacros struct {
 	data       *bytes.Buffer
@@ -61,12 +61,30 @@ func (wm *WasmMacros) DataW(value uint16) string {
 	return ""
 }
 
+func (wm *WasmMacros) DataS(value int16) string {
+	binary.Write(wm.data, binary.LittleEndian, value)
+	wm.blockStart += 2
+	return ""
+}
+
+func (wm *WasmMacros) DataSB(value int8) string {
+	binary.Write(wm.data, binary.LittleEndian, value)
+	wm.blockStart++
+	return ""
+}
+
 func (wm *WasmMacros) DataD(value uint32) string {
 	binary.Write(wm.data, binary.LittleEndian, value)
 	wm.blockStart += 4
 	return ""
 }
 
+func (wm *WasmMacros) DataF(value float32) string {
+	binary.Write(wm.data, binary.LittleEndian, value)
+	wm.blockStart += 4
+	return ""
+}
+
 func (wm *WasmMacros) Block(value int) string {
 	wm.blockStart += value
 	return ""
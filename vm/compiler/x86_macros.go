@@ -364,6 +364,19 @@ func (p *X86Macros) Export(name string, numParams int) string {
 }
 
 func (p *X86Macros) ExportFunc(name string, params ...string) string {
+	p.paramStack(name, params)
+	return fmt.Sprintf("%[1]v\n%[2]v", p.SectText(name), p.Export(name, len(p.Stacklocs)-1))
+}
+
+// ThreadFunc starts a function that is not exported, but is called from
+// outside with the C calling convention: the entry point of the threads of a
+// multithreaded player
+func (p *X86Macros) ThreadFunc(name string, params ...string) string {
+	p.paramStack(name, params)
+	return fmt.Sprintf("%v\n%v:", p.SectText(name), name)
+}
+
+func (p *X86Macros) paramStack(name string, params []string) {
 	numRegisters := 0 // in 32-bit systems, we use stdcall: everything in stack
 	switch {
 	case p.Amd64 && p.OS == "windows":
@@ -381,7 +394,6 @@ func (p *X86Macros) ExportFunc(name string, params ...string) string {
 		reverseParams[len(params)-1-i] = param
 	}
 	p.Stacklocs = append(reverseParams, "retaddr_"+name) // in 32-bit, we use stdcall and parameters are in the stack
-	return fmt.Sprintf("%[1]v\n%[2]v", p.SectText(name), p.Export(name, len(params)))
 }
 
 func (p *X86Macros) Input(unit string, port string) (string, error) {
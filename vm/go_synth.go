@@ -4,9 +4,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/vsariola/sointu"
@@ -29,6 +29,11 @@ type (
 		state      synthState
 		delaylines []delayline
 		cpuLoad    sointu.CPULoad
+
+		// syncRecorder, if set, is called after every sample with the values
+		// of the sync units, in the order they were executed
+		syncRecorder func(syncs []float32)
+		syncs        []float32
 	}
 
 	// GoSynther is a Synther implementation that can converts patches into
@@ -74,25 +79,28 @@ const (
 	envStateRelease
 )
 
-var su_sample_table [3440660]byte
+var su_sample_table [SampleBankStart * 2]byte
 
 func init() {
-	var f *os.File
-	var err error
-	if f, err = os.Open("gm.dls"); err == nil { // try to open from current directory first
-		goto success
-	}
-	if f, err = os.Open(filepath.Join(os.Getenv("SystemRoot"), "system32", "drivers", "gm.dls")); err == nil {
-		goto success
+	if path := sointu.FindGmDls(); path != "" {
+		LoadGmDls(path) // ignore errors, the samples are just silent without gm.dls
 	}
-	if f, err = os.Open(filepath.Join(os.Getenv("SystemRoot"), "SysWOW64", "drivers", "gm.dls")); err == nil {
-		goto success
+}
+
+// LoadGmDls loads the gm.dls file from the given path into the sample table
+// used by the GoSynth, replacing the file found at startup with
+// sointu.FindGmDls. Should not be called while synths are running.
+func LoadGmDls(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open gm.dls: %v", err)
 	}
-	return
-success:
 	defer f.Close()
-	// read file, ignoring errors
-	f.Read(su_sample_table[:])
+	clear(su_sample_table[:])
+	if _, err := io.ReadFull(f, su_sample_table[:]); err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("could not read gm.dls: %v", err)
+	}
+	return nil
 }
 
 func (s GoSynther) Name() string                 { return "Go" }
@@ -114,6 +122,39 @@ func (s *GoSynth) Trigger(voiceIndex int, note byte) {
 	s.state.voices[voiceIndex].sustain = true
 }
 
+// tunedNote returns the note of the voice v, mapped through the note table if
+// the patch has tunings. voices is the remaining slice of the voices, starting
+// from v.
+func (s *GoSynth) tunedNote(voices []voice, v *voice) float32 {
+	if s.bytecode.NoteTable == nil {
+		return float32(v.note)
+	}
+	voiceIndex := MAX_VOICES - len(voices)
+	return s.bytecode.NoteTable[voiceIndex*128+int(v.note&127)]
+}
+
+// wavetableSample returns the sample of the wavetable w at the given phase
+// [0,1), interpolating linearly both within a frame and between the two
+// frames nearest to the position [0,1]
+func (s *GoSynth) wavetableSample(w WavetableOffset, phase float64, position float32) float32 {
+	x := phase * sointu.WavetableFrameLength
+	j := int(math.Floor(x))
+	b := float32(x) - float32(j)
+	f := position * float32(w.NumFrames-1)
+	i := float32(math.Floor(float64(f)))
+	a := f - i
+	n := uint32(w.NumFrames)
+	i0 := uint32(int32(i)) % n // out of range positions wrap around, like in the x86 player
+	i1 := (i0 + 1) % n
+	frame := func(i uint32) float32 {
+		data := s.bytecode.WavetableBank[(uint32(w.Start)+i)*sointu.WavetableFrameLength:]
+		s0, s1 := float32(data[j]), float32(data[(j+1)%sointu.WavetableFrameLength])
+		return s0 + b*(s1-s0)
+	}
+	s0, s1 := frame(i0), frame(i1)
+	return (s0 + a*(s1-s0)) / 32767.0
+}
+
 func (s *GoSynth) Release(voiceIndex int) {
 	s.state.voices[voiceIndex].sustain = false
 }
@@ -417,7 +458,7 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 				stack = append(stack, unit.ports[0])
 				unit.ports[0] = 0
 			case opLoadnote:
-				noteFloat := float32(voice.note)/64 - 1
+				noteFloat := s.tunedNote(voices, voice)/64 - 1
 				stack = append(stack, noteFloat)
 				if stereo {
 					stack = append(stack, noteFloat)
@@ -461,6 +502,13 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 			case opOscillator:
 				var flags byte
 				flags, operands = operands[0], operands[1:]
+				syncOmega := math.Float32frombits(binary.LittleEndian.Uint32(operands))
+				operands = operands[4:]
+				var songPhase float64
+				if syncOmega < 0 { // tempo synced lfo, with phase locked to the song time
+					songPhase = math.Abs(float64(syncOmega)) * float64(s.state.globalTime)
+					songPhase -= math.Floor(songPhase)
+				}
 				detuneStereo := params[1]*2 - 1
 				unison := flags & 3
 				for i := 0; i < channels; i++ {
@@ -468,16 +516,24 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 					var output float32
 					for j := byte(0); j <= unison; j++ {
 						statevar := &unit.state[byte(i)+j*2]
-						pitch := float64(64*(params[0]*2-1) + detune)
-						if flags&0x8 == 0 { // if lfo is disable, add note to oscillator transpose
-							pitch += float64(voice.note)
-						}
-						pitch *= 0.083333333333 // from semitones to octaves
-						omega := math.Exp2(pitch)
-						if flags&0x8 == 0 {
-							omega *= 0.000092696138 // scaling coefficient to get middle-C where it should be
+						var omega float64
+						if syncOmega != 0 {
+							omega = math.Abs(float64(syncOmega)) // tempo synced lfo ignores transpose and detune
+							if syncOmega < 0 {
+								*statevar = float32(songPhase)
+							}
 						} else {
-							omega *= 0.000038 //  pretty random scaling constant to get LFOs into reasonable range. Historical reasons, goes all the way back to 4klang
+							pitch := float64(64*(params[0]*2-1) + detune)
+							if flags&0x8 == 0 { // if lfo is disable, add note to oscillator transpose
+								pitch += float64(s.tunedNote(voices, voice))
+							}
+							pitch *= 0.083333333333 // from semitones to octaves
+							omega = math.Exp2(pitch)
+							if flags&0x8 == 0 {
+								omega *= 0.000092696138 // scaling coefficient to get middle-C where it should be
+							} else {
+								omega *= 0.000038 //  pretty random scaling constant to get LFOs into reasonable range. Historical reasons, goes all the way back to 4klang
+							}
 						}
 						omega += float64(unit.ports[6]) // add frequency modulation
 						var amplitude float32
@@ -495,7 +551,11 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 								sampleindex += loopstart
 							}
 							sampleindex += int(sampleoffset.Start)
-							amplitude = float32(int16(binary.LittleEndian.Uint16(su_sample_table[sampleindex*2:]))) / 32767.0
+							if sampleindex < SampleBankStart {
+								amplitude = float32(int16(binary.LittleEndian.Uint16(su_sample_table[sampleindex*2:]))) / 32767.0
+							} else if bankindex := sampleindex - SampleBankStart; bankindex < len(s.bytecode.SampleBank) {
+								amplitude = float32(s.bytecode.SampleBank[bankindex]) / 32767.0
+							}
 						} else {
 							// at this point, the native synth actually uses 80-bit precision, so emulate that as closely as possible by using 64-bit math here
 							phase += 1
@@ -529,9 +589,12 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 								g := unit.state[4+i] // warning: still fucks up with unison = 3
 								amplitude += 0.99609375 * (g - amplitude)
 								unit.state[4+i] = amplitude
+							default: // Wavetable
+								wavetable := s.bytecode.WavetableOffsets[operandsAtTransform[3]] // reuse color as the wavetable number
+								amplitude = s.wavetableSample(wavetable, phase, params[4])       // reuse shape as the position in the wavetable
 							}
 						}
-						if flags&0x4 == 0 {
+						if flags&0x4 == 0 && flags&0xF0 != 0 { // gate and wavetable reuse shape, so they are not waveshaped
 							output += waveshape(amplitude, params[4]) * params[5]
 						} else {
 							output += amplitude * params[5]
@@ -545,12 +608,16 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 					detuneStereo = -detuneStereo
 				}
 				unit.ports[6] = 0
+			case opWidth:
+				side := (stack[l-1] - stack[l-2]) * params[0]
+				mid := (stack[l-1] + stack[l-2]) * 0.5
+				stack[l-1], stack[l-2] = mid+side, mid-side
 			case opDelay:
 				pregain2 := params[0] * params[0]
 				damp := params[3]
 				feedback := params[2]
-				var index, count byte
-				index, count, operands = operands[0], operands[1], operands[2:]
+				var interpolation, index, count byte
+				interpolation, index, count, operands = operands[0], operands[1], operands[2], operands[3:]
 				t := uint16(s.state.globalTime)
 				stackIndex := l - channels
 				for i := 0; i < channels; i++ {
@@ -561,9 +628,16 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 						d, delaylines = &delaylines[0], delaylines[1:]
 						delay := float32(s.bytecode.DelayTimes[index]) + unit.ports[4]*32767
 						if count&1 == 0 {
-							delay /= float32(math.Exp2(float64(voice.note) * 0.083333333333))
+							delay /= float32(math.Exp2(float64(s.tunedNote(voices, voice)) * 0.083333333333))
+						}
+						var delSignal float32
+						if interpolation == 1 { // linear interpolation between the two nearest samples, for smooth modulation
+							i := float32(math.Floor(float64(delay)))
+							s0, s1 := d.buffer[t-uint16(int(i))], d.buffer[t-uint16(int(i))-1]
+							delSignal = s0 + (delay-i)*(s1-s0)
+						} else {
+							delSignal = d.buffer[t-uint16(delay+0.5)]
 						}
-						delSignal := d.buffer[t-uint16(delay+0.5)]
 						output += delSignal
 						d.dampState = damp*d.dampState + (1-damp)*delSignal
 						d.buffer[t] = feedback*d.dampState + pregain2*signal
@@ -580,6 +654,11 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 				if stereo {
 					signalLevel += stack[l-2] * stack[l-2]
 				}
+				var sidechain byte
+				sidechain, operands = operands[0], operands[1:]
+				if sidechain == 1 { // the sidechain signal is only used for detecting the level
+					stack = stack[:l-channels]
+				}
 				currentLevel := unit.state[0]
 				paramIndex := 0 // compressor attacking
 				if signalLevel < currentLevel {
@@ -597,6 +676,34 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 				if stereo {
 					stack = append(stack, gain)
 				}
+			case opLimiter:
+				ceiling := params[0]
+				var lookahead byte
+				lookahead, operands = operands[0], operands[1:]
+				var d *delayline
+				d, delaylines = &delaylines[0], delaylines[1:]
+				peak := float32(math.Abs(float64(stack[l-1])))
+				if stereo {
+					peak = max(peak, float32(math.Abs(float64(stack[l-2]))))
+				}
+				// every sample takes 4 slots of the lookahead buffer: the channels
+				// and the gain reduction needed to bring the sample to the ceiling
+				t := uint16(s.state.globalTime) * 4
+				n := uint16(lookahead) * 4 // lookahead in samples
+				d.buffer[t+2] = 1 - ceiling/max(peak, ceiling)
+				// the gain reduction of each sample in the buffer is approached
+				// linearly, reaching it fully when the sample comes out, so the peak
+				// is held until it has left the buffer
+				var target float32
+				for a := uint16(0); a <= n; a++ {
+					target = max(target, d.buffer[t-a*4+2]*float32(a+1))
+				}
+				unit.state[0] = max(target/float32(n+1), unit.state[0]*(1-nonLinearMap(params[1]))) // exponential release
+				gain := 1 - unit.state[0]
+				for i := 0; i < channels; i++ {
+					d.buffer[t+uint16(i)] = stack[l-1-i]
+					stack[l-1-i] = max(-ceiling, min(ceiling, gain*d.buffer[t+uint16(i)-n*4])) // clipping only catches rounding errors
+				}
 			case opBelleq:
 				// Bell-shaped peaking filter equations based on https://shepazu.github.io/Audio-EQ-Cookbook/audio-eq-cookbook.html:
 				//   alpha = sin(omega0)/(2*Q) where omega0 determines the angular frequency of the peak and Q is the Q-factor
@@ -617,7 +724,9 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 					stack[l-1-i] = y
 				}
 			case opSync:
-				break
+				if s.syncRecorder != nil {
+					s.syncs = append(s.syncs, stack[l-1])
+				}
 			default:
 				return samples, renderTime, errors.New("invalid / unimplemented opcode")
 			}
@@ -629,6 +738,10 @@ func (s *GoSynth) Render(buffer sointu.AudioBuffer, maxtime int) (samples int, r
 		if len(stack) > 4 {
 			return samples, renderTime, errors.New("stack not empty")
 		}
+		if s.syncRecorder != nil {
+			s.syncRecorder(s.syncs)
+			s.syncs = s.syncs[:0]
+		}
 		buffer[0][0], buffer[0][1] = synth.outputs[0], synth.outputs[1]
 		synth.outputs[0] = 0
 		synth.outputs[1] = 0
@@ -3,14 +3,17 @@ package vm_test
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"maps"
 	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -109,6 +112,8 @@ var defaultUnits = map[string]sointu.Unit{
 	"compressor": {Type: "compressor", Parameters: map[string]int{"stereo": 0, "attack": 64, "release": 64, "invgain": 64, "threshold": 64, "ratio": 64}},
 	"send":       {Type: "send", Parameters: map[string]int{"stereo": 0, "amount": 128, "voice": 0, "unit": 0, "port": 0, "sendpop": 1}},
 	"sync":       {Type: "sync", Parameters: map[string]int{}},
+	"limiter":    {Type: "limiter", Parameters: map[string]int{"stereo": 1, "ceiling": 128, "release": 64, "lookahead": 32}},
+	"width":      {Type: "width", Parameters: map[string]int{"width": 64}},
 	"belleq":     {Type: "belleq", Parameters: map[string]int{"stereo": 0, "freq": 64, "bandwidth": 64, "gain": 96}},
 }
 
@@ -223,6 +228,141 @@ func TestStackBalancing(t *testing.T) {
 	}
 }
 
+func TestTuningAbsoluteFrequency(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tuning *sointu.Tuning
+		freq   int
+	}{
+		{"no tuning", nil, 220},
+		{"note 69 at 440 Hz", &sointu.Tuning{MiddleNote: 60, ReferenceNote: 69, ReferenceFreq: 440}, 440},
+		{"A4 at 432 Hz", &sointu.Tuning{MiddleNote: 60, ReferenceNote: 81, ReferenceFreq: 432}, 216},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			patch := sointu.Patch{sointu.Instrument{NumVoices: 1, Tuning: tc.tuning, Units: []sointu.Unit{
+				{Type: "oscillator", Parameters: map[string]int{"stereo": 0, "transpose": 64, "detune": 64, "phase": 0, "color": 64, "shape": 64, "gain": 128, "type": sointu.Sine}},
+				{Type: "out", Parameters: map[string]int{"stereo": 0, "gain": 128}},
+			}}}
+			synth, err := vm.GoSynther{}.Synth(patch, 120)
+			if err != nil {
+				t.Fatalf("could not create the synth: %v", err)
+			}
+			synth.Trigger(0, 69)
+			buffer := make(sointu.AudioBuffer, 44100)
+			if _, _, err := synth.Render(buffer, len(buffer)); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			crossings := 0 // one second, so the upward zero crossings are the frequency in Hz
+			for i := 1; i < len(buffer); i++ {
+				if buffer[i-1][0] < 0 && buffer[i][0] >= 0 {
+					crossings++
+				}
+			}
+			if crossings < tc.freq-1 || crossings > tc.freq+1 {
+				t.Errorf("note 69 should play at %v Hz, got %v Hz", tc.freq, crossings)
+			}
+		})
+	}
+}
+
+func TestLimiterStepStaysBelowCeiling(t *testing.T) {
+	for _, lookahead := range []int{0, 16, 128} {
+		t.Run(fmt.Sprintf("lookahead %v", lookahead), func(t *testing.T) {
+			// a sine jumping from silence to full amplitude, limited to half of
+			// it; the right channel is the left at half gain, so if the clipper
+			// touched any sample, the ratio of the channels would break
+			patch := sointu.Patch{sointu.Instrument{NumVoices: 1, Units: []sointu.Unit{
+				{Type: "envelope", Parameters: map[string]int{"stereo": 0, "attack": 0, "decay": 0, "sustain": 128, "release": 0, "gain": 128}},
+				{Type: "oscillator", Parameters: map[string]int{"stereo": 0, "transpose": 64, "detune": 64, "phase": 32, "color": 64, "shape": 64, "gain": 128, "type": sointu.Sine}},
+				{Type: "mulp", Parameters: map[string]int{"stereo": 0}},
+				{Type: "push", Parameters: map[string]int{"stereo": 0}},
+				{Type: "gain", Parameters: map[string]int{"stereo": 0, "gain": 64}},
+				{Type: "limiter", Parameters: map[string]int{"stereo": 1, "ceiling": 64, "release": 64, "lookahead": lookahead}},
+				{Type: "out", Parameters: map[string]int{"stereo": 1, "gain": 128}},
+			}}}
+			synth, err := vm.GoSynther{}.Synth(patch, 120)
+			if err != nil {
+				t.Fatalf("could not create the synth: %v", err)
+			}
+			synth.Trigger(0, 69)
+			buffer := make(sointu.AudioBuffer, 4410)
+			if _, _, err := synth.Render(buffer, len(buffer)); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			abs32 := func(x float32) float32 { return float32(math.Abs(float64(x))) }
+			peak := float32(0)
+			for i, frame := range buffer {
+				half, full := frame[0], frame[1]
+				peak = max(peak, abs32(full))
+				if abs32(full) > 0.5 || abs32(half) > 0.25+1e-6 {
+					t.Fatalf("frame %v: %v exceeds the ceiling", i, frame)
+				}
+				if abs32(half) > 1e-3 && abs32(full/half-2) > 1e-3 {
+					t.Fatalf("frame %v: %v was clipped", i, frame)
+				}
+			}
+			if peak < 0.49 {
+				t.Errorf("the output should reach the ceiling, got peak %v", peak)
+			}
+		})
+	}
+}
+
+func TestRenderSyncs(t *testing.T) {
+	_, myname, _, _ := runtime.Caller(0)
+	songBytes, err := ioutil.ReadFile(path.Join(path.Dir(myname), "..", "tests", "test_sync.yml"))
+	if err != nil {
+		t.Fatalf("cannot read the .yml file: %v", err)
+	}
+	var song sointu.Song
+	if err := yaml.Unmarshal(songBytes, &song); err != nil {
+		t.Fatalf("could not parse the .yml file: %v", err)
+	}
+	syncs, err := vm.RenderSyncs(song, vm.DefaultSyncRate, true)
+	if err != nil {
+		t.Fatalf("RenderSyncs failed: %v", err)
+	}
+	if expected := []string{"row", "instr0_sync0", "instr0_sync1"}; !reflect.DeepEqual(syncs.Names, expected) {
+		t.Fatalf("wrong sync names, got %v, expected %v", syncs.Names, expected)
+	}
+	expectedb, err := ioutil.ReadFile(path.Join(path.Dir(myname), "..", "tests", "expected_output", "test_sync_syncbuf.raw"))
+	if err != nil {
+		t.Fatalf("cannot read expected: %v", err)
+	}
+	expected := make([]float32, len(expectedb)/4)
+	if err := binary.Read(bytes.NewReader(expectedb), binary.LittleEndian, &expected); err != nil {
+		t.Fatalf("error converting expected buffer: %v", err)
+	}
+	var got []float32
+	for _, frame := range syncs.Frames {
+		got = append(got, frame...)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("sync buffer length mismatch, got %v, expected %v", len(got), len(expected))
+	}
+	for i, v := range expected {
+		if math.Abs(float64(v-got[i])) > errorThreshold {
+			t.Fatalf("sync buffer differs at %v, got %v, expected %v", i, got[i], v)
+		}
+	}
+	for format, suffixes := range map[string][]string{
+		"csv":    {".sync.csv"},
+		"json":   {".sync.json"},
+		"rocket": {"_instr0_sync0.track", "_instr0_sync1.track", "_row.track"},
+	} {
+		files, err := syncs.Files(format)
+		if err != nil {
+			t.Fatalf("Files(%v) failed: %v", format, err)
+		}
+		if got := slices.Sorted(maps.Keys(files)); !reflect.DeepEqual(got, suffixes) {
+			t.Errorf("wrong %v files, got %v, expected %v", format, got, suffixes)
+		}
+	}
+	if _, err := syncs.Files("xml"); err == nil {
+		t.Errorf("Files should fail with an unknown format")
+	}
+}
+
 func compareToRawFloat32(t *testing.T, buffer sointu.AudioBuffer, rawname string) {
 	_, filename, _, _ := runtime.Caller(0)
 	expectedb, err := ioutil.ReadFile(path.Join(path.Dir(filename), "..", "tests", "expected_output", rawname))
@@ -18,22 +18,24 @@ const (
 	opHold       = 14
 	opIn         = 15
 	opInvgain    = 16
-	opLoadnote   = 17
-	opLoadval    = 18
-	opMul        = 19
-	opMulp       = 20
-	opNoise      = 21
-	opOscillator = 22
-	opOut        = 23
-	opOutaux     = 24
-	opPan        = 25
-	opPop        = 26
-	opPush       = 27
-	opReceive    = 28
-	opSend       = 29
-	opSpeed      = 30
-	opSync       = 31
-	opXch        = 32
+	opLimiter    = 17
+	opLoadnote   = 18
+	opLoadval    = 19
+	opMul        = 20
+	opMulp       = 21
+	opNoise      = 22
+	opOscillator = 23
+	opOut        = 24
+	opOutaux     = 25
+	opPan        = 26
+	opPop        = 27
+	opPush       = 28
+	opReceive    = 29
+	opSend       = 30
+	opSpeed      = 31
+	opSync       = 32
+	opWidth      = 33
+	opXch        = 34
 )
 
-var transformCounts = [...]int{0, 0, 1, 3, 0, 5, 1, 1, 4, 1, 5, 2, 1, 1, 0, 1, 0, 1, 0, 0, 2, 6, 1, 2, 1, 0, 0, 0, 1, 0, 0, 0}
+var transformCounts = [...]int{0, 0, 1, 3, 0, 5, 1, 1, 4, 1, 5, 2, 1, 1, 0, 1, 2, 0, 1, 0, 0, 2, 6, 1, 2, 1, 0, 0, 0, 1, 0, 0, 1, 0}
@@ -0,0 +1,206 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vsariola/sointu"
+)
+
+type (
+	// Syncs are the values of the sync units of a song, rendered offline, for
+	// keyframing visuals against them without running the synth.
+	Syncs struct {
+		// Names of the syncs, e.g. "row" or "Kick_sync0". Unique and safe to
+		// be used in file names.
+		Names []string `json:"names"`
+		// Rate is the number of frames per second
+		Rate float64 `json:"rate"`
+		// Frames[i][j] is the value of sync j at time i/Rate seconds
+		Frames [][]float32 `json:"frames"`
+	}
+
+	syncSynther struct {
+		recorder func(syncs []float32)
+	}
+)
+
+// DefaultSyncRate is the rate the compiled players write the syncs: every 256
+// samples.
+const DefaultSyncRate = 44100.0 / 256
+
+// RenderSyncs renders the song with the Go VM and records the values of all
+// the sync units, rate times per second. If rowSync is true, the first sync is
+// the current fractional row, like in the players compiled with
+// Compiler.RowSync. With the DefaultSyncRate, the frames are the same as the
+// values written in the sync buffer by the compiled players.
+func RenderSyncs(song sointu.Song, rate float64, rowSync bool) (*Syncs, error) {
+	if rate <= 0 || rate > 44100 {
+		return nil, fmt.Errorf("sync rate should be between 0 and 44100, was %v", rate)
+	}
+	ret := &Syncs{Names: syncNames(song.Patch, rowSync), Rate: rate}
+	sample, samplesPerRow := 0, song.SamplesPerRow()
+	recorder := func(syncs []float32) {
+		if next := int(math.Round(float64(len(ret.Frames)) * 44100 / rate)); sample >= next {
+			frame := make([]float32, 0, len(ret.Names))
+			if rowSync {
+				frame = append(frame, float32(float64(sample%samplesPerRow)/float64(samplesPerRow)+float64(sample/samplesPerRow)))
+			}
+			ret.Frames = append(ret.Frames, append(frame, syncs...))
+		}
+		sample++
+	}
+	if _, err := sointu.Play(syncSynther{recorder: recorder}, song, nil); err != nil {
+		return nil, fmt.Errorf("could not render the syncs: %v", err)
+	}
+	return ret, nil
+}
+
+// syncNames names the syncs in the order the VM executes them: the sync units
+// of every voice of every instrument.
+func syncNames(patch sointu.Patch, rowSync bool) []string {
+	var ret []string
+	if rowSync {
+		ret = append(ret, "row")
+	}
+	for i, instr := range patch {
+		name := syncName(instr.Name)
+		if name == "" {
+			name = "instr" + strconv.Itoa(i)
+		}
+		for v := 0; v < instr.NumVoices; v++ {
+			n := 0
+			for _, unit := range instr.Units {
+				if unit.Type != "sync" || unit.Disabled {
+					continue
+				}
+				unitName := syncName(unit.Comment)
+				if unitName == "" {
+					unitName = "sync" + strconv.Itoa(n)
+				}
+				n++
+				if instr.NumVoices > 1 {
+					unitName += "_voice" + strconv.Itoa(v)
+				}
+				ret = append(ret, name+"_"+unitName)
+			}
+		}
+	}
+	for i, name := range ret { // make the names unique
+		for j := 0; j < i; j++ {
+			if ret[j] == name {
+				ret[i] = name + "_" + strconv.Itoa(i)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+func syncName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(s))
+}
+
+// CSV converts the syncs into comma separated values, with a header row and
+// the time in seconds as the first column.
+func (s *Syncs) CSV() []byte {
+	var b bytes.Buffer
+	b.WriteString("time")
+	for _, name := range s.Names {
+		b.WriteString("," + name)
+	}
+	b.WriteString("\n")
+	for i, frame := range s.Frames {
+		b.WriteString(strconv.FormatFloat(float64(i)/s.Rate, 'g', -1, 64))
+		for _, v := range frame {
+			b.WriteString("," + strconv.FormatFloat(float64(v), 'g', -1, 32))
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// JSON converts the syncs into a JSON object with the names, the rate and the
+// frames.
+func (s *Syncs) JSON() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// RocketTracks converts the syncs into GNU Rocket .track files, one per sync,
+// mapped by the sync names. Every frame is a linearly interpolated key, one per
+// Rocket row, so the rows per second of the demo should match the rate.
+func (s *Syncs) RocketTracks() (map[string][]byte, error) {
+	ret := make(map[string][]byte, len(s.Names))
+	for j, name := range s.Names {
+		var b bytes.Buffer
+		if err := binary.Write(&b, binary.LittleEndian, uint32(len(s.Frames))); err != nil {
+			return nil, fmt.Errorf("RocketTracks failed: %v", err)
+		}
+		for i, frame := range s.Frames {
+			key := struct {
+				Row   uint32
+				Value float32
+				Type  uint8 // 0 = step, 1 = linear, 2 = smooth, 3 = ramp
+			}{uint32(i), frame[j], 1}
+			if err := binary.Write(&b, binary.LittleEndian, key); err != nil {
+				return nil, fmt.Errorf("RocketTracks failed: %v", err)
+			}
+		}
+		ret[name] = b.Bytes()
+	}
+	return ret, nil
+}
+
+// SyncFormats are the formats in which Files can convert the syncs.
+var SyncFormats = []string{"csv", "json", "rocket"}
+
+// Files converts the syncs into files of the given format, one of SyncFormats.
+// The files are mapped by suffixes to be appended to the name of the song: csv
+// and json give a single file, with suffix ".sync.csv" or ".sync.json", and
+// rocket gives one .track file per sync, with suffix "_" + name + ".track".
+func (s *Syncs) Files(format string) (map[string][]byte, error) {
+	switch format {
+	case "csv":
+		return map[string][]byte{".sync.csv": s.CSV()}, nil
+	case "json":
+		b, err := s.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal the syncs as json: %v", err)
+		}
+		return map[string][]byte{".sync.json": b}, nil
+	case "rocket":
+		tracks, err := s.RocketTracks()
+		if err != nil {
+			return nil, err
+		}
+		ret := make(map[string][]byte, len(tracks))
+		for name, track := range tracks {
+			ret["_"+name+".track"] = track
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("unknown sync format %v; possible values: %v", format, strings.Join(SyncFormats, ", "))
+}
+
+func (s syncSynther) Name() string                 { return "Go" }
+func (s syncSynther) SupportsMultithreading() bool { return false }
+
+func (s syncSynther) Synth(patch sointu.Patch, bpm int) (sointu.Synth, error) {
+	synth, err := GoSynther{}.Synth(patch, bpm)
+	if err != nil {
+		return nil, err
+	}
+	synth.(*GoSynth).syncRecorder = s.recorder
+	return synth, nil
+}
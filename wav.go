@@ -0,0 +1,142 @@
+package sointu
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// decodedAudio is audio decoded from a file: interleaved samples of any number
+// of channels, at any sample rate, in the range -1 ... 1.
+type decodedAudio struct {
+	channels int
+	rate     int
+	data     []float32
+}
+
+// DecodeAudio reads a WAV or AIFF file and returns it as an AudioBuffer, which
+// can be e.g. compared to a rendered song. Supports 8, 16, 24 and 32-bit PCM
+// and 32 and 64-bit float samples. Mono files are copied to both channels and
+// of files with more than two channels, the first two channels are used. Files
+// with sample rates other than 44100 Hz are resampled; rates outside 1000 ...
+// 768000 Hz are rejected, as they are most likely corrupt headers and would
+// make the resampled buffer absurdly large or small.
+func DecodeAudio(r io.Reader) (AudioBuffer, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var a decodedAudio
+	switch {
+	case len(b) >= 12 && string(b[0:4]) == "RIFF" && string(b[8:12]) == "WAVE":
+		a, err = decodeWav(b)
+	case len(b) >= 12 && string(b[0:4]) == "FORM" && (string(b[8:12]) == "AIFF" || string(b[8:12]) == "AIFC"):
+		a, err = decodeAiff(b)
+	default:
+		return nil, errors.New("not a WAV or AIFF file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.rate < 1000 || a.rate > 768000 {
+		return nil, fmt.Errorf("unsupported sample rate %v Hz", a.rate)
+	}
+	return a.stereo().Resample(a.rate, sampleRate), nil
+}
+
+// decodeWav decodes the samples of a RIFF WAVE file.
+func decodeWav(b []byte) (decodedAudio, error) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return decodedAudio{}, errors.New("not a RIFF WAVE file")
+	}
+	var format, width, bits int
+	var ret decodedAudio
+	for pos := 12; pos+8 <= len(b); {
+		id := string(b[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(b[pos+4:]))
+		pos += 8
+		if size < 0 || pos+size > len(b) {
+			size = len(b) - pos // tolerate truncated files
+		}
+		chunk := b[pos : pos+size]
+		pos += size + size&1 // chunks are padded to even length
+		switch id {
+		case "fmt ":
+			if len(chunk) < 16 {
+				return decodedAudio{}, errors.New("fmt chunk too short")
+			}
+			format = int(binary.LittleEndian.Uint16(chunk[0:]))
+			ret.channels = int(binary.LittleEndian.Uint16(chunk[2:]))
+			ret.rate = int(binary.LittleEndian.Uint32(chunk[4:]))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:]))
+			if ret.channels > 0 { // the samples are left-justified in containers of blockAlign / channels bytes
+				width = int(binary.LittleEndian.Uint16(chunk[12:])) / ret.channels
+			}
+			if format == 0xFFFE && len(chunk) >= 26 { // WAVE_FORMAT_EXTENSIBLE, actual format is in the subformat GUID
+				format = int(binary.LittleEndian.Uint16(chunk[24:]))
+			}
+		case "data":
+			if ret.channels < 1 {
+				return decodedAudio{}, errors.New("data chunk before fmt chunk")
+			}
+			var err error
+			switch {
+			case format == 1 && width >= 1 && width <= 4:
+				ret.data, err = decodeSamples(chunk, width, binary.LittleEndian, false, width == 1) // 8-bit WAVs are unsigned
+			case format == 3 && (width == 4 || width == 8):
+				ret.data, err = decodeSamples(chunk, width, binary.LittleEndian, true, false)
+			default:
+				return decodedAudio{}, fmt.Errorf("unsupported WAV format %v with %v bits per sample", format, bits)
+			}
+			return ret, err
+		}
+	}
+	return decodedAudio{}, errors.New("no data chunk found")
+}
+
+// decodeSamples decodes the samples into floats in the range -1 ... 1. width
+// is the size of a sample in bytes. Integer samples are left-justified, so
+// e.g. 20-bit samples in 3 bytes are decoded like 24-bit samples.
+func decodeSamples(data []byte, width int, order binary.ByteOrder, float bool, unsigned bool) ([]float32, error) {
+	ret := make([]float32, len(data)/width)
+	for i := range ret {
+		s := data[i*width : (i+1)*width]
+		switch {
+		case float && width == 4:
+			ret[i] = math.Float32frombits(order.Uint32(s))
+		case float && width == 8:
+			ret[i] = float32(math.Float64frombits(order.Uint64(s)))
+		case float:
+			return nil, fmt.Errorf("unsupported float samples of %v bytes", width)
+		default:
+			var v uint32
+			for k := range s {
+				if order == binary.BigEndian {
+					v = v<<8 | uint32(s[k])
+				} else {
+					v |= uint32(s[k]) << (8 * k)
+				}
+			}
+			x := int32(v << (32 - 8*width))
+			if unsigned {
+				x ^= math.MinInt32
+			}
+			ret[i] = float32(x) / (1 << 31)
+		}
+	}
+	return ret, nil
+}
+
+// stereo converts the decoded audio to a stereo buffer, copying mono to both
+// channels and dropping the channels after the first two.
+func (a decodedAudio) stereo() AudioBuffer {
+	ret := make(AudioBuffer, len(a.data)/a.channels)
+	right := min(1, a.channels-1)
+	for i := range ret {
+		frame := a.data[i*a.channels:]
+		ret[i] = [2]float32{frame[0], frame[right]}
+	}
+	return ret
+}
@@ -0,0 +1,178 @@
+package sointu_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"testing"
+
+	"github.com/vsariola/sointu"
+	"github.com/vsariola/sointu/internal/rifftest"
+)
+
+var decodeTestSamples = []float64{0, 0.5, -0.5, -1, 0.25, -0.75}
+
+// encodeSamples encodes the samples as integers of the given width in bytes,
+// or as floats if float is true.
+func encodeSamples(samples []float64, width int, order binary.ByteOrder, float, unsigned bool) []byte {
+	var ret []byte
+	for _, s := range samples {
+		switch {
+		case float && width == 4:
+			ret, _ = binary.Append(ret, order, float32(s))
+		case float:
+			ret, _ = binary.Append(ret, order, s)
+		default:
+			scale := int64(1) << (8*width - 1)
+			v := uint32(min(int64(math.Round(s*float64(scale))), scale-1))
+			if unsigned {
+				v += uint32(scale)
+			}
+			b := make([]byte, 4)
+			binary.LittleEndian.PutUint32(b, v)
+			b = b[:width]
+			if order == binary.BigEndian {
+				for i, j := 0, width-1; i < j; i, j = i+1, j-1 {
+					b[i], b[j] = b[j], b[i]
+				}
+			}
+			ret = append(ret, b...)
+		}
+	}
+	return ret
+}
+
+// makeWav builds a WAV file with the given format tag, sample rate, channels
+// and bits per sample, around already encoded little-endian sample data.
+func makeWav(format, rate, channels, sampleBits int, data []byte) []byte {
+	return rifftest.Chunk("RIFF", []byte("WAVE"),
+		rifftest.Chunk("fmt ", rifftest.LE(uint16(format), uint16(channels), uint32(rate), uint32(rate*channels*sampleBits/8), uint16(channels*sampleBits/8), uint16(sampleBits))),
+		rifftest.Chunk("data", data))
+}
+
+// makeAiffC builds an AIFF-C file with the given compression type.
+func makeAiffC(compression string, rate, channels, sampleBits int, data []byte) []byte {
+	be := func(values ...any) []byte {
+		buf := new(bytes.Buffer)
+		for _, v := range values {
+			binary.Write(buf, binary.BigEndian, v)
+		}
+		return buf.Bytes()
+	}
+	exponent := bits.Len64(uint64(rate)) - 1
+	comm := be(uint16(channels), uint32(len(data)/(channels*((sampleBits+7)/8))), uint16(sampleBits),
+		uint16(16383+exponent), uint64(rate)<<(63-exponent), []byte(compression), []byte{0, 0})
+	chunk := func(id string, body []byte) []byte {
+		return append(be([]byte(id), uint32(len(body))), body...)
+	}
+	body := append([]byte("AIFC"), chunk("FVER", be(uint32(0xA2805140)))...)
+	body = append(body, chunk("COMM", comm)...)
+	body = append(body, chunk("SSND", append(make([]byte, 8), data...))...)
+	return chunk("FORM", body)
+}
+
+func TestDecodeAudioFormats(t *testing.T) {
+	// all files are at 44100 Hz so that the samples are not resampled
+	for _, tc := range []struct {
+		name  string
+		data  []byte
+		width int
+	}{
+		{"8-bit WAV", makeWav(1, 44100, 1, 8, encodeSamples(decodeTestSamples, 1, binary.LittleEndian, false, true)), 1},
+		{"16-bit WAV", makeWav(1, 44100, 1, 16, encodeSamples(decodeTestSamples, 2, binary.LittleEndian, false, false)), 2},
+		{"24-bit WAV", makeWav(1, 44100, 1, 24, encodeSamples(decodeTestSamples, 3, binary.LittleEndian, false, false)), 3},
+		{"32-bit WAV", makeWav(1, 44100, 1, 32, encodeSamples(decodeTestSamples, 4, binary.LittleEndian, false, false)), 4},
+		{"float WAV", makeWav(3, 44100, 1, 32, encodeSamples(decodeTestSamples, 4, binary.LittleEndian, true, false)), 0},
+		{"double WAV", makeWav(3, 44100, 1, 64, encodeSamples(decodeTestSamples, 8, binary.LittleEndian, true, false)), 0},
+		{"extensible WAV", rifftest.Chunk("RIFF", []byte("WAVE"),
+			rifftest.Chunk("fmt ", rifftest.LE(uint16(0xFFFE), uint16(1), uint32(44100), uint32(44100*3), uint16(3), uint16(24),
+				uint16(22), uint16(24), uint32(4), uint16(1), []byte("\x00\x00\x00\x00\x10\x00\x80\x00\x00\xAA\x00\x38\x9B\x71"))),
+			rifftest.Chunk("data", encodeSamples(decodeTestSamples, 3, binary.LittleEndian, false, false))), 3},
+		{"16-bit AIFF-C", makeAiffC("NONE", 44100, 1, 16, encodeSamples(decodeTestSamples, 2, binary.BigEndian, false, false)), 2},
+		{"little-endian AIFF-C", makeAiffC("sowt", 44100, 1, 16, encodeSamples(decodeTestSamples, 2, binary.LittleEndian, false, false)), 2},
+		{"float AIFF-C", makeAiffC("fl32", 44100, 1, 32, encodeSamples(decodeTestSamples, 4, binary.BigEndian, true, false)), 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := sointu.DecodeAudio(bytes.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("DecodeAudio failed: %v", err)
+			}
+			if len(buffer) != len(decodeTestSamples) {
+				t.Fatalf("expected %v frames, got %v", len(decodeTestSamples), len(buffer))
+			}
+			tol := 0.0
+			if tc.width > 0 {
+				tol = 1 / float64(int(1)<<(8*tc.width-1))
+			}
+			for i, e := range decodeTestSamples {
+				if math.Abs(float64(buffer[i][0])-e) > tol || buffer[i][1] != buffer[i][0] {
+					t.Errorf("frame %v: expected %v in both channels, got %v", i, e, buffer[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeAudioChannels(t *testing.T) {
+	// three channels: the third one should be dropped
+	data := encodeSamples([]float64{0.25, -0.25, 0.5, 0.125, -0.125, -0.5}, 2, binary.LittleEndian, false, false)
+	buffer, err := sointu.DecodeAudio(bytes.NewReader(makeWav(1, 44100, 3, 16, data)))
+	if err != nil {
+		t.Fatalf("DecodeAudio failed: %v", err)
+	}
+	if len(buffer) != 2 || buffer[0] != [2]float32{0.25, -0.25} || buffer[1] != [2]float32{0.125, -0.125} {
+		t.Errorf("expected the first two channels, got %v", buffer)
+	}
+}
+
+func TestDecodeAudioErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"unknown file", []byte("not an audio file at all")},
+		{"unsupported WAV format", makeWav(2, 44100, 1, 4, make([]byte, 8))},
+		{"unsupported AIFF-C compression", makeAiffC("ulaw", 44100, 1, 8, make([]byte, 8))},
+		{"zero sample rate", makeWav(1, 0, 1, 16, make([]byte, 8))},
+		{"too low sample rate", makeWav(1, 999, 1, 16, make([]byte, 8))},
+		{"too high sample rate", makeWav(1, 768001, 1, 16, make([]byte, 8))},
+		{"too high AIFF-C sample rate", makeAiffC("NONE", 1<<30, 1, 16, make([]byte, 8))},
+		{"no data", rifftest.Chunk("RIFF", []byte("WAVE"), rifftest.Chunk("fmt ", rifftest.LE(uint16(1), uint16(1), uint32(44100), uint32(88200), uint16(2), uint16(16))))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := sointu.DecodeAudio(bytes.NewReader(tc.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDecodeAudioResamples(t *testing.T) {
+	samples := make([]float64, 22050)
+	for i := range samples { // one second of 1 kHz sine at 22050 Hz
+		samples[i] = 0.5 * math.Sin(2*math.Pi*1000*float64(i)/22050)
+	}
+	buffer, err := sointu.DecodeAudio(bytes.NewReader(makeWav(1, 22050, 1, 16, encodeSamples(samples, 2, binary.LittleEndian, false, false))))
+	if err != nil {
+		t.Fatalf("DecodeAudio failed: %v", err)
+	}
+	if len(buffer) != 44100 {
+		t.Fatalf("expected one second at 44100 Hz, got %v frames", len(buffer))
+	}
+	crossings, peak := 0, 0.0
+	for i := 1; i < len(buffer); i++ {
+		if buffer[i-1][0] < 0 && buffer[i][0] >= 0 {
+			crossings++
+		}
+		if i > 1000 && i < len(buffer)-1000 { // away from the edges, where the kernel is truncated
+			peak = max(peak, math.Abs(float64(buffer[i][0])))
+		}
+	}
+	if crossings < 999 || crossings > 1001 {
+		t.Errorf("expected about 1000 periods after resampling, got %v", crossings)
+	}
+	if math.Abs(peak-0.5) > 0.01 {
+		t.Errorf("resampling should keep the amplitude of the sine, got peak %v", peak)
+	}
+}
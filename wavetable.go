@@ -0,0 +1,123 @@
+package sointu
+
+import (
+	"math"
+	"sync"
+)
+
+type (
+	// UserWavetable is a wavetable drawn by the user, which the wavetable
+	// oscillators can play instead of the built-in wavetables. A wavetable
+	// consists of one or more single-cycle waves, called frames, each
+	// WavetableFrameLength samples long, stored one after another in Data. The
+	// oscillator morphs between the frames.
+	UserWavetable struct {
+		Name string     `yaml:",omitempty"`
+		Data SampleData `yaml:",omitempty"`
+	}
+)
+
+// WavetableFrameLength is the number of samples in each frame (single-cycle
+// wave) of a wavetable.
+const WavetableFrameLength = 256
+
+// builtinWavetableAmps are the names of the built-in wavetables and the
+// amplitudes of their harmonics, from which the tables are built when first
+// needed.
+var builtinWavetableAmps = [...]struct {
+	name string
+	amp  func(frame, n int) float64
+}{
+	{"sine-saw", func(frame, n int) float64 {
+		if n > 1<<frame { // double the number of harmonics on every frame
+			return 0
+		}
+		return 1 / float64(n)
+	}},
+	{"pwm", func(frame, n int) float64 {
+		duty := 0.5 - float64(frame)*0.06 // pulse width from 50% down to 8%
+		return math.Sin(math.Pi*float64(n)*duty) / float64(n)
+	}},
+	{"formant", func(frame, n int) float64 {
+		peak := math.Exp2(1 + float64(frame)*0.5) // formant peak sweeping from 2nd to ~22nd harmonic
+		d := math.Log2(float64(n) / peak)
+		return math.Exp(-d * d * 4)
+	}},
+	{"square-tri", func(frame, n int) float64 {
+		if n%2 == 0 {
+			return 0
+		}
+		return 1 / math.Pow(float64(n), 1+float64(frame)/7) // odd harmonics, rolling off from square to triangle-like
+	}},
+}
+
+// NumBuiltinWavetables is the number of built-in wavetables: parameter
+// "wavetable" < NumBuiltinWavetables selects one of these, while larger values
+// select one of the user wavetables of the instrument.
+const NumBuiltinWavetables = len(builtinWavetableAmps)
+
+var (
+	builtinWavetablesOnce sync.Once
+	builtinWavetables     [NumBuiltinWavetables]UserWavetable
+)
+
+// BuiltinWavetables returns the wavetables that are always available to the
+// wavetable oscillators. Each built-in table has eight frames. The tables are
+// built on the first call, so that programs not using them do not pay for the
+// additive synthesis at startup. The data is shared and should not be
+// modified.
+func BuiltinWavetables() [NumBuiltinWavetables]UserWavetable {
+	builtinWavetablesOnce.Do(func() {
+		for i, w := range builtinWavetableAmps {
+			builtinWavetables[i] = UserWavetable{Name: w.name, Data: additiveWavetable(w.amp)}
+		}
+	})
+	return builtinWavetables
+}
+
+// NumFrames returns the number of frames in the wavetable.
+func (w *UserWavetable) NumFrames() int {
+	return len(w.Data) / WavetableFrameLength
+}
+
+// Copy makes a deep copy of a wavetable.
+func (w *UserWavetable) Copy() UserWavetable {
+	ret := *w
+	ret.Data = append(SampleData(nil), w.Data...)
+	return ret
+}
+
+// Wavetable returns the wavetable selected by the value v of the "wavetable"
+// parameter of an oscillator in this instrument, or false if there is no such
+// wavetable.
+func (instr *Instrument) Wavetable(v int) (UserWavetable, bool) {
+	if v >= 0 && v < NumBuiltinWavetables {
+		return BuiltinWavetables()[v], true
+	}
+	if i := v - NumBuiltinWavetables; i >= 0 && i < len(instr.Wavetables) {
+		return instr.Wavetables[i], true
+	}
+	return UserWavetable{}, false
+}
+
+// additiveWavetable builds an eight frame wavetable by summing sine harmonics,
+// amp giving the amplitude of the nth harmonic on each frame. The frames are
+// normalized to full scale.
+func additiveWavetable(amp func(frame, n int) float64) SampleData {
+	const numFrames = 8
+	ret := make(SampleData, numFrames*WavetableFrameLength)
+	for f := 0; f < numFrames; f++ {
+		var wave [WavetableFrameLength]float64
+		var peak float64
+		for i := range wave {
+			for n := 1; n < WavetableFrameLength/2; n++ {
+				wave[i] += amp(f, n) * math.Sin(2*math.Pi*float64(n*i)/WavetableFrameLength)
+			}
+			peak = max(peak, math.Abs(wave[i]))
+		}
+		for i, v := range wave {
+			ret[f*WavetableFrameLength+i] = int16(math.Round(v / peak * 32767))
+		}
+	}
+	return ret
+}